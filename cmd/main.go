@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
 
+	"botmanager/internal/app"
 	"botmanager/internal/config"
 	"botmanager/pkg/logger"
 	"botmanager/pkg/migrator"
@@ -39,4 +45,43 @@ func main() {
 	logger.Debug("debug mode is enabled")
 
 	log.Println("All migrations applied successfully!")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	application := app.NewApp(cfg, db)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- application.Run()
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			log.Fatalf("Server failed: %v", err)
+		}
+		return
+	case <-ctx.Done():
+	}
+
+	// second signal kills process at once
+	stop()
+
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := application.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("Shutdown failed: %v", err)
+	}
+
+	log.Println("Shutdown completed")
 }
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"fmt"
+
+	"botmanager/internal/config"
+	transporthttp "botmanager/internal/transport/http"
+)
+
+// buildAdminTokens binds admin API tokens of config to shops they manage.
+func buildAdminTokens(cfg *config.Config) (transporthttp.AdminTokens, error) {
+	tokens := transporthttp.AdminTokens{}
+	if cfg.Admin.APIToken != "" {
+		tokens[cfg.Admin.APIToken] = 0
+	}
+
+	for shopID, token := range cfg.Admin.ShopTokens {
+		// zero shop would make token admin of the instance
+		if shopID <= 0 {
+			return nil, fmt.Errorf("admin token of invalid shop %d", shopID)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("empty admin token of shop %d", shopID)
+		}
+		if _, exists := tokens[token]; exists {
+			return nil, fmt.Errorf("admin token of shop %d is not unique", shopID)
+		}
+		tokens[token] = shopID
+	}
+
+	return tokens, nil
+}
@@ -5,20 +5,34 @@
 package app
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"botmanager/internal/config"
+	"botmanager/internal/infrastructure/eventbus"
+	"botmanager/internal/manager"
+	"botmanager/internal/service"
+	"botmanager/internal/storage/postgres"
 	transporthttp "botmanager/internal/transport/http"
 	"botmanager/internal/transport/http/handler"
 	"botmanager/pkg/logger"
 )
 
 type App struct {
-	server *http.Server
+	server    *http.Server
+	bots      *manager.Manager
+	sweeper   *service.OrderSweeper
+	reconcile time.Duration
 }
 
-func NewApp(cfg *config.Config) *App {
+// NewApp builds application, bots saved in db are restored
+// before it serves requests.
+func NewApp(cfg *config.Config, db *sql.DB) *App {
 	logger, err := logger.Setup(cfg.Env)
 	if err != nil {
 		panic(err)
@@ -27,19 +41,84 @@ func NewApp(cfg *config.Config) *App {
 
 	slog.Info("application started")
 
-	orderService := buildOrderService(logger.Logger)
+	bus := eventbus.New(logger.Logger)
+	tx := postgres.NewTxManager(db)
 
-	handler := handler.NewOrderHandler(orderService)
-	router := transporthttp.NewRouter(handler)
+	orderService, sweeper := buildOrderService(cfg, db, tx, bus, logger.Logger)
+	users := service.NewUserService(postgres.NewUserRepository(db, logger.Logger), tx, bus, logger.Logger)
+	flow := buildCatalogFlow(db, orderService, logger.Logger)
+
+	shops := postgres.NewShopRepository(db, logger.Logger)
+
+	bots, webhook, err := buildBotManager(cfg, db, users, shops, flow, logger.Logger)
+	if err != nil {
+		panic(err)
+	}
+
+	// bots which could not be restored are reported,
+	// the rest is started anyway
+	if err := bots.Restore(context.Background()); err != nil {
+		logger.Logger.Error("failed to restore bots", "err", err)
+	}
+
+	// tokens wrapped by retired keys are moved to primary key
+	if err := bots.ReencryptTokens(context.Background()); err != nil {
+		logger.Logger.Error("failed to re-encrypt bot tokens", "err", err)
+	}
+
+	adminTokens, err := buildAdminTokens(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	orderHandler := handler.NewOrderHandler(orderService)
+	botHandler := handler.NewBotHandler(bots)
+	router := transporthttp.NewRouter(orderHandler, botHandler, webhook, adminTokens)
 
 	server := &http.Server{
 		Addr:    ":" + cfg.HTTP.Port,
 		Handler: router,
 	}
 
-	return &App{server: server}
+	return &App{
+		server:    server,
+		bots:      bots,
+		sweeper:   sweeper,
+		reconcile: cfg.Leases.ReconcileInterval,
+	}
 }
 
+// Run serves HTTP requests, expires unpaid orders and reconciles
+// bots with other replicas until Shutdown.
+//
+// Returns nil after Shutdown.
 func (a *App) Run() error {
-	return a.server.ListenAndServe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go a.sweeper.Run(ctx)
+	go a.bots.RunReconciler(ctx, a.reconcile)
+
+	if err := a.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops application within ctx deadline.
+//
+// HTTP server is drained first, so no webhook update
+// reaches bots being stopped, then bots are stopped.
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown http server: %w", err))
+	}
+
+	if err := a.bots.StopAll(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("stop bots: %w", err))
+	}
+
+	return errors.Join(errs...)
 }
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"botmanager/internal/config"
+	"botmanager/internal/infrastructure/telegram"
+	"botmanager/internal/manager"
+	"botmanager/internal/storage/memory"
+	"botmanager/internal/storage/postgres"
+	"botmanager/internal/transport/bot"
+	"botmanager/internal/transport/bot/catalog"
+	transporthttp "botmanager/internal/transport/http"
+	"botmanager/pkg/envelope"
+)
+
+// buildBotManager creates bot manager with runner selected by config.
+//
+// Registered bots are persisted in db with tokens encrypted by keyring
+// of config, offsets of polled updates are kept in db as well,
+// so restarted instance does not process updates twice.
+// Bots serve catalog flow to users resolved by users,
+// bots of shops disabled in shops do not serve.
+// Returned webhook handler is nil when bots use long polling.
+func buildBotManager(
+	cfg *config.Config,
+	db *sql.DB,
+	users bot.UserResolver,
+	shops bot.ShopLookup,
+	flow *catalog.Flow,
+	logger *slog.Logger,
+) (*manager.Manager, http.Handler, error) {
+	keyring, err := buildKeyring(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("token keyring: %w", err)
+	}
+
+	leases, owner, err := buildLeaseStore(cfg, db, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("bot leases: %w", err)
+	}
+
+	client := telegram.NewClient(cfg.Telegram.APIURL, nil)
+
+	handler := bot.NewRouter()
+	handler.Use(bot.Recovery(logger), bot.Logging(logger))
+
+	limits := telegram.WithRateLimits(cfg.Telegram.GlobalRate, cfg.Telegram.ChatRate)
+
+	var (
+		runner  manager.Runner
+		webhook http.Handler
+	)
+
+	if cfg.Telegram.WebhookURL != "" {
+		wh := telegram.NewWebhook(
+			client,
+			cfg.Telegram.WebhookURL+transporthttp.WebhookPath,
+			handler,
+			logger,
+			telegram.WithWebhookQueueOptions(limits),
+		)
+		runner = wh
+		webhook = wh
+	} else {
+		runner = telegram.NewPoller(
+			client,
+			postgres.NewOffsetStore(db, logger),
+			handler,
+			logger,
+			telegram.WithQueueOptions(limits),
+		)
+	}
+
+	m := manager.NewManager(
+		runner,
+		manager.WithRestartPolicy(manager.DefaultRestartPolicy()),
+		manager.WithTokenValidator(telegram.NewTokenValidator(client)),
+		manager.WithRepository(postgres.NewBotRepository(db, logger), keyring),
+		manager.WithLeases(leases, owner, cfg.Leases.TTL),
+		manager.WithLogger(logger),
+	)
+
+	// scope catalog of every bot to its storefront,
+	// users are registered in shop of the bot
+	handler.Use(bot.Storefront(m), bot.ShopEnabled(shops), bot.LoadUser(users))
+	flow.Register(handler)
+
+	return m, webhook, nil
+}
+
+// buildKeyring creates keyring encrypting bot tokens
+// with master keys of config.
+func buildKeyring(cfg *config.Config) (*envelope.Keyring, error) {
+	if cfg.Crypto.PrimaryKeyID == "" {
+		return nil, errors.New("TOKEN_PRIMARY_KEY_ID is not set")
+	}
+
+	keys, err := envelope.ParseKeys(cfg.Crypto.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope.NewKeyring(cfg.Crypto.PrimaryKeyID, keys)
+}
+
+// buildLeaseStore creates store of bot leases selected by config
+// and returns owner identifying this instance.
+func buildLeaseStore(cfg *config.Config, db *sql.DB, logger *slog.Logger) (manager.LeaseStore, string, error) {
+	owner := cfg.Leases.InstanceID
+	if owner == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, "", fmt.Errorf("instance id: %w", err)
+		}
+		owner = host
+	}
+
+	switch cfg.Leases.Store {
+	case "memory":
+		return memory.NewLeaseStore(), owner, nil
+	case "postgres":
+		return postgres.NewLeaseStore(db, logger), owner, nil
+	default:
+		return nil, "", fmt.Errorf("unknown lease store %q", cfg.Leases.Store)
+	}
+}
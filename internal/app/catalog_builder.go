@@ -0,0 +1,23 @@
+package app
+
+import (
+	"database/sql"
+	"log/slog"
+
+	"botmanager/internal/service"
+	"botmanager/internal/storage/postgres"
+	"botmanager/internal/transport/bot/catalog"
+)
+
+// buildCatalogFlow creates catalog conversation of bots
+// browsing catalog stored in db and buying with orders.
+func buildCatalogFlow(db *sql.DB, orders *service.OrderService, logger *slog.Logger) *catalog.Flow {
+	return catalog.NewFlow(
+		postgres.NewCityRepository(db, logger),
+		postgres.NewDistrictRepository(db, logger),
+		postgres.NewCategoryRepository(db, logger),
+		postgres.NewProductRepository(db, logger),
+		orders,
+		logger,
+	)
+}
@@ -1,43 +1,43 @@
 package app
 
 import (
-	"context"
+	"database/sql"
 	"log/slog"
-	"sync"
 
-	"botmanager/internal/domain"
-	"botmanager/internal/infrastructure/eventbus"
+	"botmanager/internal/config"
 	"botmanager/internal/service"
-	"botmanager/internal/storage/memory"
+	"botmanager/internal/storage/postgres"
 )
 
-func buildOrderService(logger *slog.Logger) *service.OrderService {
-	mu := &sync.Mutex{}
-	// repositories
-	orderRepo := memory.NewOrderRepository()
-	productRepo := memory.NewProductRepository(mu)
+// buildOrderService creates order service stored in db
+// and sweeper expiring its unpaid orders.
+func buildOrderService(
+	cfg *config.Config,
+	db *sql.DB,
+	tx service.TxManager,
+	bus service.EventBus,
+	logger *slog.Logger,
+) (*service.OrderService, *service.OrderSweeper) {
+	orderRepo := postgres.NewOrderRepository(db, logger)
 
-	// Seed data
-	{
-		p, err := domain.NewProduct(1, "test name", 1, "test distriction", "")
-		if err != nil {
-			panic(err)
-		}
-		_ = productRepo.Create(context.Background(), p)
-	}
-
-	// event bus
-	bus := eventbus.New(logger)
-
-	// transaction manager
-	txManager := memory.NewTxManager(mu)
-
-	return service.NewOrderService(
-		productRepo,
+	orders := service.NewOrderService(
+		postgres.NewProductRepository(db, logger),
 		orderRepo,
-		orderRepo,
-		txManager,
+		postgres.NewUserRepository(db, logger),
+		postgres.NewStockRepository(db, logger),
 		bus,
+		tx,
 		logger,
 	)
+	orders.SetPaymentTTL(cfg.Orders.PaymentTTL)
+
+	sweeper := service.NewOrderSweeper(
+		orderRepo,
+		orders,
+		cfg.Orders.SweepInterval,
+		cfg.Orders.SweepBatch,
+		logger,
+	)
+
+	return orders, sweeper
 }
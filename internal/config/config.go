@@ -3,6 +3,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -12,6 +13,63 @@ type Config struct {
 	HTTP struct {
 		Port string `env:"ENV_PORT" env-default:"8080"`
 	} `env:"HTTP"`
+	// ShutdownTimeout limits graceful shutdown of HTTP server and bots.
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" env-default:"15s"`
+	// Admin protects bot management API.
+	//
+	// Requests must carry "Authorization: Bearer <token>".
+	// APIToken manages bots of every shop, ShopTokens
+	// ("<shop id>:<token>,...") manage only bots of their shop.
+	// Without tokens the API rejects every request.
+	Admin struct {
+		APIToken   string         `env:"ADMIN_API_TOKEN"`
+		ShopTokens map[int]string `env:"ADMIN_SHOP_TOKENS"`
+	} `env:"ADMIN"`
+	// Telegram configures Bot API access.
+	//
+	// If WebhookURL (public base URL of HTTP server) is set,
+	// bots receive updates via webhooks, otherwise via long polling.
+	Telegram struct {
+		APIURL     string `env:"TELEGRAM_API_URL" env-default:"https://api.telegram.org"`
+		WebhookURL string `env:"TELEGRAM_WEBHOOK_URL"`
+		// GlobalRate and ChatRate limit messages sent by a bot
+		// per second, in total and to a single chat. Both must be positive.
+		GlobalRate float64 `env:"TELEGRAM_GLOBAL_RATE" env-default:"30"`
+		ChatRate   float64 `env:"TELEGRAM_CHAT_RATE"   env-default:"1"`
+	} `env:"TELEGRAM"`
+	// Leases configures ownership of bots by application replicas.
+	//
+	// With Store "postgres" replicas sharing database run every bot
+	// on exactly one of them, "memory" suits a single instance.
+	// InstanceID must be unique per replica, hostname by default.
+	// Every ReconcileInterval bots registered, resumed or removed
+	// by other replicas are picked up.
+	Leases struct {
+		Store             string        `env:"BOT_LEASE_STORE"        env-default:"memory"`
+		InstanceID        string        `env:"INSTANCE_ID"`
+		TTL               time.Duration `env:"BOT_LEASE_TTL"          env-default:"30s"`
+		ReconcileInterval time.Duration `env:"BOT_RECONCILE_INTERVAL" env-default:"30s"`
+	} `env:"LEASES"`
+	// Orders configures expiry of unpaid orders.
+	//
+	// Pending order not paid within PaymentTTL expires,
+	// its reservations are released by sweeper run every
+	// SweepInterval, at most SweepBatch orders per batch.
+	Orders struct {
+		PaymentTTL    time.Duration `env:"ORDER_PAYMENT_TTL"    env-default:"30m"`
+		SweepInterval time.Duration `env:"ORDER_SWEEP_INTERVAL" env-default:"1m"`
+		SweepBatch    int           `env:"ORDER_SWEEP_BATCH"    env-default:"100"`
+	} `env:"ORDERS"`
+	// Crypto holds master keys for bot tokens encryption.
+	//
+	// Keys format: "key_id:base64_key,key_id2:base64_key2".
+	// New tokens are encrypted with PrimaryKeyID key,
+	// others are kept to decrypt tokens after rotation.
+	// Application does not start without primary key.
+	Crypto struct {
+		PrimaryKeyID string            `env:"TOKEN_PRIMARY_KEY_ID"`
+		Keys         map[string]string `env:"TOKEN_KEYS"`
+	} `env:"CRYPTO"`
 }
 
 func MustLoad() *Config {
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"slices"
 )
 
 // CartStatus represents cart lifecycle state.
@@ -19,6 +20,8 @@ var (
 	ErrItemAlreadyExists  error = errors.New("item already exists in cart")
 	ErrInvalidItemQuality error = errors.New("invalid item quantity")
 	ErrCartEmpty          error = errors.New("cart is empty")
+	ErrCartNotFound       error = errors.New("cart not found")
+	ErrItemUnavailable    error = errors.New("item is no longer available")
 )
 
 // Cart represents user's shopping cart.
@@ -30,6 +33,7 @@ var (
 //   - Total is calculated from price snapshots.
 type Cart struct {
 	BaseAggregate
+	ShopOwned
 
 	id      int
 	userID  int
@@ -90,6 +94,38 @@ func (c *Cart) Items() []CartItem {
 	return result
 }
 
+// VariantID returns id of product variant.
+func (i CartItem) VariantID() int {
+	return i.variantID
+}
+
+// Quantity returns number of units.
+func (i CartItem) Quantity() int {
+	return i.quantity
+}
+
+// Price returns unit price snapshot taken when item was added.
+func (i CartItem) Price() int64 {
+	return i.price
+}
+
+// ---- SETTERS ----
+
+// SetID is intended for repository layer only.
+func (c *Cart) SetID(id int) {
+	c.id = id
+}
+
+// Clone returns copy of cart sharing no mutable state with it,
+// events not pulled yet are not copied.
+// It is intended for repository layer only.
+func (c *Cart) Clone() *Cart {
+	clone := *c
+	clone.items = slices.Clone(c.items)
+	clone.events = nil
+	return &clone
+}
+
 // AddItem adds new variant to cart.
 func (c *Cart) AddItem(variantID int, quantity int, price int64) error {
 	if c.status != CartStatusActive {
@@ -164,10 +200,10 @@ func (c *Cart) Total() int64 {
 	return total
 }
 
-// CheckOut closes cart.
+// Checkout closes cart.
 //
 // Fails if cart is empty or already checked out.
-func (c *Cart) Chackout() error {
+func (c *Cart) Checkout() error {
 	if c.status != CartStatusActive {
 		return ErrCartNotActive
 	}
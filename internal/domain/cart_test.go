@@ -61,14 +61,24 @@ func TestCart_Total(t *testing.T) {
 func TestCart_Checkout(t *testing.T) {
 	c, _ := NewCart(1)
 
-	err := c.Chackout()
+	err := c.Checkout()
 	require.ErrorIs(t, err, ErrCartEmpty)
 
 	_ = c.AddItem(1, 1, 100)
 
-	require.NoError(t, c.Chackout())
+	require.NoError(t, c.Checkout())
 	require.Equal(t, CartStatusCheckedOut, c.Status())
 
 	err = c.AddItem(2, 1, 100)
 	require.ErrorIs(t, err, ErrCartNotActive)
 }
+
+func TestCart_ItemGetters(t *testing.T) {
+	c, _ := NewCart(1)
+	_ = c.AddItem(5, 3, 250)
+
+	item := c.Items()[0]
+	require.Equal(t, 5, item.VariantID())
+	require.Equal(t, 3, item.Quantity())
+	require.Equal(t, int64(250), item.Price())
+}
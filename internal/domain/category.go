@@ -6,10 +6,15 @@ import (
 	"time"
 )
 
-var ErrInvalidCategoryName error = errors.New("invalid category name")
+var (
+	ErrInvalidCategoryName error = errors.New("invalid category name")
+	ErrCategoryNotFound    error = errors.New("category not found")
+)
 
 // Category represent category of the product.
 type Category struct {
+	ShopOwned
+
 	id          int
 	name        string
 	description string
@@ -34,6 +39,15 @@ func NewCategory(name string, description string) (*Category, error) {
 	}, nil
 }
 
+// NewCategoryFromDB restores category from database.
+func NewCategoryFromDB(id int, name string, description string) *Category {
+	return &Category{
+		id:          id,
+		name:        name,
+		description: description,
+	}
+}
+
 // ---- SETTERS ----
 
 // Rename renames the category.
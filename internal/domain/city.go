@@ -9,10 +9,13 @@ import (
 var (
 	ErrInvalidCityName error = errors.New("invalid city name")
 	ErrInvalidCityID   error = errors.New("invalid city id")
+	ErrCityNotFound    error = errors.New("city not found")
 )
 
 // City represent the city.
 type City struct {
+	ShopOwned
+
 	id        int
 	name      string
 	createdAt time.Time
@@ -34,6 +37,14 @@ func NewCity(name string) (*City, error) {
 	}, nil
 }
 
+// NewCityFromDB restores city from database.
+func NewCityFromDB(id int, name string) *City {
+	return &City{
+		id:   id,
+		name: name,
+	}
+}
+
 // ---- SETTERS ----
 
 // Rename renames the city
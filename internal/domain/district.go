@@ -6,7 +6,10 @@ import (
 	"time"
 )
 
-var ErrInvalidDistrictName error = errors.New("invalid district name")
+var (
+	ErrInvalidDistrictName error = errors.New("invalid district name")
+	ErrDistrictNotFound    error = errors.New("district not found")
+)
 
 // District represent the district of the city.
 type District struct {
@@ -38,6 +41,15 @@ func NewDistrict(cityID int, name string) (*District, error) {
 	}, nil
 }
 
+// NewDistrictFromDB restores district from database.
+func NewDistrictFromDB(id int, cityID int, name string) *District {
+	return &District{
+		id:     id,
+		cityID: cityID,
+		name:   name,
+	}
+}
+
 // ---- SETTERS ----
 
 // Rename renames the district
@@ -37,6 +37,11 @@
 //
 //   - buffering domain events (PullEvents)
 //
+//   - Shop
+//     Represents a tenant: an independent merchant hosted on the instance.
+//     Catalog, users, carts and orders embed ShopOwned to reference their shop,
+//     the shop of an entity is assigned once and never changes.
+//
 // Entities / Value Objects
 //
 //   - ProductVariant
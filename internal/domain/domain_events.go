@@ -19,6 +19,13 @@ const (
 	// Orders
 	NameOrderPaid      string = "order_paid"
 	NameOrderCancelled string = "order_cancelled"
+	NameOrderExpired   string = "order_expired"
+
+	NameOrderProcessingStarted string = "order_processing_started"
+	NameOrderReady             string = "order_ready"
+	NameOrderDelivered         string = "order_delivered"
+	NameOrderCompleted         string = "order_completed"
+	NameOrderRefunded          string = "order_refunded"
 
 	// Products
 	NameProductVariantAdded    string = "product_variant_added"
@@ -136,3 +143,163 @@ func (e OrderCancelled) Name() string {
 func (e OrderCancelled) OccurredAt() time.Time {
 	return e.at
 }
+
+// OrderExpired is emitted when pending order
+// was not paid in time and transitions to expired state.
+type OrderExpired struct {
+	OrderID int
+	at      time.Time
+}
+
+// NewOrderExpired creates OrderExpired event
+// with current timestamp.
+func NewOrderExpired(orderID int) OrderExpired {
+	return OrderExpired{
+		OrderID: orderID,
+		at:      time.Now(),
+	}
+}
+
+// Name returns event type identifier.
+func (e OrderExpired) Name() string {
+	return NameOrderExpired
+}
+
+// OccurredAt returns event timestamp.
+func (e OrderExpired) OccurredAt() time.Time {
+	return e.at
+}
+
+// OrderProcessingStarted is emitted when fulfilment of paid order starts.
+type OrderProcessingStarted struct {
+	OrderID int
+	at      time.Time
+}
+
+// NewOrderProcessingStarted creates OrderProcessingStarted event
+// with current timestamp.
+func NewOrderProcessingStarted(orderID int) OrderProcessingStarted {
+	return OrderProcessingStarted{
+		OrderID: orderID,
+		at:      time.Now(),
+	}
+}
+
+// Name returns event type identifier.
+func (e OrderProcessingStarted) Name() string {
+	return NameOrderProcessingStarted
+}
+
+// OccurredAt returns event timestamp.
+func (e OrderProcessingStarted) OccurredAt() time.Time {
+	return e.at
+}
+
+// OrderReady is emitted when order is ready for delivery or pickup.
+type OrderReady struct {
+	OrderID int
+	at      time.Time
+}
+
+// NewOrderReady creates OrderReady event
+// with current timestamp.
+func NewOrderReady(orderID int) OrderReady {
+	return OrderReady{
+		OrderID: orderID,
+		at:      time.Now(),
+	}
+}
+
+// Name returns event type identifier.
+func (e OrderReady) Name() string {
+	return NameOrderReady
+}
+
+// OccurredAt returns event timestamp.
+func (e OrderReady) OccurredAt() time.Time {
+	return e.at
+}
+
+// OrderDelivered is emitted when order is handed over to user.
+type OrderDelivered struct {
+	OrderID int
+	at      time.Time
+}
+
+// NewOrderDelivered creates OrderDelivered event
+// with current timestamp.
+func NewOrderDelivered(orderID int) OrderDelivered {
+	return OrderDelivered{
+		OrderID: orderID,
+		at:      time.Now(),
+	}
+}
+
+// Name returns event type identifier.
+func (e OrderDelivered) Name() string {
+	return NameOrderDelivered
+}
+
+// OccurredAt returns event timestamp.
+func (e OrderDelivered) OccurredAt() time.Time {
+	return e.at
+}
+
+// OrderCompleted is emitted when delivered order is closed.
+type OrderCompleted struct {
+	OrderID int
+	at      time.Time
+}
+
+// NewOrderCompleted creates OrderCompleted event
+// with current timestamp.
+func NewOrderCompleted(orderID int) OrderCompleted {
+	return OrderCompleted{
+		OrderID: orderID,
+		at:      time.Now(),
+	}
+}
+
+// Name returns event type identifier.
+func (e OrderCompleted) Name() string {
+	return NameOrderCompleted
+}
+
+// OccurredAt returns event timestamp.
+func (e OrderCompleted) OccurredAt() time.Time {
+	return e.at
+}
+
+// OrderRefunded is emitted when money of paid order
+// is returned to user, fully or partially.
+//
+// Full is set when the whole order total is refunded.
+type OrderRefunded struct {
+	OrderID int
+	Amount  int64
+	Reason  string
+	Full    bool
+	at      time.Time
+}
+
+// NewOrderRefunded creates OrderRefunded event
+// with current timestamp.
+func NewOrderRefunded(orderID int, amount int64, reason string, full bool) OrderRefunded {
+	return OrderRefunded{
+		OrderID: orderID,
+		Amount:  amount,
+		Reason:  reason,
+		Full:    full,
+		at:      time.Now(),
+	}
+}
+
+// Name returns event type identifier.
+func (e OrderRefunded) Name() string {
+	return NameOrderRefunded
+}
+
+// OccurredAt returns event timestamp.
+func (e OrderRefunded) OccurredAt() time.Time {
+	return e.at
+}
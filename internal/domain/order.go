@@ -2,6 +2,8 @@ package domain
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"time"
 )
 
@@ -12,6 +14,14 @@ const (
 	OrderStatusPending   OrderStatus = "pending"
 	OrderStatusPaid      OrderStatus = "paid"
 	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusExpired   OrderStatus = "expired"
+
+	// fulfilment of paid order
+	OrderStatusProcessing OrderStatus = "processing"
+	OrderStatusReady      OrderStatus = "ready"
+	OrderStatusDelivered  OrderStatus = "delivered"
+	OrderStatusCompleted  OrderStatus = "completed"
+	OrderStatusRefunded   OrderStatus = "refunded"
 )
 
 var (
@@ -23,6 +33,12 @@ var (
 	ErrOrderNotFound         error = errors.New("order not found")
 	ErrOrderUpdate           error = errors.New("failed to update order")
 	ErrOrderCancel           error = errors.New("failed to cancel order")
+	ErrOrderExpired          error = errors.New("order expired")
+	ErrOrderNotOverdue       error = errors.New("order payment is not overdue")
+	ErrInvalidPaymentTTL     error = errors.New("invalid payment ttl")
+	ErrOrderTransition       error = errors.New("order status transition is not allowed")
+	ErrInvalidRefundAmount   error = errors.New("invalid refund amount")
+	ErrRefundExceedsTotal    error = errors.New("refund exceeds order total")
 )
 
 // Order represents confirmed purchase intent.
@@ -30,11 +46,20 @@ var (
 // Business rules:
 //   - Created only with items.
 //   - Total is immutable after creation.
-//   - Only pending order can be paid or cancelled.
+//   - Only pending order can be paid, cancelled or expired.
 //   - Paid order cannot be cancelled.
 //   - Cancelled order cannot be paid.
+//   - Pending order with payment TTL expires once TTL is over,
+//     order without TTL never expires.
+//   - Paid order is fulfilled: processing, ready, delivered, completed.
+//   - Paid order may be refunded in parts, refunded amount
+//     never exceeds total. Order is refunded once total is refunded.
+//
+// Allowed status changes are declared by order state graph,
+// see OrderTransitions.
 type Order struct {
 	BaseAggregate
+	ShopOwned
 
 	id          int
 	userID      int
@@ -44,6 +69,17 @@ type Order struct {
 	createdAt   time.Time
 	paidAt      *time.Time
 	cancelledAt *time.Time
+	expiresAt   *time.Time
+	expiredAt   *time.Time
+
+	processingAt *time.Time
+	readyAt      *time.Time
+	deliveredAt  *time.Time
+	completedAt  *time.Time
+	refundedAt   *time.Time
+
+	// refunded is money returned to user so far.
+	refunded int64
 }
 
 // NewOrder creates new pending order.
@@ -74,6 +110,55 @@ func NewOrder(userID int, items []OrderItem, createdAt time.Time) (*Order, error
 	return o, nil
 }
 
+// OrderFromDB holds stored state of order, see NewOrderFromDB.
+type OrderFromDB struct {
+	ID       int
+	ShopID   int
+	UserID   int
+	Items    []OrderItem
+	Total    int64
+	Refunded int64
+	Status   OrderStatus
+	Version  int
+
+	CreatedAt    time.Time
+	ExpiresAt    *time.Time
+	PaidAt       *time.Time
+	CancelledAt  *time.Time
+	ExpiredAt    *time.Time
+	ProcessingAt *time.Time
+	ReadyAt      *time.Time
+	DeliveredAt  *time.Time
+	CompletedAt  *time.Time
+	RefundedAt   *time.Time
+}
+
+// NewOrderFromDB restores order from its stored state.
+func NewOrderFromDB(p OrderFromDB) *Order {
+	o := &Order{
+		ShopOwned:    ShopOwned{shopID: p.ShopID},
+		id:           p.ID,
+		userID:       p.UserID,
+		items:        p.Items,
+		total:        p.Total,
+		refunded:     p.Refunded,
+		status:       p.Status,
+		createdAt:    p.CreatedAt,
+		expiresAt:    p.ExpiresAt,
+		paidAt:       p.PaidAt,
+		cancelledAt:  p.CancelledAt,
+		expiredAt:    p.ExpiredAt,
+		processingAt: p.ProcessingAt,
+		readyAt:      p.ReadyAt,
+		deliveredAt:  p.DeliveredAt,
+		completedAt:  p.CompletedAt,
+		refundedAt:   p.RefundedAt,
+	}
+
+	o.setInitialVersion(p.Version)
+	return o
+}
+
 // ---- GETTERS ----
 
 // ID returns order id.
@@ -103,28 +188,59 @@ func (o *Order) Items() []OrderItem {
 	return result
 }
 
+// Refunded returns money returned to user so far.
+func (o *Order) Refunded() int64 {
+	return o.refunded
+}
+
+// RefundedItems returns items whose whole price is refunded.
+//
+// Refunded amount covers items in order of the order,
+// so items refunded by earlier refunds always come first.
+func (o *Order) RefundedItems() []OrderItem {
+	var (
+		left  = o.refunded
+		items []OrderItem
+	)
+	for _, item := range o.items {
+		if left < item.Total() {
+			break
+		}
+		left -= item.Total()
+		items = append(items, item)
+	}
+	return items
+}
+
 // Version returns aggregate version.
 func (o *Order) Version() int {
 	return o.version
 }
 
+// CreatedAt returns creation time.
+func (o *Order) CreatedAt() time.Time {
+	return o.createdAt
+}
+
+// ExpiresAt returns payment deadline of order
+// or nil if order never expires.
+func (o *Order) ExpiresAt() *time.Time {
+	return o.expiresAt
+}
+
+// IsOverdue reports whether pending order was not paid
+// until its payment deadline.
+func (o *Order) IsOverdue(now time.Time) bool {
+	return o.status == OrderStatusPending && o.expiresAt != nil && !now.Before(*o.expiresAt)
+}
+
 // MarkPaid marks order as paid.
 //
-// Fails if:
-//   - already paid
-//   - already cancelled
-//   - not pending
+// Fails if order is not pending, error wraps ErrOrderTransition
+// and reason of current status (see leavePending).
 func (o *Order) MarkPaid(now time.Time) error {
-	if o.status == OrderStatusPaid {
-		return ErrOrderAlreadyPaid
-	}
-
-	if o.status == OrderStatusCancelled {
-		return ErrOrderAlreadyCancelled
-	}
-
-	if o.status != OrderStatusPending {
-		return ErrOrderNotPending
+	if err := o.leavePending(OrderStatusPaid); err != nil {
+		return err
 	}
 
 	o.status = OrderStatusPaid
@@ -138,34 +254,240 @@ func (o *Order) MarkPaid(now time.Time) error {
 
 // Cancel cancels order.
 //
-// Fails if:
-//   - already cancelled
-//   - already paid
+// Fails if order is not pending, error wraps ErrOrderTransition
+// and reason of current status (see leavePending).
 func (o *Order) Cancel(now time.Time) error {
-	if o.status == OrderStatusCancelled {
-		return ErrOrderAlreadyCancelled
+	if err := o.leavePending(OrderStatusCancelled); err != nil {
+		return err
 	}
 
-	if o.status == OrderStatusPaid {
-		return ErrOrderAlreadyPaid
+	o.status = OrderStatusCancelled
+	o.cancelledAt = &now
+
+	o.incrementVersion()
+	o.addEvent(NewOrderCancelled(o.id))
+
+	return nil
+}
+
+// SetPaymentTTL sets time pending order waits for payment,
+// counted from its creation.
+//
+// Fails if ttl is not positive or order is not pending.
+func (o *Order) SetPaymentTTL(ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidPaymentTTL
 	}
 
 	if o.status != OrderStatusPending {
 		return ErrOrderNotPending
 	}
 
-	o.status = OrderStatusCancelled
-	o.cancelledAt = &now
+	expiresAt := o.createdAt.Add(ttl)
+	o.expiresAt = &expiresAt
+	return nil
+}
+
+// Expire closes pending order which was not paid in time.
+//
+// Fails if:
+//   - not pending, error wraps ErrOrderTransition and reason
+//     of current status (see leavePending)
+//   - payment deadline is not reached or order has no TTL
+func (o *Order) Expire(now time.Time) error {
+	if err := o.leavePending(OrderStatusExpired); err != nil {
+		return err
+	}
+
+	if !o.IsOverdue(now) {
+		return ErrOrderNotOverdue
+	}
+
+	o.status = OrderStatusExpired
+	o.expiredAt = &now
 
 	o.incrementVersion()
-	o.addEvent(NewOrderCancelled(o.id))
+	o.addEvent(NewOrderExpired(o.id))
+
+	return nil
+}
+
+// StartProcessing starts fulfilment of paid order.
+func (o *Order) StartProcessing(now time.Time) error {
+	if err := o.checkTransition(OrderStatusProcessing); err != nil {
+		return err
+	}
+
+	o.status = OrderStatusProcessing
+	o.processingAt = &now
+
+	o.incrementVersion()
+	o.addEvent(NewOrderProcessingStarted(o.id))
 
 	return nil
 }
 
+// MarkReady marks processed order as ready for delivery or pickup.
+func (o *Order) MarkReady(now time.Time) error {
+	if err := o.checkTransition(OrderStatusReady); err != nil {
+		return err
+	}
+
+	o.status = OrderStatusReady
+	o.readyAt = &now
+
+	o.incrementVersion()
+	o.addEvent(NewOrderReady(o.id))
+
+	return nil
+}
+
+// MarkDelivered marks ready order as handed over to user.
+func (o *Order) MarkDelivered(now time.Time) error {
+	if err := o.checkTransition(OrderStatusDelivered); err != nil {
+		return err
+	}
+
+	o.status = OrderStatusDelivered
+	o.deliveredAt = &now
+
+	o.incrementVersion()
+	o.addEvent(NewOrderDelivered(o.id))
+
+	return nil
+}
+
+// Complete closes delivered order.
+func (o *Order) Complete(now time.Time) error {
+	if err := o.checkTransition(OrderStatusCompleted); err != nil {
+		return err
+	}
+
+	o.status = OrderStatusCompleted
+	o.completedAt = &now
+
+	o.incrementVersion()
+	o.addEvent(NewOrderCompleted(o.id))
+
+	return nil
+}
+
+// MarkRefunded marks paid order as refunded,
+// the rest of total not refunded yet is counted as refunded.
+//
+// Fails if order was not paid or is already refunded.
+func (o *Order) MarkRefunded(now time.Time) error {
+	return o.Refund(o.total-o.refunded, "", now)
+}
+
+// Refund returns amount of paid order to user.
+//
+// Order becomes refunded when refunded amount reaches total,
+// smaller refunds keep its status. Items covered by refunded
+// amount are reported by RefundedItems.
+//
+// Fails if:
+//   - order was not paid or is already refunded
+//   - amount <= 0
+//   - refunded amount would exceed total
+func (o *Order) Refund(amount int64, reason string, now time.Time) error {
+	if err := o.checkTransition(OrderStatusRefunded); err != nil {
+		return err
+	}
+
+	if amount <= 0 {
+		return ErrInvalidRefundAmount
+	}
+
+	if o.refunded+amount > o.total {
+		return ErrRefundExceedsTotal
+	}
+
+	o.refunded += amount
+
+	full := o.refunded == o.total
+	if full {
+		o.status = OrderStatusRefunded
+		o.refundedAt = &now
+	}
+
+	o.incrementVersion()
+	o.addEvent(NewOrderRefunded(o.id, amount, reason, full))
+
+	return nil
+}
+
+// StatusChangedAt returns time order entered status
+// or nil if it never did.
+func (o *Order) StatusChangedAt(status OrderStatus) *time.Time {
+	switch status {
+	case OrderStatusPending:
+		return &o.createdAt
+	case OrderStatusPaid:
+		return o.paidAt
+	case OrderStatusCancelled:
+		return o.cancelledAt
+	case OrderStatusExpired:
+		return o.expiredAt
+	case OrderStatusProcessing:
+		return o.processingAt
+	case OrderStatusReady:
+		return o.readyAt
+	case OrderStatusDelivered:
+		return o.deliveredAt
+	case OrderStatusCompleted:
+		return o.completedAt
+	case OrderStatusRefunded:
+		return o.refundedAt
+	}
+	return nil
+}
+
+// checkTransition fails if order state graph
+// does not allow change of current status to next.
+func (o *Order) checkTransition(next OrderStatus) error {
+	if !o.status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s to %s", ErrOrderTransition, o.status, next)
+	}
+	return nil
+}
+
+// leavePending is checkTransition of pending order to next.
+//
+// Besides ErrOrderTransition error wraps reason order is not pending:
+// ErrOrderAlreadyPaid, ErrOrderAlreadyCancelled, ErrOrderExpired
+// or ErrOrderNotPending for the rest of statuses.
+func (o *Order) leavePending(next OrderStatus) error {
+	err := o.checkTransition(next)
+	if err == nil {
+		return nil
+	}
+
+	reason := ErrOrderNotPending
+	switch o.status {
+	case OrderStatusPaid:
+		reason = ErrOrderAlreadyPaid
+	case OrderStatusCancelled:
+		reason = ErrOrderAlreadyCancelled
+	case OrderStatusExpired:
+		reason = ErrOrderExpired
+	}
+	return fmt.Errorf("%w: %w", reason, err)
+}
+
 // ---- SETTERS ----
 
 // SetID is intended for repository layer only.
 func (o *Order) SetID(id int) {
 	o.id = id
 }
+
+// Clone returns copy of order sharing no mutable state with it,
+// events not pulled yet are not copied.
+// It is intended for repository layer only.
+func (o *Order) Clone() *Order {
+	c := *o
+	c.items = slices.Clone(o.items)
+	c.events = nil
+	return &c
+}
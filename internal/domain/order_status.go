@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+)
+
+// orderTransitions is the state graph of order,
+// every status change of Order must be declared here.
+//
+// Statuses without outgoing transitions are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:    {OrderStatusPaid, OrderStatusCancelled, OrderStatusExpired},
+	OrderStatusPaid:       {OrderStatusProcessing, OrderStatusRefunded},
+	OrderStatusProcessing: {OrderStatusReady, OrderStatusRefunded},
+	OrderStatusReady:      {OrderStatusDelivered, OrderStatusRefunded},
+	OrderStatusDelivered:  {OrderStatusCompleted, OrderStatusRefunded},
+	OrderStatusCompleted:  {OrderStatusRefunded},
+	OrderStatusCancelled:  nil,
+	OrderStatusExpired:    nil,
+	OrderStatusRefunded:   nil,
+}
+
+// OrderTransition is an edge of order state graph.
+type OrderTransition struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+// OrderStatuses returns all statuses of order, sorted.
+func OrderStatuses() []OrderStatus {
+	statuses := make([]OrderStatus, 0, len(orderTransitions))
+	for status := range orderTransitions {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+	return statuses
+}
+
+// OrderTransitions returns all allowed status changes of order,
+// sorted by source and target status.
+func OrderTransitions() []OrderTransition {
+	var transitions []OrderTransition
+	for from, targets := range orderTransitions {
+		for _, to := range targets {
+			transitions = append(transitions, OrderTransition{From: from, To: to})
+		}
+	}
+
+	slices.SortFunc(transitions, func(a, b OrderTransition) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+	return transitions
+}
+
+// CanTransitionTo reports whether order in status s may change to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return slices.Contains(orderTransitions[s], next)
+}
+
+// IsFinal reports whether order in status s never changes.
+func (s OrderStatus) IsFinal() bool {
+	return len(orderTransitions[s]) == 0
+}
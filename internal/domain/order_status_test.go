@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderTransitions_Graph(t *testing.T) {
+	statuses := OrderStatuses()
+
+	// every target is declared status
+	for _, tr := range OrderTransitions() {
+		require.Contains(t, statuses, tr.To, "transition %s -> %s", tr.From, tr.To)
+		require.NotEqual(t, tr.From, tr.To)
+	}
+
+	// every status is reachable from pending
+	reached := map[OrderStatus]bool{OrderStatusPending: true}
+	queue := []OrderStatus{OrderStatusPending}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, tr := range OrderTransitions() {
+			if tr.From == from && !reached[tr.To] {
+				reached[tr.To] = true
+				queue = append(queue, tr.To)
+			}
+		}
+	}
+	for _, status := range statuses {
+		require.True(t, reached[status], "status %s is not reachable", status)
+	}
+
+	for _, status := range []OrderStatus{OrderStatusCancelled, OrderStatusExpired, OrderStatusRefunded} {
+		require.True(t, status.IsFinal(), "status %s", status)
+	}
+	require.False(t, OrderStatusCompleted.IsFinal())
+}
+
+func TestOrderStatus_CanTransitionTo(t *testing.T) {
+	require.True(t, OrderStatusPaid.CanTransitionTo(OrderStatusProcessing))
+	require.True(t, OrderStatusDelivered.CanTransitionTo(OrderStatusRefunded))
+	require.False(t, OrderStatusPending.CanTransitionTo(OrderStatusProcessing))
+	require.False(t, OrderStatusRefunded.CanTransitionTo(OrderStatusPaid))
+}
+
+// TestOrder_TransitionMethods checks that every status change method
+// of Order follows state graph for every pair of statuses.
+func TestOrder_TransitionMethods(t *testing.T) {
+	createdAt := time.Now().Add(-time.Hour)
+
+	methods := map[OrderStatus]func(o *Order) error{
+		OrderStatusPaid:       func(o *Order) error { return o.MarkPaid(time.Now()) },
+		OrderStatusCancelled:  func(o *Order) error { return o.Cancel(time.Now()) },
+		OrderStatusExpired:    func(o *Order) error { return o.Expire(time.Now()) },
+		OrderStatusProcessing: func(o *Order) error { return o.StartProcessing(time.Now()) },
+		OrderStatusReady:      func(o *Order) error { return o.MarkReady(time.Now()) },
+		OrderStatusDelivered:  func(o *Order) error { return o.MarkDelivered(time.Now()) },
+		OrderStatusCompleted:  func(o *Order) error { return o.Complete(time.Now()) },
+		OrderStatusRefunded:   func(o *Order) error { return o.MarkRefunded(time.Now()) },
+	}
+
+	for _, from := range OrderStatuses() {
+		for _, to := range OrderStatuses() {
+			method, ok := methods[to]
+			if !ok {
+				// no method changes order back to pending
+				require.False(t, from.CanTransitionTo(to), "transition %s -> %s", from, to)
+				continue
+			}
+
+			// overdue order, so that Expire depends on status only
+			o, err := NewOrder(1, []OrderItem{{variantID: 1, quantity: 1, unitPrice: 100}}, createdAt)
+			require.NoError(t, err)
+			require.NoError(t, o.SetPaymentTTL(time.Minute))
+			o.status = from
+
+			err = method(o)
+			if from.CanTransitionTo(to) {
+				require.NoError(t, err, "transition %s -> %s", from, to)
+				require.Equal(t, to, o.Status())
+			} else {
+				require.ErrorIs(t, err, ErrOrderTransition, "transition %s -> %s", from, to)
+				require.Equal(t, from, o.Status())
+			}
+		}
+	}
+}
@@ -24,6 +24,32 @@ func TestNewOrder(t *testing.T) {
 	require.Equal(t, OrderStatusPending, o.Status())
 }
 
+func TestNewOrderFromDB(t *testing.T) {
+	paidAt := time.Now()
+
+	o := NewOrderFromDB(OrderFromDB{
+		ID:       7,
+		ShopID:   2,
+		UserID:   1,
+		Items:    []OrderItem{{variantID: 1, quantity: 1, unitPrice: 100}},
+		Total:    100,
+		Status:   OrderStatusPaid,
+		Version:  3,
+		PaidAt:   &paidAt,
+		Refunded: 40,
+	})
+
+	require.Equal(t, 7, o.ID())
+	require.Equal(t, 2, o.ShopID())
+	require.Equal(t, 3, o.Version())
+	require.Equal(t, &paidAt, o.StatusChangedAt(OrderStatusPaid))
+	require.Empty(t, o.PullEvents())
+
+	// restored order keeps its rules
+	require.ErrorIs(t, o.Cancel(time.Now()), ErrOrderAlreadyPaid)
+	require.ErrorIs(t, o.Refund(70, "", time.Now()), ErrRefundExceedsTotal)
+}
+
 func TestOrder_MarkPaid(t *testing.T) {
 	items := []OrderItem{
 		{variantID: 1, quantity: 1, unitPrice: 100},
@@ -99,3 +125,196 @@ func TestOrder_CannotCancelPaid(t *testing.T) {
 	err := o.Cancel(time.Now())
 	require.ErrorIs(t, err, ErrOrderAlreadyPaid)
 }
+
+func TestOrder_SetPaymentTTL(t *testing.T) {
+	items := []OrderItem{
+		{variantID: 1, quantity: 1, unitPrice: 100},
+	}
+
+	createdAt := time.Now()
+	o, _ := NewOrder(1, items, createdAt)
+	require.Nil(t, o.ExpiresAt())
+
+	require.ErrorIs(t, o.SetPaymentTTL(0), ErrInvalidPaymentTTL)
+
+	require.NoError(t, o.SetPaymentTTL(time.Minute))
+	require.Equal(t, createdAt.Add(time.Minute), *o.ExpiresAt())
+
+	_ = o.MarkPaid(time.Now())
+	require.ErrorIs(t, o.SetPaymentTTL(time.Minute), ErrOrderNotPending)
+}
+
+func TestOrder_Expire(t *testing.T) {
+	items := []OrderItem{
+		{variantID: 1, quantity: 1, unitPrice: 100},
+	}
+
+	createdAt := time.Now()
+	o, _ := NewOrder(1, items, createdAt)
+
+	// order without TTL never expires
+	require.ErrorIs(t, o.Expire(createdAt.Add(time.Hour)), ErrOrderNotOverdue)
+
+	require.NoError(t, o.SetPaymentTTL(time.Minute))
+	require.False(t, o.IsOverdue(createdAt.Add(time.Second)))
+	require.ErrorIs(t, o.Expire(createdAt.Add(time.Second)), ErrOrderNotOverdue)
+
+	require.True(t, o.IsOverdue(createdAt.Add(time.Minute)))
+	require.NoError(t, o.Expire(createdAt.Add(time.Minute)))
+	require.Equal(t, OrderStatusExpired, o.Status())
+	require.Equal(t, 2, o.Version())
+
+	events := o.PullEvents()
+	require.Len(t, events, 1)
+	require.Equal(t, NameOrderExpired, events[0].Name())
+
+	require.ErrorIs(t, o.Expire(createdAt.Add(time.Hour)), ErrOrderExpired)
+	require.ErrorIs(t, o.MarkPaid(time.Now()), ErrOrderExpired)
+	require.ErrorIs(t, o.Cancel(time.Now()), ErrOrderExpired)
+}
+
+func TestOrder_CannotExpirePaid(t *testing.T) {
+	items := []OrderItem{
+		{variantID: 1, quantity: 1, unitPrice: 100},
+	}
+
+	createdAt := time.Now()
+	o, _ := NewOrder(1, items, createdAt)
+	_ = o.SetPaymentTTL(time.Minute)
+	_ = o.MarkPaid(createdAt)
+
+	require.False(t, o.IsOverdue(createdAt.Add(time.Hour)))
+	err := o.Expire(createdAt.Add(time.Hour))
+	require.ErrorIs(t, err, ErrOrderAlreadyPaid)
+	require.ErrorIs(t, err, ErrOrderTransition)
+}
+
+func TestOrder_Fulfilment(t *testing.T) {
+	items := []OrderItem{
+		{variantID: 1, quantity: 1, unitPrice: 100},
+	}
+
+	o, _ := NewOrder(1, items, time.Now())
+
+	err := o.StartProcessing(time.Now())
+	require.ErrorIs(t, err, ErrOrderTransition)
+
+	require.NoError(t, o.MarkPaid(time.Now()))
+	require.NoError(t, o.StartProcessing(time.Now()))
+	require.ErrorIs(t, o.Complete(time.Now()), ErrOrderTransition)
+	require.NoError(t, o.MarkReady(time.Now()))
+	require.NoError(t, o.MarkDelivered(time.Now()))
+
+	completedAt := time.Now()
+	require.NoError(t, o.Complete(completedAt))
+	require.Equal(t, OrderStatusCompleted, o.Status())
+	require.Equal(t, completedAt, *o.StatusChangedAt(OrderStatusCompleted))
+	require.Nil(t, o.StatusChangedAt(OrderStatusRefunded))
+	require.Equal(t, 6, o.Version())
+
+	var names []string
+	for _, e := range o.PullEvents() {
+		names = append(names, e.Name())
+	}
+	require.Equal(t, []string{
+		NameOrderPaid,
+		NameOrderProcessingStarted,
+		NameOrderReady,
+		NameOrderDelivered,
+		NameOrderCompleted,
+	}, names)
+
+	require.ErrorIs(t, o.Cancel(time.Now()), ErrOrderNotPending)
+}
+
+func TestOrder_MarkRefunded(t *testing.T) {
+	items := []OrderItem{
+		{variantID: 1, quantity: 1, unitPrice: 100},
+	}
+
+	o, _ := NewOrder(1, items, time.Now())
+	require.ErrorIs(t, o.MarkRefunded(time.Now()), ErrOrderTransition)
+
+	_ = o.MarkPaid(time.Now())
+	_ = o.StartProcessing(time.Now())
+	require.NoError(t, o.MarkRefunded(time.Now()))
+	require.Equal(t, OrderStatusRefunded, o.Status())
+
+	require.ErrorIs(t, o.MarkRefunded(time.Now()), ErrOrderTransition)
+	require.ErrorIs(t, o.MarkReady(time.Now()), ErrOrderTransition)
+}
+
+func TestOrder_Refund(t *testing.T) {
+	items := []OrderItem{
+		{variantID: 1, quantity: 2, unitPrice: 100},
+	}
+
+	o, _ := NewOrder(1, items, time.Now())
+	require.ErrorIs(t, o.Refund(50, "damaged", time.Now()), ErrOrderTransition)
+
+	_ = o.MarkPaid(time.Now())
+	_ = o.PullEvents()
+
+	require.ErrorIs(t, o.Refund(0, "damaged", time.Now()), ErrInvalidRefundAmount)
+	require.ErrorIs(t, o.Refund(201, "damaged", time.Now()), ErrRefundExceedsTotal)
+
+	require.NoError(t, o.Refund(50, "damaged", time.Now()))
+	require.Equal(t, OrderStatusPaid, o.Status())
+	require.Equal(t, int64(50), o.Refunded())
+
+	require.ErrorIs(t, o.Refund(151, "damaged", time.Now()), ErrRefundExceedsTotal)
+
+	require.NoError(t, o.Refund(150, "not delivered", time.Now()))
+	require.Equal(t, OrderStatusRefunded, o.Status())
+	require.Equal(t, int64(200), o.Refunded())
+	require.NotNil(t, o.StatusChangedAt(OrderStatusRefunded))
+
+	events := o.PullEvents()
+	require.Len(t, events, 2)
+	require.Equal(t, int64(50), events[0].(OrderRefunded).Amount)
+	require.False(t, events[0].(OrderRefunded).Full)
+	require.True(t, events[1].(OrderRefunded).Full)
+
+	require.ErrorIs(t, o.Refund(1, "damaged", time.Now()), ErrOrderTransition)
+}
+
+func TestOrder_MarkRefundedAfterPartialRefund(t *testing.T) {
+	items := []OrderItem{
+		{variantID: 1, quantity: 2, unitPrice: 100},
+	}
+
+	o, _ := NewOrder(1, items, time.Now())
+	_ = o.MarkPaid(time.Now())
+	_ = o.Refund(50, "damaged", time.Now())
+	_ = o.PullEvents()
+
+	require.NoError(t, o.MarkRefunded(time.Now()))
+	require.Equal(t, OrderStatusRefunded, o.Status())
+
+	events := o.PullEvents()
+	require.Len(t, events, 1)
+	require.Equal(t, int64(150), events[0].(OrderRefunded).Amount)
+}
+
+func TestOrder_RefundedItems(t *testing.T) {
+	items := []OrderItem{
+		{variantID: 1, quantity: 1, unitPrice: 150},
+		{variantID: 2, quantity: 2, unitPrice: 100},
+	}
+
+	o, _ := NewOrder(1, items, time.Now())
+	_ = o.MarkPaid(time.Now())
+	require.Empty(t, o.RefundedItems())
+
+	require.NoError(t, o.Refund(100, "damaged", time.Now()))
+	require.Empty(t, o.RefundedItems())
+
+	require.NoError(t, o.Refund(50, "damaged", time.Now()))
+	require.Equal(t, items[:1], o.RefundedItems())
+
+	require.NoError(t, o.Refund(199, "damaged", time.Now()))
+	require.Equal(t, items[:1], o.RefundedItems())
+
+	require.NoError(t, o.Refund(1, "damaged", time.Now()))
+	require.Equal(t, items, o.RefundedItems())
+}
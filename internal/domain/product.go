@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"slices"
 	"strings"
 	"time"
 )
@@ -17,6 +18,7 @@ var (
 // Product represents the product.
 type Product struct {
 	BaseAggregate
+	ShopOwned
 
 	id          int
 	categoryID  *int
@@ -170,6 +172,19 @@ func (p *Product) SetID(id int) {
 	p.id = id
 }
 
+// Clone returns copy of product sharing no mutable state with it,
+// events not pulled yet are not copied.
+// It is intended for repository layer only.
+func (p *Product) Clone() *Product {
+	c := *p
+	c.variants = slices.Clone(p.variants)
+	for i := range c.variants {
+		c.variants[i].events = nil
+	}
+	c.events = nil
+	return &c
+}
+
 // SetVariantID sets variant id.
 func (p *Product) SetVariantID(index int, id int) {
 	if index >= 0 && index < len(p.variants) {
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidShopName     error = errors.New("invalid shop name")
+	ErrShopNotFound        error = errors.New("shop not found")
+	ErrShopDisabled        error = errors.New("shop is disabled")
+	ErrShopAlreadyAssigned error = errors.New("entity already belongs to another shop")
+	ErrInvalidShopID       error = errors.New("invalid shop id")
+)
+
+// Shop is a tenant: an independent merchant hosted on the instance.
+//
+// Bots, catalog, users and orders belong to a shop,
+// disabled shop keeps its data but must not be served.
+type Shop struct {
+	BaseAggregate
+
+	id        int
+	name      string
+	isEnabled bool
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+// NewShop creates a new enabled shop.
+// Returns error if name is empty.
+func NewShop(name string) (*Shop, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidShopName
+	}
+
+	now := time.Now()
+	s := &Shop{
+		name:      name,
+		isEnabled: true,
+		createdAt: now,
+		updatedAt: now,
+	}
+
+	s.setInitialVersion(1)
+	return s, nil
+}
+
+// NewShopFromDB restores shop loaded by repository.
+func NewShopFromDB(id int, name string, isEnabled bool, version int, createdAt, updatedAt time.Time) *Shop {
+	s := &Shop{
+		id:        id,
+		name:      name,
+		isEnabled: isEnabled,
+		createdAt: createdAt,
+		updatedAt: updatedAt,
+	}
+
+	s.setInitialVersion(version)
+	return s
+}
+
+// ---- SETTERS ----
+
+// Rename renames the shop
+// or returns error if new name is empty.
+func (s *Shop) Rename(newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return ErrInvalidShopName
+	}
+
+	s.name = newName
+	s.updatedAt = time.Now()
+	s.incrementVersion()
+	return nil
+}
+
+// Enable lets shop be served again.
+func (s *Shop) Enable() {
+	if s.isEnabled {
+		return
+	}
+
+	s.isEnabled = true
+	s.updatedAt = time.Now()
+	s.incrementVersion()
+}
+
+// Disable stops serving the shop.
+func (s *Shop) Disable() {
+	if !s.isEnabled {
+		return
+	}
+
+	s.isEnabled = false
+	s.updatedAt = time.Now()
+	s.incrementVersion()
+}
+
+// SetID is used by repository layer only.
+func (s *Shop) SetID(id int) {
+	s.id = id
+}
+
+// ---- GETTERS ----
+
+// ID returns identifier of the shop.
+func (s *Shop) ID() int {
+	return s.id
+}
+
+// Name returns name of the shop.
+func (s *Shop) Name() string {
+	return s.name
+}
+
+// IsEnabled reports whether shop is served.
+func (s *Shop) IsEnabled() bool {
+	return s.isEnabled
+}
+
+// CreatedAt returns time where created the shop.
+func (s *Shop) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+// UpdatedAt returns time where updated the shop.
+func (s *Shop) UpdatedAt() time.Time {
+	return s.updatedAt
+}
+
+// ShopOwned marks entity belonging to a shop.
+//
+// Zero shop id means entity of the instance itself,
+// which is not bound to any shop.
+// Concrete entities should embed this struct.
+type ShopOwned struct {
+	shopID int
+}
+
+// ShopID returns identifier of shop owning entity.
+func (o *ShopOwned) ShopID() int {
+	return o.shopID
+}
+
+// AssignShop binds entity to shop.
+//
+// Entity can not be moved to another shop:
+// assigning a different shop fails with ErrShopAlreadyAssigned.
+func (o *ShopOwned) AssignShop(shopID int) error {
+	if shopID <= 0 {
+		return ErrInvalidShopID
+	}
+
+	if o.shopID != 0 && o.shopID != shopID {
+		return ErrShopAlreadyAssigned
+	}
+
+	o.shopID = shopID
+	return nil
+}
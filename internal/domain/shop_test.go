@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShop(t *testing.T) {
+	_, err := NewShop("  ")
+	require.ErrorIs(t, err, ErrInvalidShopName)
+
+	s, err := NewShop(" Coffee House ")
+	require.NoError(t, err)
+	require.Equal(t, "Coffee House", s.Name())
+	require.True(t, s.IsEnabled())
+	require.Equal(t, 1, s.Version())
+}
+
+func TestShop_Rename(t *testing.T) {
+	s, _ := NewShop("Old")
+
+	require.ErrorIs(t, s.Rename(""), ErrInvalidShopName)
+	require.Equal(t, 1, s.Version())
+
+	require.NoError(t, s.Rename("New"))
+	require.Equal(t, "New", s.Name())
+	require.Equal(t, 2, s.Version())
+}
+
+func TestShop_EnableDisable(t *testing.T) {
+	s, _ := NewShop("Shop")
+
+	s.Disable()
+	require.False(t, s.IsEnabled())
+	require.Equal(t, 2, s.Version())
+
+	// no-op does not change version
+	s.Disable()
+	require.Equal(t, 2, s.Version())
+
+	s.Enable()
+	require.True(t, s.IsEnabled())
+	require.Equal(t, 3, s.Version())
+}
+
+func TestShopOwned_AssignShop(t *testing.T) {
+	c, _ := NewCity("London")
+	require.Equal(t, 0, c.ShopID())
+
+	require.ErrorIs(t, c.AssignShop(0), ErrInvalidShopID)
+
+	require.NoError(t, c.AssignShop(1))
+	require.Equal(t, 1, c.ShopID())
+
+	// assigning the same shop again is allowed
+	require.NoError(t, c.AssignShop(1))
+
+	require.ErrorIs(t, c.AssignShop(2), ErrShopAlreadyAssigned)
+	require.Equal(t, 1, c.ShopID())
+}
@@ -10,6 +10,7 @@ var (
 	ErrInsufficientReservedStock error = errors.New("insufficient reserved stock")
 	ErrInvalidWarehouseID        error = errors.New("invalid warehouse id")
 	ErrInvalidStockVariantID     error = errors.New("invalid variant id")
+	ErrStockNotFound             error = errors.New("stock not found")
 )
 
 // Stock represents physical inventory of a product variant
@@ -182,6 +183,20 @@ func (s *Stock) Decrease(n int) error {
 	return nil
 }
 
+// Restock returns n units of sold stock to inventory,
+// for example goods of refunded order.
+//
+// Fails if n <= 0.
+func (s *Stock) Restock(n int) error {
+	if n <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	s.quantity += n
+	s.incrementVersion()
+	return nil
+}
+
 // SetID is intended for repository layer only.
 func (s *Stock) SetID(id int) {
 	s.id = id
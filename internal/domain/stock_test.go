@@ -74,3 +74,15 @@ func TestStock_AvailableCalculation(t *testing.T) {
 	_ = s.Release(2)
 	require.Equal(t, 15, s.Available())
 }
+
+func TestStock_Restock(t *testing.T) {
+	s, _ := NewStock(1, 1, 10)
+	_ = s.Reserve(3)
+	_ = s.Decrease(3)
+
+	require.ErrorIs(t, s.Restock(0), ErrInvalidQuantity)
+
+	require.NoError(t, s.Restock(2))
+	require.Equal(t, 9, s.Quantity())
+	require.Equal(t, 9, s.Available())
+}
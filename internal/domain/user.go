@@ -18,6 +18,7 @@ var (
 	ErrInvalidCredentials  error = errors.New("invalid credentials")
 	ErrInsufficientBalance error = errors.New("insufficient balance")
 	ErrInvalidAmount       error = errors.New("amount must be positive")
+	ErrUserNotFound        error = errors.New("user not found")
 )
 
 // User represents an application user.
@@ -26,6 +27,7 @@ var (
 // Admin user require explicit access expiration configuration.
 type User struct {
 	BaseAggregate
+	ShopOwned
 
 	id           int
 	tgID         *int64
@@ -47,6 +49,8 @@ type NewUserParams struct {
 	Email        string
 	PasswordHash string
 	Role         Role
+	// ShopID is shop of user, zero for users of the instance.
+	ShopID int
 }
 
 // NewUser created a new user instance.
@@ -56,6 +60,7 @@ type NewUserParams struct {
 //   - Role must be either RoleCustomer or RoleAdmin.
 //   - User must authenticate either via Telegram (TgID)
 //     or via email + password hash.
+//   - ShopID must not be negative.
 //
 // Admin users are enabled by default.
 // Customer users are disabled by default.
@@ -74,12 +79,17 @@ func NewUser(p NewUserParams) (*User, error) {
 		return nil, ErrInvalidCredentials
 	}
 
+	if p.ShopID < 0 {
+		return nil, ErrInvalidShopID
+	}
+
 	var tgName *string
 	if p.TgID != nil {
 		tgName = &p.TgName
 	}
 
 	user := &User{
+		ShopOwned:    ShopOwned{shopID: p.ShopID},
 		tgID:         p.TgID,
 		tgName:       tgName,
 		email:        p.Email,
@@ -100,6 +110,44 @@ func NewUser(p NewUserParams) (*User, error) {
 	return user, nil
 }
 
+// UserFromDB holds stored state of user, see NewUserFromDB.
+type UserFromDB struct {
+	ID           int
+	ShopID       int
+	TgID         *int64
+	TgName       *string
+	Email        string
+	PasswordHash string
+	Role         Role
+	Balance      int64
+	IsEnabled    bool
+
+	AdminAccessExpiresAt *time.Time
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// NewUserFromDB restores user from its stored state.
+func NewUserFromDB(p UserFromDB) *User {
+	u := &User{
+		ShopOwned:            ShopOwned{shopID: p.ShopID},
+		id:                   p.ID,
+		tgID:                 p.TgID,
+		tgName:               p.TgName,
+		email:                p.Email,
+		passwordHash:         p.PasswordHash,
+		role:                 p.Role,
+		balance:              p.Balance,
+		isEnabled:            p.IsEnabled,
+		adminAccessExpiresAt: p.AdminAccessExpiresAt,
+		createdAt:            p.CreatedAt,
+		updatedAt:            p.UpdatedAt,
+	}
+
+	u.setInitialVersion(1)
+	return u
+}
+
 // ID returns user id.
 func (u *User) ID() int {
 	return u.id
@@ -121,6 +169,47 @@ func (u *User) TelegramName() (string, bool) {
 	return *u.tgName, true
 }
 
+// Role returns user role.
+func (u *User) Role() Role {
+	return u.role
+}
+
+// Email returns user email, empty for Telegram users.
+func (u *User) Email() string {
+	return u.email
+}
+
+// PasswordHash returns hash of user password.
+func (u *User) PasswordHash() string {
+	return u.passwordHash
+}
+
+// AdminAccessExpiresAt returns end of admin access
+// or nil if it was never granted.
+func (u *User) AdminAccessExpiresAt() *time.Time {
+	return u.adminAccessExpiresAt
+}
+
+// CreatedAt returns time user was created.
+func (u *User) CreatedAt() time.Time {
+	return u.createdAt
+}
+
+// UpdatedAt returns time user was last changed.
+func (u *User) UpdatedAt() time.Time {
+	return u.updatedAt
+}
+
+// IsEnabled reports whether user account is active.
+func (u *User) IsEnabled() bool {
+	return u.isEnabled
+}
+
+// Balance returns user balance.
+func (u *User) Balance() int64 {
+	return u.balance
+}
+
 // AddBalance increase user balance.
 func (u *User) AddBalance(amount int64) error {
 	if amount <= 0 {
@@ -176,8 +265,34 @@ func (u *User) CanUseAdminPanel(now time.Time) bool {
 	return now.Before(*u.adminAccessExpiresAt)
 }
 
+// CanManageShop reports whether user may administrate shop.
+//
+// Besides CanUseAdminPanel conditions admin must belong
+// to the shop. Admins without shop manage the whole instance.
+func (u *User) CanManageShop(shopID int, now time.Time) bool {
+	if !u.CanUseAdminPanel(now) {
+		return false
+	}
+
+	return u.shopID == 0 || u.shopID == shopID
+}
+
 // ---- SETTERS ----
 
+// SetID is intended for repository layer only.
+func (u *User) SetID(id int) {
+	u.id = id
+}
+
+// Clone returns copy of user sharing no mutable state with it,
+// events not pulled yet are not copied.
+// It is intended for repository layer only.
+func (u *User) Clone() *User {
+	c := *u
+	c.events = nil
+	return &c
+}
+
 // Enable activates the user account
 // and updates the modification timestamp.
 func (u *User) Enable() {
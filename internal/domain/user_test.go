@@ -343,3 +343,35 @@ func TestUser_GrantAdminAccess_CustomerIgnored(t *testing.T) {
 	require.Nil(t, u.adminAccessExpiresAt)
 	require.False(t, u.CanUseAdminPanel(now))
 }
+
+func TestUser_CanManageShop(t *testing.T) {
+	now := time.Now()
+
+	shopAdmin, _ := NewUser(NewUserParams{
+		Email:        "admin@shop.com",
+		PasswordHash: "hash",
+		Role:         RoleAdmin,
+		ShopID:       1,
+	})
+	shopAdmin.GrantAdminAccess(now.Add(time.Hour))
+
+	require.Equal(t, 1, shopAdmin.ShopID())
+	require.True(t, shopAdmin.CanManageShop(1, now))
+	require.False(t, shopAdmin.CanManageShop(2, now))
+	require.False(t, shopAdmin.CanManageShop(1, now.Add(2*time.Hour)))
+
+	instanceAdmin, _ := NewUser(NewUserParams{
+		Email:        "root@site.dev",
+		PasswordHash: "hash",
+		Role:         RoleAdmin,
+	})
+	instanceAdmin.GrantAdminAccess(now.Add(time.Hour))
+
+	require.True(t, instanceAdmin.CanManageShop(2, now))
+}
+
+func TestNewUser_InvalidShop(t *testing.T) {
+	tgID := int64(1)
+	_, err := NewUser(NewUserParams{TgID: &tgID, ShopID: -1})
+	require.ErrorIs(t, err, ErrInvalidShopID)
+}
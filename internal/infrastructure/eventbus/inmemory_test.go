@@ -16,15 +16,15 @@ func TestInMemoryBus_MultipleEvents(t *testing.T) {
 	count := 0
 
 	bus.Subscribe(
-		domain.NameOrderConfirm,
-		func(ctx context.Context, event domain.DomainEvent) error {
+		domain.NameOrderPaid,
+		func(ctx context.Context, event domain.Event) error {
 			count++
 			return nil
 		},
 	)
 
-	e1 := domain.NewOrderConfirmed(1)
-	e2 := domain.NewOrderConfirmed(2)
+	e1 := domain.NewOrderPaid(1)
+	e2 := domain.NewOrderPaid(2)
 
 	_ = bus.Publish(context.Background(), e1, e2)
 
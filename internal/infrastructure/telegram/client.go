@@ -0,0 +1,212 @@
+// Package telegram implements Telegram Bot API client
+// and bot runners for manager.Manager.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"botmanager/internal/manager"
+)
+
+// DefaultBaseURL is address of public Telegram Bot API.
+const DefaultBaseURL = "https://api.telegram.org"
+
+// APIError is an error returned by Bot API.
+//
+// RetryAfter is set when Telegram asks to slow down (429).
+type APIError struct {
+	Code        int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram: %d %s", e.Code, e.Description)
+}
+
+// IsUnauthorized reports whether err means invalid bot token.
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusUnauthorized
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// Client performs Bot API calls.
+//
+// Client is safe for concurrent use by many bots,
+// token is passed to every call.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient creates a new Bot API client.
+//
+// Empty baseURL means DefaultBaseURL, nil httpClient means http.DefaultClient.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    httpClient,
+	}
+}
+
+// Call invokes Bot API method with JSON encoded params
+// and decodes result into result (if not nil).
+func (c *Client) Call(
+	ctx context.Context,
+	token string,
+	method string,
+	params any,
+	result any,
+) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("telegram: encode %s params: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		c.baseURL+"/bot"+token+"/"+method,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return fmt.Errorf("telegram: build %s request: %w", method, stripURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// url.Error contains request URL with token, never expose it.
+		return fmt.Errorf("telegram: %s: %w", method, stripURL(err))
+	}
+	defer resp.Body.Close()
+
+	var apiResp apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		// Proxies in front of Bot API answer with HTML pages.
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return &APIError{Code: resp.StatusCode, Description: http.StatusText(resp.StatusCode)}
+		}
+		return fmt.Errorf("telegram: decode %s response (status %d): %w", method, resp.StatusCode, err)
+	}
+
+	if !apiResp.OK {
+		apiErr := &APIError{
+			Code:        apiResp.ErrorCode,
+			Description: apiResp.Description,
+		}
+		if apiErr.Code == 0 {
+			apiErr.Code = resp.StatusCode
+		}
+		if apiResp.Parameters != nil && apiResp.Parameters.RetryAfter > 0 {
+			apiErr.RetryAfter = time.Duration(apiResp.Parameters.RetryAfter) * time.Second
+		}
+		return apiErr
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(apiResp.Result, result); err != nil {
+		return fmt.Errorf("telegram: decode %s result: %w", method, err)
+	}
+
+	return nil
+}
+
+// Bot binds client to a single bot token.
+//
+// Bots created by runners send messages through outbound Queue,
+// see SendMessage.
+type Bot struct {
+	client *Client
+	token  string
+	id     string
+	queue  *Queue
+}
+
+// NewBot creates a new bot bound to token.
+func NewBot(client *Client, token string) *Bot {
+	return &Bot{client: client, token: token, id: manager.BotID(token)}
+}
+
+// ID returns internal bot id, see manager.BotID.
+func (b *Bot) ID() string {
+	return b.id
+}
+
+// Call invokes Bot API method on behalf of bot.
+func (b *Bot) Call(ctx context.Context, method string, params any, result any) error {
+	return b.client.Call(ctx, b.token, method, params, result)
+}
+
+// GetMe returns bot's own Telegram account.
+func (b *Bot) GetMe(ctx context.Context) (*User, error) {
+	var user User
+	if err := b.Call(ctx, "getMe", struct{}{}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SendMessage sends text message and waits for its delivery.
+func (b *Bot) SendMessage(ctx context.Context, params SendMessageParams) (*Message, error) {
+	var msg Message
+	if err := b.send(ctx, params.ChatID, "sendMessage", params, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// EditMessageText replaces text and keyboard of sent message.
+func (b *Bot) EditMessageText(ctx context.Context, params EditMessageTextParams) error {
+	return b.send(ctx, params.ChatID, "editMessageText", params, nil)
+}
+
+// send calls method addressed to chat through queue of bot if it has one.
+func (b *Bot) send(ctx context.Context, chatID int64, method string, params, result any) error {
+	if b.queue == nil {
+		return b.Call(ctx, method, params, result)
+	}
+	return b.queue.Send(ctx, chatID, method, params, result)
+}
+
+// AnswerCallbackQuery confirms press of inline keyboard button.
+func (b *Bot) AnswerCallbackQuery(ctx context.Context, params AnswerCallbackQueryParams) error {
+	return b.Call(ctx, "answerCallbackQuery", params, nil)
+}
+
+func stripURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
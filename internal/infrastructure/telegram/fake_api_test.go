@@ -0,0 +1,199 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAPI is a minimal Telegram Bot API server for tests.
+type fakeAPI struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu      sync.Mutex
+	tokens  map[string]bool
+	updates []Update
+	offsets []int64
+	calls   map[string]int
+	// params holds params of the last call of each method.
+	params map[string]map[string]any
+	// failures are returned by getUpdates before real updates.
+	failures []apiResponse
+	// handlers override behaviour of methods.
+	handlers map[string]func(params map[string]any) apiResponse
+}
+
+func newFakeAPI(t *testing.T, token string) *fakeAPI {
+	f := &fakeAPI{
+		t:        t,
+		tokens:   map[string]bool{token: true},
+		calls:    make(map[string]int),
+		params:   make(map[string]map[string]any),
+		handlers: make(map[string]func(params map[string]any) apiResponse),
+	}
+
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+func (f *fakeAPI) client() *Client {
+	return NewClient(f.server.URL, f.server.Client())
+}
+
+// allow makes fake API accept one more bot token.
+func (f *fakeAPI) allow(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens[token] = true
+}
+
+func (f *fakeAPI) push(updates ...Update) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updates = append(f.updates, updates...)
+}
+
+func (f *fakeAPI) handle(method string, h func(params map[string]any) apiResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[method] = h
+}
+
+func (f *fakeAPI) callCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+func (f *fakeAPI) lastParams(method string) map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.params[method]
+}
+
+// waitParam waits until last call of method has param with value
+// formatted by %v as want.
+func (f *fakeAPI) waitParam(t *testing.T, method, param, want string) {
+	t.Helper()
+
+	deadline := time.After(time.Second)
+	for {
+		var got string
+		if params := f.lastParams(method); params != nil {
+			got = fmt.Sprint(params[param])
+		}
+		if got == want {
+			return
+		}
+
+		select {
+		case <-deadline:
+			t.Fatalf("expected %s %s=%s, got %s", method, param, want, got)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func (f *fakeAPI) receivedOffsets() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int64(nil), f.offsets...)
+}
+
+func okResponse(t *testing.T, result any) apiResponse {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshal result: %v", err)
+	}
+	return apiResponse{OK: true, Result: raw}
+}
+
+func (f *fakeAPI) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/bot")
+	token, method, _ := strings.Cut(path, "/")
+
+	var params map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&params)
+
+	f.mu.Lock()
+	known := f.tokens[token]
+	f.mu.Unlock()
+
+	if !known {
+		writeResponse(w, http.StatusUnauthorized, apiResponse{
+			ErrorCode:   http.StatusUnauthorized,
+			Description: "Unauthorized",
+		})
+		return
+	}
+
+	f.mu.Lock()
+	f.calls[method]++
+	f.params[method] = params
+	h := f.handlers[method]
+	f.mu.Unlock()
+
+	if h != nil {
+		resp := h(params)
+		status := http.StatusOK
+		if !resp.OK {
+			status = resp.ErrorCode
+		}
+		writeResponse(w, status, resp)
+		return
+	}
+
+	if method != "getUpdates" {
+		writeResponse(w, http.StatusOK, okResponse(f.t, true))
+		return
+	}
+
+	offset := int64(0)
+	if v, ok := params["offset"].(float64); ok {
+		offset = int64(v)
+	}
+
+	f.mu.Lock()
+	f.offsets = append(f.offsets, offset)
+
+	if len(f.failures) > 0 {
+		resp := f.failures[0]
+		f.failures = f.failures[1:]
+		f.mu.Unlock()
+		writeResponse(w, resp.ErrorCode, resp)
+		return
+	}
+
+	result := []Update{}
+	for _, u := range f.updates {
+		if u.UpdateID >= offset {
+			result = append(result, u)
+		}
+	}
+	f.mu.Unlock()
+
+	if len(result) == 0 {
+		// emulate short long poll
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	writeResponse(w, http.StatusOK, okResponse(f.t, result))
+}
+
+func writeResponse(w http.ResponseWriter, status int, resp apiResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
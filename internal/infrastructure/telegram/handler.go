@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+)
+
+// Handler processes a single update received by bot.
+type Handler interface {
+	HandleUpdate(ctx context.Context, bot *Bot, update Update) error
+}
+
+// HandlerFunc is an adapter to use ordinary functions as Handler.
+type HandlerFunc func(ctx context.Context, bot *Bot, update Update) error
+
+// HandleUpdate calls f(ctx, bot, update).
+func (f HandlerFunc) HandleUpdate(ctx context.Context, bot *Bot, update Update) error {
+	return f(ctx, bot, update)
+}
+
+// handleSafely calls handler turning panic into error,
+// so that a single bad update does not kill the bot.
+func handleSafely(ctx context.Context, h Handler, bot *Bot, u Update) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+
+	return h.HandleUpdate(ctx, bot, u)
+}
+
+// OffsetStore persists offset of the next update to be received by bot.
+//
+// Bots are identified by manager.BotID, never by raw token.
+// Load must return 0 if offset was never saved.
+type OffsetStore interface {
+	Load(ctx context.Context, botID string) (int64, error)
+	Save(ctx context.Context, botID string, offset int64) error
+}
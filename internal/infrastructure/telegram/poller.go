@@ -0,0 +1,254 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"botmanager/internal/manager"
+)
+
+const (
+	defaultPollTimeout = 30 * time.Second
+	defaultPollLimit   = 100
+	defaultRetryDelay  = 3 * time.Second
+)
+
+var _ manager.ConfigurableRunner = (*Poller)(nil)
+
+// Poller is manager.Runner receiving updates with getUpdates long polling.
+//
+// Offset of the next update is saved after every handled update,
+// so restarted bot continues exactly where it stopped.
+// Errors returned by handler are logged and do not stop polling.
+type Poller struct {
+	client  *Client
+	offsets OffsetStore
+	handler Handler
+	logger  *slog.Logger
+
+	timeout    time.Duration
+	limit      int
+	retryDelay time.Duration
+	queueOpts  []QueueOption
+}
+
+// PollerOption configures Poller.
+type PollerOption func(*Poller)
+
+// WithPollTimeout sets long polling timeout.
+func WithPollTimeout(d time.Duration) PollerOption {
+	return func(p *Poller) {
+		p.timeout = d
+	}
+}
+
+// WithRetryDelay sets delay before retry of failed getUpdates call.
+func WithRetryDelay(d time.Duration) PollerOption {
+	return func(p *Poller) {
+		p.retryDelay = d
+	}
+}
+
+// WithQueueOptions configures outbound queue of polled bots.
+func WithQueueOptions(opts ...QueueOption) PollerOption {
+	return func(p *Poller) {
+		p.queueOpts = opts
+	}
+}
+
+// NewPoller creates a new long polling runner.
+//
+// logger may be nil, in that case slog.Default() is used.
+func NewPoller(
+	client *Client,
+	offsets OffsetStore,
+	handler Handler,
+	logger *slog.Logger,
+	opts ...PollerOption,
+) *Poller {
+	if client == nil {
+		panic("telegram: Client is nil")
+	}
+
+	if offsets == nil {
+		panic("telegram: OffsetStore is nil")
+	}
+
+	if handler == nil {
+		panic("telegram: Handler is nil")
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	p := &Poller{
+		client:     client,
+		offsets:    offsets,
+		handler:    handler,
+		logger:     logger,
+		timeout:    defaultPollTimeout,
+		limit:      defaultPollLimit,
+		retryDelay: defaultRetryDelay,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+type getUpdatesParams struct {
+	Offset         int64    `json:"offset,omitempty"`
+	Limit          int      `json:"limit,omitempty"`
+	Timeout        int      `json:"timeout"`
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+// Run polls updates until ctx is cancelled.
+//
+// Returns nil when ctx is cancelled and error when bot
+// cannot continue (e.g. token was revoked).
+func (p *Poller) Run(ctx context.Context, token string) error {
+	return p.RunWithConfig(ctx, token, manager.BotConfig{}, nil)
+}
+
+// RunWithConfig polls updates of types allowed by cfg until ctx is cancelled.
+//
+// Config changes received from changes apply to the next getUpdates call,
+// polling continues from the same offset.
+func (p *Poller) RunWithConfig(
+	ctx context.Context,
+	token string,
+	cfg manager.BotConfig,
+	changes <-chan manager.BotConfig,
+) error {
+	botID := manager.BotID(token)
+	logger := manager.Logger(ctx, p.logger.With("bot_id", botID))
+	bot := NewBot(p.client, token)
+
+	// Stopped after polling, when no handler sends anymore.
+	stopQueue := startQueue(bot, logger, p.queueOpts...)
+	defer stopQueue()
+
+	offset, err := p.offsets.Load(ctx, botID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("load offset: %w", err)
+	}
+
+	allowed := allowedUpdates(cfg)
+
+	logger.Info("bot polling started", "offset", offset)
+
+	for {
+		if ctx.Err() != nil {
+			logger.Info("bot polling stopped")
+			return nil
+		}
+
+		select {
+		case cfg := <-changes:
+			allowed = allowedUpdates(cfg)
+			logger.Info("bot config reloaded", "allowed_updates", allowed)
+		default:
+		}
+
+		var updates []Update
+		err := bot.Call(ctx, "getUpdates", getUpdatesParams{
+			Offset:         offset,
+			Limit:          p.limit,
+			Timeout:        int(p.timeout / time.Second),
+			AllowedUpdates: allowed,
+		}, &updates)
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.Info("bot polling stopped")
+				return nil
+			}
+
+			if IsUnauthorized(err) {
+				logger.Error("bot token rejected", "err", err)
+				return err
+			}
+
+			delay := p.retryDelay
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+				delay = apiErr.RetryAfter
+			}
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict {
+				logger.Error("bot is polled by another instance", "err", err)
+			} else {
+				logger.Warn("failed to get updates", "err", err, "retry_in", delay)
+			}
+
+			if !sleep(ctx, delay) {
+				logger.Info("bot polling stopped")
+				return nil
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			if u.UpdateID < offset {
+				continue
+			}
+
+			// rest of batch is not handled and not acknowledged,
+			// so it is received again after restart
+			if ctx.Err() != nil {
+				logger.Info("bot polling stopped")
+				return nil
+			}
+
+			if err := handleSafely(ctx, p.handler, bot, u); err != nil {
+				logger.Warn("failed to handle update",
+					"update_id", u.UpdateID,
+					"err", err,
+				)
+			}
+
+			offset = u.UpdateID + 1
+
+			// Saved with uncancellable context: handled update must
+			// not be replayed even if bot is being stopped.
+			if err := p.offsets.Save(context.WithoutCancel(ctx), botID, offset); err != nil {
+				logger.Error("failed to save offset", "offset", offset, "err", err)
+			}
+		}
+	}
+}
+
+// allowedUpdates returns update types of cfg for Bot API.
+//
+// Empty list is sent explicitly: omitted list makes
+// Telegram keep previous setting.
+func allowedUpdates(cfg manager.BotConfig) []string {
+	if len(cfg.AllowedUpdates) == 0 {
+		return []string{}
+	}
+	return slices.Clone(cfg.AllowedUpdates)
+}
+
+// sleep waits for d or ctx cancellation.
+// Returns false if ctx was cancelled.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,334 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"botmanager/internal/manager"
+)
+
+const testToken = "123456:secret"
+
+type memoryOffsets struct {
+	mu      sync.Mutex
+	offsets map[string]int64
+}
+
+func newMemoryOffsets() *memoryOffsets {
+	return &memoryOffsets{offsets: make(map[string]int64)}
+}
+
+func (m *memoryOffsets) Load(ctx context.Context, botID string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.offsets[botID], nil
+}
+
+func (m *memoryOffsets) Save(ctx context.Context, botID string, offset int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offsets[botID] = offset
+	return nil
+}
+
+type recordingHandler struct {
+	mu  sync.Mutex
+	ids []int64
+	got chan int64
+	err error
+}
+
+func newRecordingHandler() *recordingHandler {
+	return &recordingHandler{got: make(chan int64, 100)}
+}
+
+func (h *recordingHandler) HandleUpdate(ctx context.Context, bot *Bot, u Update) error {
+	h.mu.Lock()
+	h.ids = append(h.ids, u.UpdateID)
+	h.mu.Unlock()
+	h.got <- u.UpdateID
+	return h.err
+}
+
+func (h *recordingHandler) wait(t *testing.T, n int) []int64 {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-h.got:
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d updates, got %d", n, i)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]int64(nil), h.ids...)
+}
+
+func runPoller(t *testing.T, p *Poller, token string) (context.CancelFunc, <-chan error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.Run(ctx, token)
+	}()
+
+	return cancel, errCh
+}
+
+func waitRun(t *testing.T, errCh <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("poller did not stop")
+		return nil
+	}
+}
+
+func TestPollerDeliversUpdatesAndSavesOffset(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.push(Update{UpdateID: 10}, Update{UpdateID: 11}, Update{UpdateID: 12})
+
+	offsets := newMemoryOffsets()
+	handler := newRecordingHandler()
+	p := NewPoller(api.client(), offsets, handler, nil, WithPollTimeout(0))
+
+	cancel, errCh := runPoller(t, p, testToken)
+
+	ids := handler.wait(t, 3)
+	if len(ids) != 3 || ids[0] != 10 || ids[2] != 12 {
+		t.Fatalf("unexpected updates %v", ids)
+	}
+
+	cancel()
+	if err := waitRun(t, errCh); err != nil {
+		t.Fatalf("expected nil error on cancel, got %v", err)
+	}
+
+	saved, _ := offsets.Load(context.Background(), manager.BotID(testToken))
+	if saved != 13 {
+		t.Fatalf("expected saved offset 13, got %d", saved)
+	}
+}
+
+func TestPollerResumesFromSavedOffset(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.push(Update{UpdateID: 1}, Update{UpdateID: 2}, Update{UpdateID: 3})
+
+	offsets := newMemoryOffsets()
+	_ = offsets.Save(context.Background(), manager.BotID(testToken), 3)
+
+	handler := newRecordingHandler()
+	p := NewPoller(api.client(), offsets, handler, nil, WithPollTimeout(0))
+
+	cancel, errCh := runPoller(t, p, testToken)
+
+	ids := handler.wait(t, 1)
+	cancel()
+	_ = waitRun(t, errCh)
+
+	if len(ids) != 1 || ids[0] != 3 {
+		t.Fatalf("expected only update 3 to be delivered, got %v", ids)
+	}
+
+	if got := api.receivedOffsets(); got[0] != 3 {
+		t.Fatalf("expected first getUpdates with offset 3, got %d", got[0])
+	}
+}
+
+func TestPollerStopsMidBatch(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.push(Update{UpdateID: 1}, Update{UpdateID: 2}, Update{UpdateID: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ids []int64
+	handler := HandlerFunc(func(ctx context.Context, bot *Bot, u Update) error {
+		ids = append(ids, u.UpdateID)
+
+		// bot is stopped while first update is handled
+		cancel()
+		return nil
+	})
+
+	offsets := newMemoryOffsets()
+	p := NewPoller(api.client(), offsets, handler, nil, WithPollTimeout(0))
+
+	if err := p.Run(ctx, testToken); err != nil {
+		t.Fatalf("expected nil error on cancel, got %v", err)
+	}
+
+	if len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("expected only update 1 to be handled, got %v", ids)
+	}
+
+	saved, _ := offsets.Load(context.Background(), manager.BotID(testToken))
+	if saved != 2 {
+		t.Fatalf("expected saved offset 2, got %d", saved)
+	}
+}
+
+func TestPollerHandlerErrorDoesNotStopPolling(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.push(Update{UpdateID: 1}, Update{UpdateID: 2})
+
+	handler := newRecordingHandler()
+	handler.err = errors.New("broken handler")
+
+	p := NewPoller(api.client(), newMemoryOffsets(), handler, nil, WithPollTimeout(0))
+
+	cancel, errCh := runPoller(t, p, testToken)
+	handler.wait(t, 2)
+	cancel()
+
+	if err := waitRun(t, errCh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPollerRecoversHandlerPanic(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.push(Update{UpdateID: 1}, Update{UpdateID: 2})
+
+	got := make(chan int64, 2)
+	handler := HandlerFunc(func(ctx context.Context, bot *Bot, u Update) error {
+		got <- u.UpdateID
+		if u.UpdateID == 1 {
+			panic("boom")
+		}
+		return nil
+	})
+
+	p := NewPoller(api.client(), newMemoryOffsets(), handler, nil, WithPollTimeout(0))
+	cancel, errCh := runPoller(t, p, testToken)
+	defer func() {
+		cancel()
+		_ = waitRun(t, errCh)
+	}()
+
+	for want := int64(1); want <= 2; want++ {
+		select {
+		case id := <-got:
+			if id != want {
+				t.Fatalf("expected update %d, got %d", want, id)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("update %d was not delivered", want)
+		}
+	}
+}
+
+func TestPollerUnauthorizedStops(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+
+	p := NewPoller(api.client(), newMemoryOffsets(), newRecordingHandler(), nil, WithPollTimeout(0))
+
+	_, errCh := runPoller(t, p, "654321:wrong")
+
+	err := waitRun(t, errCh)
+	if !IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
+
+func TestPollerRetriesAfterRateLimit(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.failures = []apiResponse{{
+		ErrorCode:   429,
+		Description: "Too Many Requests",
+		Parameters: &struct {
+			RetryAfter int `json:"retry_after"`
+		}{RetryAfter: 1},
+	}}
+	api.push(Update{UpdateID: 1})
+
+	handler := newRecordingHandler()
+	p := NewPoller(api.client(), newMemoryOffsets(), handler, nil,
+		WithPollTimeout(0),
+		WithRetryDelay(time.Hour),
+	)
+
+	start := time.Now()
+	cancel, errCh := runPoller(t, p, testToken)
+
+	select {
+	case <-handler.got:
+	case <-time.After(3 * time.Second):
+		t.Fatal("update was not delivered after retry")
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected retry_after to be honored, retried after %s", elapsed)
+	}
+
+	cancel()
+	_ = waitRun(t, errCh)
+}
+
+func TestPollerWithManager(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.push(Update{UpdateID: 1})
+
+	handler := newRecordingHandler()
+	p := NewPoller(api.client(), newMemoryOffsets(), handler, nil, WithPollTimeout(0))
+
+	m := manager.NewManager(p)
+	if err := m.Register("bot", testToken); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	handler.wait(t, 1)
+
+	if err := m.Remove(context.Background(), manager.BotID(testToken)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPollerReloadsConfigKeepingOffset(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.push(Update{UpdateID: 1})
+
+	handler := newRecordingHandler()
+	p := NewPoller(api.client(), newMemoryOffsets(), handler, nil, WithPollTimeout(0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan manager.BotConfig, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.RunWithConfig(ctx, testToken, manager.BotConfig{
+			AllowedUpdates: []string{"message"},
+		}, changes)
+	}()
+
+	handler.wait(t, 1)
+	api.waitParam(t, "getUpdates", "allowed_updates", "[message]")
+
+	changes <- manager.BotConfig{AllowedUpdates: []string{"message", "callback_query"}}
+	api.waitParam(t, "getUpdates", "allowed_updates", "[message callback_query]")
+
+	api.push(Update{UpdateID: 2})
+	ids := handler.wait(t, 1)
+
+	cancel()
+	_ = waitRun(t, errCh)
+
+	if len(ids) != 2 || ids[1] != 2 {
+		t.Fatalf("expected updates to continue after reload, got %v", ids)
+	}
+
+	// reload must not reset offset
+	for _, offset := range api.receivedOffsets()[1:] {
+		if offset < 2 {
+			t.Fatalf("polling restarted from offset %d", offset)
+		}
+	}
+}
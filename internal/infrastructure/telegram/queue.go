@@ -0,0 +1,422 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrQueueClosed is returned for messages that were not sent
+// because bot stopped.
+var ErrQueueClosed = errors.New("telegram: outbound queue closed")
+
+const (
+	// Telegram allows about 30 messages per second for a bot
+	// and 1 message per second for a chat.
+	defaultGlobalRate = 30
+	defaultChatRate   = 1
+
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+
+	// defaultDrainTimeout limits sending of in-flight messages on stop.
+	defaultDrainTimeout = 10 * time.Second
+)
+
+// QueueOption configures Queue.
+type QueueOption func(*Queue)
+
+// WithRateLimits sets global and per chat limits in messages per second.
+//
+// Panics if any limit is not positive, so misconfigured
+// limits are reported before any bot starts.
+func WithRateLimits(global, perChat float64) QueueOption {
+	if global <= 0 || perChat <= 0 {
+		panic(fmt.Sprintf("telegram: rate limits must be positive, got %g and %g", global, perChat))
+	}
+
+	return func(q *Queue) {
+		q.globalRate = global
+		q.chatRate = perChat
+	}
+}
+
+// WithRetries sets max attempts of a message and backoff between them.
+// Backoff doubles after every failed attempt up to maxBackoff.
+func WithRetries(maxAttempts int, initialBackoff, maxBackoff time.Duration) QueueOption {
+	return func(q *Queue) {
+		q.maxAttempts = maxAttempts
+		q.initialBackoff = initialBackoff
+		q.maxBackoff = maxBackoff
+	}
+}
+
+// Queue sends messages of a single bot within Telegram rate limits.
+//
+// Messages to the same chat are delivered in order they were enqueued,
+// messages to different chats do not wait for each other.
+// On 429 response the whole queue is paused for retry_after,
+// network errors and 5xx responses are retried with backoff.
+type Queue struct {
+	bot    *Bot
+	logger *slog.Logger
+
+	globalRate     float64
+	chatRate       float64
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	drainTimeout   time.Duration
+
+	requests chan *outgoing
+	// stopped is closed when Run stops accepting messages.
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+type outgoing struct {
+	chatID int64
+	method string
+	params any
+	result any
+
+	attempts  int
+	lastErr   error
+	notBefore time.Time
+	// done receives delivery result and is set to nil after that.
+	done chan error
+}
+
+// NewQueue creates outbound queue of bot.
+//
+// logger may be nil, in that case slog.Default() is used.
+func NewQueue(bot *Bot, logger *slog.Logger, opts ...QueueOption) *Queue {
+	if bot == nil {
+		panic("telegram: Bot is nil")
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	q := &Queue{
+		bot:            bot,
+		logger:         logger,
+		globalRate:     defaultGlobalRate,
+		chatRate:       defaultChatRate,
+		maxAttempts:    defaultMaxAttempts,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		drainTimeout:   defaultDrainTimeout,
+		requests:       make(chan *outgoing),
+		stopped:        make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
+}
+
+// startQueue attaches a new queue to bot and runs it
+// until returned stop is called.
+func startQueue(bot *Bot, logger *slog.Logger, opts ...QueueOption) (stop func()) {
+	q := NewQueue(bot, logger, opts...)
+	bot.queue = q
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Run(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Send enqueues Bot API call addressed to chat and waits for its delivery.
+// result may be nil.
+//
+// Returns the last error if message was not delivered and
+// ErrQueueClosed if bot stopped before message was sent.
+// If ctx is cancelled Send returns, but message stays in the queue.
+func (q *Queue) Send(ctx context.Context, chatID int64, method string, params, result any) error {
+	done := make(chan error, 1)
+	o := &outgoing{
+		chatID: chatID,
+		method: method,
+		params: params,
+		result: result,
+		done:   done,
+	}
+
+	select {
+	case q.requests <- o:
+	case <-q.stopped:
+		return ErrQueueClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run sends enqueued messages until ctx is cancelled.
+//
+// On stop messages which are not sent yet fail with ErrQueueClosed,
+// Run waits for messages being sent at the moment.
+func (q *Queue) Run(ctx context.Context) {
+	var (
+		pending     []*outgoing
+		retries     = make(chan *outgoing)
+		inflight    sync.WaitGroup
+		global      = newBucket(q.globalRate, q.globalRate, time.Now())
+		chats       = make(map[int64]*bucket)
+		busy        = make(map[int64]bool)
+		pausedUntil time.Time
+	)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	// Attempts sent at the moment of stop are finished,
+	// so they are not cut in the middle. Their time is limited
+	// by drainTimeout counted from stop.
+	sendCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	for {
+		wait := time.Hour
+		t := time.Now()
+
+		// chats with an earlier message waiting, so the later ones
+		// do not overtake it
+		blocked := make(map[int64]bool)
+
+		for i := 0; i < len(pending); i++ {
+			o := pending[i]
+			if busy[o.chatID] || blocked[o.chatID] {
+				continue
+			}
+
+			d := o.notBefore.Sub(t)
+			d = max(d, pausedUntil.Sub(t))
+
+			chat, ok := chats[o.chatID]
+			if !ok {
+				// no bursts within a chat
+				chat = newBucket(q.chatRate, 1, t)
+				chats[o.chatID] = chat
+			}
+			d = max(d, chat.wait(t), global.wait(t))
+
+			if d > 0 {
+				blocked[o.chatID] = true
+				wait = min(wait, d)
+				continue
+			}
+
+			chat.take()
+			global.take()
+			busy[o.chatID] = true
+			pending = append(pending[:i], pending[i+1:]...)
+			i--
+
+			inflight.Add(1)
+			go func() {
+				defer inflight.Done()
+				q.attempt(sendCtx, o)
+				select {
+				case retries <- o:
+				case <-sendCtx.Done():
+					// Run is gone and o can not be retried.
+					if o.done != nil {
+						o.done <- ErrQueueClosed
+					}
+				}
+			}()
+		}
+
+		q.forgetIdle(chats, busy, pending, t)
+
+		timer.Reset(wait)
+
+		select {
+		case o := <-q.requests:
+			pending = append(pending, o)
+
+		case o := <-retries:
+			delete(busy, o.chatID)
+			if o.done == nil {
+				continue
+			}
+
+			var apiErr *APIError
+			if errors.As(o.lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+				pausedUntil = time.Now().Add(apiErr.RetryAfter)
+			}
+			// retried message goes first to keep order within chat
+			pending = append([]*outgoing{o}, pending...)
+
+		case <-timer.C:
+
+		case <-ctx.Done():
+			q.stopOnce.Do(func() { close(q.stopped) })
+
+			drain := time.AfterFunc(q.drainTimeout, cancel)
+			defer drain.Stop()
+
+			for _, o := range pending {
+				o.done <- ErrQueueClosed
+			}
+
+			// finish attempts in flight, they are not retried
+			go func() {
+				inflight.Wait()
+				close(retries)
+			}()
+			for o := range retries {
+				if o.done != nil {
+					o.done <- ErrQueueClosed
+				}
+			}
+
+			return
+		}
+	}
+}
+
+// attempt sends o once. If o is delivered or failed for good
+// its result is reported and o.done is cleared,
+// otherwise o is scheduled for the next attempt.
+func (q *Queue) attempt(ctx context.Context, o *outgoing) {
+	o.attempts++
+	err := q.bot.Call(ctx, o.method, o.params, o.result)
+	o.lastErr = err
+
+	if err == nil {
+		o.done <- nil
+		o.done = nil
+		return
+	}
+
+	logger := q.logger.With(
+		"bot_id", q.bot.ID(),
+		"chat_id", o.chatID,
+		"method", o.method,
+		"attempt", o.attempts,
+	)
+
+	if !retryable(err) || o.attempts >= q.maxAttempts {
+		logger.Warn("message not delivered", "err", err)
+		o.done <- err
+		o.done = nil
+		return
+	}
+
+	delay := q.backoff(o.attempts)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		delay = apiErr.RetryAfter
+	}
+	o.notBefore = time.Now().Add(delay)
+
+	logger.Info("message delivery will be retried", "err", err, "retry_in", delay)
+}
+
+// backoff returns delay before attempt following n failed ones.
+func (q *Queue) backoff(n int) time.Duration {
+	d := q.initialBackoff
+	for i := 1; i < n && d < q.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, q.maxBackoff)
+}
+
+// forgetIdle drops limiters of chats which are full again
+// and have nothing to send, so long running bot does not
+// keep a limiter of every chat it ever talked to.
+func (q *Queue) forgetIdle(chats map[int64]*bucket, busy map[int64]bool, pending []*outgoing, t time.Time) {
+	if len(chats) < 1024 {
+		return
+	}
+
+	waiting := make(map[int64]bool, len(pending))
+	for _, o := range pending {
+		waiting[o.chatID] = true
+	}
+
+	for id, b := range chats {
+		if !busy[id] && !waiting[id] && b.full(t) {
+			delete(chats, id)
+		}
+	}
+}
+
+// retryable reports whether err is transient.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// bucket is a token bucket limiter.
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate, burst float64, t time.Time) *bucket {
+	burst = max(burst, 1)
+	return &bucket{rate: rate, burst: burst, tokens: burst, last: t}
+}
+
+func (b *bucket) refill(t time.Time) {
+	if t.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+t.Sub(b.last).Seconds()*b.rate)
+		b.last = t
+	}
+}
+
+// wait returns time until a token is available.
+func (b *bucket) wait(t time.Time) time.Duration {
+	b.refill(t)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *bucket) take() {
+	b.tokens--
+}
+
+func (b *bucket) full(t time.Time) bool {
+	b.refill(t)
+	return b.tokens >= b.burst
+}
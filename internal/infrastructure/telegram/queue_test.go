@@ -0,0 +1,277 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type sentMessage struct {
+	chatID int64
+	text   string
+	at     time.Time
+}
+
+// recordSends makes fake API record sendMessage calls.
+func recordSends(f *fakeAPI) func() []sentMessage {
+	var (
+		mu   sync.Mutex
+		sent []sentMessage
+	)
+
+	f.handle("sendMessage", func(params map[string]any) apiResponse {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, sentMessage{
+			chatID: int64(params["chat_id"].(float64)),
+			text:   params["text"].(string),
+			at:     time.Now(),
+		})
+		return okResponse(f.t, Message{MessageID: int64(len(sent))})
+	})
+
+	return func() []sentMessage {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]sentMessage(nil), sent...)
+	}
+}
+
+func runQueue(t *testing.T, f *fakeAPI, opts ...QueueOption) (*Bot, context.CancelFunc) {
+	t.Helper()
+
+	bot := NewBot(f.client(), testToken)
+	q := NewQueue(bot, nil, opts...)
+	bot.queue = q
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return bot, cancel
+}
+
+func sendAsync(bot *Bot, chatID int64, text string) <-chan error {
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := bot.SendMessage(context.Background(), SendMessageParams{ChatID: chatID, Text: text})
+		errCh <- err
+	}()
+	return errCh
+}
+
+func waitSent(t *testing.T, errs ...<-chan error) {
+	t.Helper()
+
+	for _, errCh := range errs {
+		select {
+		case err := <-errCh:
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not delivered")
+		}
+	}
+}
+
+func TestWithRateLimitsRejectsNonPositive(t *testing.T) {
+	for _, rates := range [][2]float64{{0, 1}, {30, 0}, {-1, 1}, {30, -0.5}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for rates %v", rates)
+				}
+			}()
+			WithRateLimits(rates[0], rates[1])
+		}()
+	}
+}
+
+func TestQueueChatRateLimit(t *testing.T) {
+	f := newFakeAPI(t, testToken)
+	sent := recordSends(f)
+	bot, _ := runQueue(t, f, WithRateLimits(100, 10))
+
+	var errs []<-chan error
+	for _, text := range []string{"1", "2", "3"} {
+		errs = append(errs, sendAsync(bot, 1, text))
+		// enqueue in known order
+		time.Sleep(5 * time.Millisecond)
+	}
+	errs = append(errs, sendAsync(bot, 2, "other"))
+	waitSent(t, errs...)
+
+	var chat1 []sentMessage
+	for _, m := range sent() {
+		if m.chatID == 1 {
+			chat1 = append(chat1, m)
+		}
+	}
+
+	if len(chat1) != 3 {
+		t.Fatalf("expected 3 messages to chat 1, got %d", len(chat1))
+	}
+	for i, m := range chat1 {
+		if want := string(rune('1' + i)); m.text != want {
+			t.Fatalf("message %d: expected %q, got %q", i, want, m.text)
+		}
+		if i > 0 && m.at.Sub(chat1[i-1].at) < 80*time.Millisecond {
+			t.Fatalf("messages to chat were sent %v apart", m.at.Sub(chat1[i-1].at))
+		}
+	}
+
+	// other chat does not wait for the limit of chat 1
+	other := sent()[1]
+	if other.chatID != 2 {
+		t.Fatalf("expected message to chat 2 to be sent second, got %+v", sent())
+	}
+}
+
+func TestQueueRetryAfter(t *testing.T) {
+	f := newFakeAPI(t, testToken)
+
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+	f.handle("sendMessage", func(params map[string]any) apiResponse {
+		mu.Lock()
+		defer mu.Unlock()
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			resp := apiResponse{ErrorCode: http.StatusTooManyRequests, Description: "Too Many Requests"}
+			resp.Parameters = &struct {
+				RetryAfter int `json:"retry_after"`
+			}{RetryAfter: 1}
+			return resp
+		}
+		return okResponse(f.t, Message{MessageID: 1})
+	})
+
+	bot, _ := runQueue(t, f, WithRateLimits(100, 100), WithRetries(3, time.Millisecond, time.Millisecond))
+	waitSent(t, sendAsync(bot, 1, "hi"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(times) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(times))
+	}
+	if d := times[1].Sub(times[0]); d < time.Second {
+		t.Fatalf("retry_after was not honored, retried after %v", d)
+	}
+}
+
+func TestQueueRetriesTransientErrors(t *testing.T) {
+	f := newFakeAPI(t, testToken)
+
+	var calls int
+	var mu sync.Mutex
+	f.handle("sendMessage", func(params map[string]any) apiResponse {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls < 3 {
+			return apiResponse{ErrorCode: http.StatusBadGateway, Description: "Bad Gateway"}
+		}
+		return okResponse(f.t, Message{MessageID: 7})
+	})
+
+	bot, _ := runQueue(t, f, WithRateLimits(100, 100), WithRetries(5, time.Millisecond, 10*time.Millisecond))
+
+	msg, err := bot.SendMessage(context.Background(), SendMessageParams{ChatID: 1, Text: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.MessageID != 7 {
+		t.Fatalf("expected delivered message, got %+v", msg)
+	}
+	if got := f.callCount("sendMessage"); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestQueueReportsFailures(t *testing.T) {
+	f := newFakeAPI(t, testToken)
+	f.handle("sendMessage", func(params map[string]any) apiResponse {
+		if params["chat_id"].(float64) == 1 {
+			return apiResponse{ErrorCode: http.StatusForbidden, Description: "bot was blocked by the user"}
+		}
+		return apiResponse{ErrorCode: http.StatusInternalServerError, Description: "Internal Server Error"}
+	})
+
+	bot, _ := runQueue(t, f, WithRateLimits(100, 100), WithRetries(2, time.Millisecond, time.Millisecond))
+
+	var apiErr *APIError
+
+	_, err := bot.SendMessage(context.Background(), SendMessageParams{ChatID: 1, Text: "hi"})
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %v", err)
+	}
+	if got := f.callCount("sendMessage"); got != 1 {
+		t.Fatalf("permanent error must not be retried, got %d attempts", got)
+	}
+
+	_, err = bot.SendMessage(context.Background(), SendMessageParams{ChatID: 2, Text: "hi"})
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %v", err)
+	}
+	if got := f.callCount("sendMessage"); got != 3 {
+		t.Fatalf("expected 2 attempts of transient error, got %d", got-1)
+	}
+}
+
+func TestQueueClose(t *testing.T) {
+	f := newFakeAPI(t, testToken)
+	recordSends(f)
+
+	bot, cancel := runQueue(t, f, WithRateLimits(100, 0.1))
+
+	// first message uses the burst, second waits for 10s
+	waitSent(t, sendAsync(bot, 1, "first"))
+	waiting := sendAsync(bot, 1, "second")
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+
+	select {
+	case err := <-waiting:
+		if !errors.Is(err, ErrQueueClosed) {
+			t.Fatalf("expected ErrQueueClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending message was not released on stop")
+	}
+
+	_, err := bot.SendMessage(context.Background(), SendMessageParams{ChatID: 1, Text: "late"})
+	if !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expected ErrQueueClosed after stop, got %v", err)
+	}
+}
+
+func TestQueueSendsAfterDrainTimeout(t *testing.T) {
+	f := newFakeAPI(t, testToken)
+	recordSends(f)
+
+	shortDrain := func(q *Queue) { q.drainTimeout = 20 * time.Millisecond }
+	bot, _ := runQueue(t, f, WithRateLimits(100, 100), WithRetries(1, time.Millisecond, time.Millisecond), shortDrain)
+
+	// drain timeout of running queue does not limit its sends
+	time.Sleep(50 * time.Millisecond)
+
+	waitSent(t, sendAsync(bot, 1, "late"))
+	if got := f.callCount("sendMessage"); got != 1 {
+		t.Fatalf("expected single attempt, got %d", got)
+	}
+}
@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"botmanager/internal/manager"
+)
+
+// tokenFormat is format of tokens issued by BotFather: "<bot id>:<secret>".
+var tokenFormat = regexp.MustCompile(`^\d+:[\w-]+$`)
+
+// TokenValidator checks bot tokens with getMe,
+// it implements manager.TokenValidator.
+type TokenValidator struct {
+	client *Client
+}
+
+// NewTokenValidator creates a new validator calling Bot API through client.
+func NewTokenValidator(client *Client) *TokenValidator {
+	if client == nil {
+		panic("telegram: Client is nil")
+	}
+
+	return &TokenValidator{client: client}
+}
+
+// Validate returns identity of bot owning token.
+//
+// Malformed tokens and tokens rejected by Telegram
+// fail with manager.ErrInvalidToken, other errors mean
+// token could not be checked.
+func (v *TokenValidator) Validate(ctx context.Context, token string) (manager.Identity, error) {
+	if !tokenFormat.MatchString(token) {
+		return manager.Identity{}, fmt.Errorf("%w: malformed token", manager.ErrInvalidToken)
+	}
+
+	me, err := NewBot(v.client, token).GetMe(ctx)
+	if err != nil {
+		var apiErr *APIError
+		// Bot API answers 404 to tokens of a wrong shape.
+		if errors.As(err, &apiErr) &&
+			(apiErr.Code == http.StatusUnauthorized || apiErr.Code == http.StatusNotFound) {
+			return manager.Identity{}, fmt.Errorf("%w: %s", manager.ErrInvalidToken, apiErr.Description)
+		}
+		return manager.Identity{}, fmt.Errorf("telegram: validate token: %w", err)
+	}
+
+	if !me.IsBot {
+		return manager.Identity{}, fmt.Errorf("%w: not a bot account", manager.ErrInvalidToken)
+	}
+
+	return manager.Identity{
+		TelegramID:              me.ID,
+		Username:                me.Username,
+		CanJoinGroups:           me.CanJoinGroups,
+		CanReadAllGroupMessages: me.CanReadAllGroupMessages,
+		SupportsInlineQueries:   me.SupportsInlineQueries,
+	}, nil
+}
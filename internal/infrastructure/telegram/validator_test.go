@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"botmanager/internal/manager"
+)
+
+func TestTokenValidatorReturnsIdentity(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.handle("getMe", func(map[string]any) apiResponse {
+		return okResponse(t, User{
+			ID:                    123456,
+			IsBot:                 true,
+			FirstName:             "Shop",
+			Username:              "shop_bot",
+			CanJoinGroups:         true,
+			SupportsInlineQueries: true,
+		})
+	})
+
+	identity, err := NewTokenValidator(api.client()).Validate(context.Background(), testToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := manager.Identity{
+		TelegramID:            123456,
+		Username:              "shop_bot",
+		CanJoinGroups:         true,
+		SupportsInlineQueries: true,
+	}
+	if identity != want {
+		t.Fatalf("expected %+v, got %+v", want, identity)
+	}
+}
+
+func TestTokenValidatorRejectsUnknownToken(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+
+	_, err := NewTokenValidator(api.client()).Validate(context.Background(), "654321:revoked")
+	if !errors.Is(err, manager.ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestTokenValidatorRejectsMalformedToken(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+
+	_, err := NewTokenValidator(api.client()).Validate(context.Background(), "not a token")
+	if !errors.Is(err, manager.ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+	if n := api.callCount("getMe"); n != 0 {
+		t.Fatalf("expected no getMe calls, got %d", n)
+	}
+}
+
+func TestTokenValidatorServerError(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.handle("getMe", func(map[string]any) apiResponse {
+		return apiResponse{ErrorCode: 502, Description: "Bad Gateway"}
+	})
+
+	_, err := NewTokenValidator(api.client()).Validate(context.Background(), testToken)
+	if err == nil || errors.Is(err, manager.ErrInvalidToken) {
+		t.Fatalf("expected transient error, got %v", err)
+	}
+}
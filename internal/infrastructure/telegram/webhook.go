@@ -0,0 +1,276 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"botmanager/internal/manager"
+)
+
+// SecretHeader is header carrying secret_token of webhook.
+const SecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+const deleteWebhookTimeout = 10 * time.Second
+
+var (
+	_ manager.ConfigurableRunner = (*Webhook)(nil)
+	_ http.Handler               = (*Webhook)(nil)
+)
+
+// Webhook is manager.Runner receiving updates via webhooks.
+//
+// A single Webhook serves every bot of manager: it is mounted once
+// on HTTP router and routes incoming request to the bot by secret
+// path segment. Each bot also gets its own secret_token, which
+// Telegram sends back in SecretHeader.
+//
+// On start bot registers webhook with setWebhook,
+// on stop it removes it with deleteWebhook.
+type Webhook struct {
+	client  *Client
+	baseURL string
+	handler Handler
+	logger  *slog.Logger
+
+	queueOpts []QueueOption
+
+	mu     sync.RWMutex
+	routes map[string]*webhookRoute
+}
+
+type webhookRoute struct {
+	botID  string
+	secret string
+	bot    *Bot
+	logger *slog.Logger
+}
+
+// WebhookOption configures Webhook.
+type WebhookOption func(*Webhook)
+
+// WithWebhookQueueOptions configures outbound queue of served bots.
+func WithWebhookQueueOptions(opts ...QueueOption) WebhookOption {
+	return func(w *Webhook) {
+		w.queueOpts = opts
+	}
+}
+
+// NewWebhook creates a new webhook runner.
+//
+// baseURL is public URL where Webhook is mounted,
+// for example "https://example.com/telegram/webhook".
+// logger may be nil, in that case slog.Default() is used.
+func NewWebhook(
+	client *Client,
+	baseURL string,
+	handler Handler,
+	logger *slog.Logger,
+	opts ...WebhookOption,
+) *Webhook {
+	if client == nil {
+		panic("telegram: Client is nil")
+	}
+
+	if handler == nil {
+		panic("telegram: Handler is nil")
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	w := &Webhook{
+		client:  client,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		handler: handler,
+		logger:  logger,
+		routes:  make(map[string]*webhookRoute),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+type setWebhookParams struct {
+	URL            string   `json:"url"`
+	SecretToken    string   `json:"secret_token"`
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+type deleteWebhookParams struct {
+	DropPendingUpdates bool `json:"drop_pending_updates"`
+}
+
+// Run registers webhook of bot and serves it until ctx is cancelled.
+func (w *Webhook) Run(ctx context.Context, token string) error {
+	return w.RunWithConfig(ctx, token, manager.BotConfig{}, nil)
+}
+
+// RunWithConfig registers webhook receiving updates of types
+// allowed by cfg and serves it until ctx is cancelled.
+//
+// Config changes received from changes update the registered webhook
+// in place, its URL and secret stay the same.
+func (w *Webhook) RunWithConfig(
+	ctx context.Context,
+	token string,
+	cfg manager.BotConfig,
+	changes <-chan manager.BotConfig,
+) error {
+	botID := manager.BotID(token)
+	logger := manager.Logger(ctx, w.logger.With("bot_id", botID))
+
+	routePath, err := randomSecret()
+	if err != nil {
+		return err
+	}
+
+	secret, err := randomSecret()
+	if err != nil {
+		return err
+	}
+
+	route := &webhookRoute{
+		botID:  botID,
+		secret: secret,
+		bot:    NewBot(w.client, token),
+		logger: logger,
+	}
+
+	stopQueue := startQueue(route.bot, logger, w.queueOpts...)
+	defer stopQueue()
+
+	w.mu.Lock()
+	w.routes[routePath] = route
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		delete(w.routes, routePath)
+		w.mu.Unlock()
+	}()
+
+	params := setWebhookParams{
+		URL:            w.baseURL + "/" + routePath,
+		SecretToken:    secret,
+		AllowedUpdates: allowedUpdates(cfg),
+	}
+
+	if err := route.bot.Call(ctx, "setWebhook", params, nil); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		logger.Error("failed to set webhook", "err", err)
+		return fmt.Errorf("set webhook: %w", err)
+	}
+
+	logger.Info("bot webhook registered")
+
+	reloadWebhook(ctx, route.bot, params, changes, logger)
+
+	delCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deleteWebhookTimeout)
+	defer cancel()
+
+	if err := route.bot.Call(delCtx, "deleteWebhook", deleteWebhookParams{}, nil); err != nil {
+		logger.Warn("failed to delete webhook", "err", err)
+	}
+
+	logger.Info("bot webhook removed")
+
+	return nil
+}
+
+// reloadWebhook applies config changes to registered webhook
+// until ctx is cancelled.
+func reloadWebhook(
+	ctx context.Context,
+	bot *Bot,
+	params setWebhookParams,
+	changes <-chan manager.BotConfig,
+	logger *slog.Logger,
+) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case cfg := <-changes:
+			params.AllowedUpdates = allowedUpdates(cfg)
+
+			// Failed update keeps previous settings, webhook still works.
+			if err := bot.Call(ctx, "setWebhook", params, nil); err != nil {
+				if ctx.Err() == nil {
+					logger.Error("failed to update webhook", "err", err)
+				}
+				continue
+			}
+
+			logger.Info("bot config reloaded", "allowed_updates", params.AllowedUpdates)
+		}
+	}
+}
+
+// ServeHTTP receives update for the bot addressed by last path segment.
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.mu.RLock()
+	route, ok := w.routes[path.Base(r.URL.Path)]
+	w.mu.RUnlock()
+
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+
+	got := r.Header.Get(SecretHeader)
+	if subtle.ConstantTimeCompare([]byte(got), []byte(route.secret)) != 1 {
+		route.logger.Warn("webhook secret mismatch")
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var u Update
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		http.Error(rw, "invalid update", http.StatusBadRequest)
+		return
+	}
+
+	// Handler errors are not reported to Telegram:
+	// otherwise it would redeliver the same update again and again.
+	ctx := manager.ContextWithLogger(r.Context(), route.logger)
+	if err := handleSafely(ctx, w.handler, route.bot, u); err != nil {
+		route.logger.Warn("failed to handle update",
+			"update_id", u.UpdateID,
+			"err", err,
+		)
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// randomSecret returns url-safe random string accepted by Telegram
+// both as path segment and secret_token.
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
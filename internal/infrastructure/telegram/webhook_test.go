@@ -0,0 +1,233 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"botmanager/internal/manager"
+)
+
+type webhookRegistration struct {
+	url    string
+	secret string
+}
+
+func captureSetWebhook(t *testing.T, api *fakeAPI) <-chan webhookRegistration {
+	t.Helper()
+
+	regs := make(chan webhookRegistration, 10)
+	api.handle("setWebhook", func(params map[string]any) apiResponse {
+		u, _ := params["url"].(string)
+		s, _ := params["secret_token"].(string)
+		regs <- webhookRegistration{url: u, secret: s}
+		return okResponse(t, true)
+	})
+
+	return regs
+}
+
+func waitRegistration(t *testing.T, regs <-chan webhookRegistration) webhookRegistration {
+	t.Helper()
+
+	select {
+	case reg := <-regs:
+		return reg
+	case <-time.After(time.Second):
+		t.Fatal("webhook was not registered")
+		return webhookRegistration{}
+	}
+}
+
+func postUpdate(t *testing.T, target string, secret string, u Update) int {
+	t.Helper()
+
+	body, _ := json.Marshal(u)
+	req, _ := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if secret != "" {
+		req.Header.Set(SecretHeader, secret)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post update: %v", err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+// serverURL rewrites registered webhook url to test server.
+func serverURL(t *testing.T, srv *httptest.Server, registered string) string {
+	t.Helper()
+
+	u, err := url.Parse(registered)
+	if err != nil {
+		t.Fatalf("parse webhook url: %v", err)
+	}
+	return srv.URL + u.Path
+}
+
+func TestWebhookServesUpdates(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	regs := captureSetWebhook(t, api)
+
+	handler := newRecordingHandler()
+	wh := NewWebhook(api.client(), "https://example.com/telegram/webhook", handler, nil)
+
+	srv := httptest.NewServer(wh)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- wh.Run(ctx, testToken) }()
+
+	reg := waitRegistration(t, regs)
+	if reg.secret == "" {
+		t.Fatal("expected secret token to be registered")
+	}
+
+	target := serverURL(t, srv, reg.url)
+
+	if code := postUpdate(t, target, reg.secret, Update{UpdateID: 7}); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	if ids := handler.wait(t, 1); ids[0] != 7 {
+		t.Fatalf("unexpected update %d", ids[0])
+	}
+
+	if code := postUpdate(t, target, "wrong", Update{UpdateID: 8}); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for wrong secret, got %d", code)
+	}
+
+	if code := postUpdate(t, target, "", Update{UpdateID: 8}); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for missing secret, got %d", code)
+	}
+
+	if code := postUpdate(t, srv.URL+"/unknown", reg.secret, Update{UpdateID: 8}); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown path, got %d", code)
+	}
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("webhook runner did not stop")
+	}
+
+	if api.callCount("deleteWebhook") != 1 {
+		t.Fatal("expected webhook to be deleted on stop")
+	}
+
+	if code := postUpdate(t, target, reg.secret, Update{UpdateID: 9}); code != http.StatusNotFound {
+		t.Fatalf("expected 404 after stop, got %d", code)
+	}
+}
+
+func TestWebhookSecretsArePerBot(t *testing.T) {
+	const otherToken = "654321:other"
+
+	api1 := newFakeAPI(t, testToken)
+	regs1 := captureSetWebhook(t, api1)
+
+	handler := newRecordingHandler()
+	wh := NewWebhook(api1.client(), "https://example.com/hook", handler, nil)
+
+	srv := httptest.NewServer(wh)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() { _ = wh.Run(ctx, testToken) }()
+	reg1 := waitRegistration(t, regs1)
+
+	api1.allow(otherToken)
+	go func() { _ = wh.Run(ctx, otherToken) }()
+	reg2 := waitRegistration(t, regs1)
+
+	if reg1.url == reg2.url || reg1.secret == reg2.secret {
+		t.Fatal("expected distinct webhook path and secret per bot")
+	}
+
+	target1 := serverURL(t, srv, reg1.url)
+	if code := postUpdate(t, target1, reg2.secret, Update{UpdateID: 1}); code != http.StatusUnauthorized {
+		t.Fatalf("expected secret of another bot to be rejected, got %d", code)
+	}
+
+	if code := postUpdate(t, target1, reg1.secret, Update{UpdateID: 1}); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+}
+
+func TestWebhookSetFailureReturnsError(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	api.handle("setWebhook", func(params map[string]any) apiResponse {
+		return apiResponse{ErrorCode: http.StatusBadRequest, Description: "bad webhook"}
+	})
+
+	wh := NewWebhook(api.client(), "https://example.com/hook", newRecordingHandler(), nil)
+
+	if err := wh.Run(context.Background(), testToken); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestWebhookReloadsConfig(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	regs := captureSetWebhook(t, api)
+
+	wh := NewWebhook(api.client(), "https://example.com/hook", newRecordingHandler(), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan manager.BotConfig, 1)
+	go func() {
+		_ = wh.RunWithConfig(ctx, testToken, manager.BotConfig{}, changes)
+	}()
+
+	first := waitRegistration(t, regs)
+	api.waitParam(t, "setWebhook", "allowed_updates", "[]")
+
+	changes <- manager.BotConfig{AllowedUpdates: []string{"callback_query"}}
+
+	second := waitRegistration(t, regs)
+	if second != first {
+		t.Fatalf("reload must keep webhook url and secret, got %+v and %+v", first, second)
+	}
+	api.waitParam(t, "setWebhook", "allowed_updates", "[callback_query]")
+}
+
+func TestWebhookAppliesQueueOptions(t *testing.T) {
+	api := newFakeAPI(t, testToken)
+	regs := captureSetWebhook(t, api)
+
+	applied := make(chan struct{}, 1)
+	wh := NewWebhook(api.client(), "https://example.com/hook", newRecordingHandler(), nil,
+		WithWebhookQueueOptions(func(q *Queue) { applied <- struct{}{} }),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() { _ = wh.Run(ctx, testToken) }()
+
+	waitRegistration(t, regs)
+
+	select {
+	case <-applied:
+	case <-time.After(time.Second):
+		t.Fatal("expected queue option to be applied")
+	}
+}
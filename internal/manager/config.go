@@ -0,0 +1,26 @@
+package manager
+
+import "slices"
+
+// BotConfig is configuration of registered bot
+// which can be changed while bot runs, see Manager.Update.
+type BotConfig struct {
+	Name string
+	// AllowedUpdates lists types of updates bot receives,
+	// empty list means runner default.
+	AllowedUpdates []string
+	// Storefront is catalog scope of bot.
+	Storefront Storefront
+	// ShopID is shop owning bot, it is set on registration
+	// and can not be changed by Update.
+	ShopID int
+	// Policy supervises runner, it is not persisted:
+	// restored bots use manager default policy.
+	Policy RestartPolicy
+}
+
+func (c BotConfig) clone() BotConfig {
+	c.AllowedUpdates = slices.Clone(c.AllowedUpdates)
+	c.Storefront = c.Storefront.clone()
+	return c
+}
@@ -1,15 +1,128 @@
 package manager
 
-import "context"
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
 
+// Bot describes registered bot.
+//
+// Raw token is never exposed, TokenHint holds redacted form of it.
+// Identity is known only for bots validated by TokenValidator.
+// ShopID is shop owning bot, zero for bots of the instance.
 type Bot struct {
-	Name  string
-	Token string
+	ID        string
+	Name      string
+	TokenHint string
+	Identity  Identity
+	ShopID    int
+}
+
+// DisplayName returns @username of validated bot and Name otherwise.
+func (b Bot) DisplayName() string {
+	if b.Identity.Username != "" {
+		return "@" + b.Identity.Username
+	}
+	return b.Name
 }
 
 type botEntry struct {
 	bot    Bot
+	token  string
 	cancel context.CancelFunc
 	done   chan struct{}
-	status BotStatus
+	// updates passes config changes to ConfigurableRunner,
+	// it holds at most the latest one.
+	updates chan BotConfig
+	// logs are kept across renew, logger writes to them.
+	logs   *logBuffer
+	logger *slog.Logger
+
+	// guarded by Manager.mu
+	policy         RestartPolicy
+	allowedUpdates []string
+	storefront     Storefront
+	status         BotStatus
+	lastErr        error
+	startedAt      time.Time
+	restarts       int
+	restartTimes   []time.Time
+	crashes        []Crash
+
+	// stopStatus is set when runner exits after cancel:
+	// StatusStopped, or StatusPaused for paused bot.
+	stopStatus BotStatus
+}
+
+// renew returns a new entry of the same bot
+// keeping its config, identity, crash history and logs.
+// Caller must hold Manager.mu.
+func (e *botEntry) renew() *botEntry {
+	next := newEntry(e.token, e.config(), e.bot.Identity)
+	next.crashes = e.crashes
+	next.logs = e.logs
+	return next
+}
+
+// config returns current config of entry. Caller must hold Manager.mu.
+func (e *botEntry) config() BotConfig {
+	return BotConfig{
+		Name:           e.bot.Name,
+		AllowedUpdates: e.allowedUpdates,
+		Storefront:     e.storefront,
+		ShopID:         e.bot.ShopID,
+		Policy:         e.policy,
+	}.clone()
+}
+
+// apply sets config of entry. Caller must hold Manager.mu.
+func (e *botEntry) apply(cfg BotConfig) {
+	e.bot.Name = cfg.Name
+	e.allowedUpdates = cfg.AllowedUpdates
+	e.storefront = cfg.Storefront
+	e.bot.ShopID = cfg.ShopID
+	e.policy = cfg.Policy
+}
+
+// applyRecord sets config of entry saved in rec and reports
+// whether it changed. Caller must hold Manager.mu.
+func (e *botEntry) applyRecord(rec BotRecord) bool {
+	if e.bot.Name == rec.Name &&
+		slices.Equal(e.allowedUpdates, rec.AllowedUpdates) &&
+		e.storefront.equal(rec.Storefront) {
+		return false
+	}
+
+	e.bot.Name = rec.Name
+	e.allowedUpdates = slices.Clone(rec.AllowedUpdates)
+	e.storefront = rec.Storefront.clone()
+	return true
+}
+
+// state builds snapshot of entry. Caller must hold Manager.mu.
+func (e *botEntry) state(now time.Time) BotState {
+	s := BotState{
+		Bot:       e.bot,
+		Status:    e.status,
+		LastError: e.lastErr,
+		StartedAt: e.startedAt,
+		Restarts:  e.restarts,
+	}
+
+	if e.status == StatusRunning && !e.startedAt.IsZero() {
+		s.Uptime = now.Sub(e.startedAt)
+	}
+
+	return s
+}
+
+// recordCrash appends crash to history keeping at most limit
+// latest crashes. Caller must hold Manager.mu.
+func (e *botEntry) recordCrash(c Crash, limit int) {
+	if len(e.crashes) >= limit {
+		e.crashes = slices.Delete(e.crashes, 0, len(e.crashes)-limit+1)
+	}
+	e.crashes = append(e.crashes, c)
 }
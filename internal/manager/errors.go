@@ -1,8 +1,46 @@
 package manager
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	ErrDuplicationToken = errors.New("duplicate token")
 	ErrNotFound         = errors.New("bot not found")
+	ErrTooManyRestarts  = errors.New("too many restarts")
+	// ErrInvalidState is returned when operation is not allowed
+	// in current bot status, e.g. Resume of a running bot.
+	ErrInvalidState  = errors.New("operation not allowed in bot state")
+	ErrInvalidConfig = errors.New("invalid bot config")
+	ErrInvalidToken  = errors.New("invalid bot token")
+	// ErrLeaseLost means bot was stopped because
+	// its lease is held by another instance.
+	ErrLeaseLost = errors.New("bot lease lost")
 )
+
+// StopTimeoutError reports bots whose runners did not return
+// before context of StopAll or Remove was done.
+type StopTimeoutError struct {
+	BotIDs []string
+	Err    error
+}
+
+func (e *StopTimeoutError) Error() string {
+	return fmt.Sprintf("bots did not stop in time: %s: %v", strings.Join(e.BotIDs, ", "), e.Err)
+}
+
+func (e *StopTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is error of runner which panicked.
+type PanicError struct {
+	Value any
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("runner panicked: %v", e.Value)
+}
@@ -0,0 +1,105 @@
+package manager
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType is kind of bot lifecycle event.
+type EventType int
+
+const (
+	// BotRegistered is published when bot is added by Register.
+	BotRegistered EventType = iota
+	// BotStarted is published when runner of bot starts.
+	BotStarted
+	// BotStopped is published when runner returns without error
+	// or after it was cancelled (Remove, Pause, StopAll).
+	BotStopped
+	// BotFailed is published when runner returns error or panics,
+	// Status tells whether it is going to be restarted.
+	BotFailed
+	// BotRestarted is published when runner is scheduled to start again,
+	// by restart policy or by Restart.
+	BotRestarted
+)
+
+// String returns human readable event type name.
+func (t EventType) String() string {
+	switch t {
+	case BotRegistered:
+		return "registered"
+	case BotStarted:
+		return "started"
+	case BotStopped:
+		return "stopped"
+	case BotFailed:
+		return "failed"
+	case BotRestarted:
+		return "restarted"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes change of bot lifecycle.
+//
+// Status is bot status right after the event.
+// Err is set for BotFailed. Restarts and Delay are set for BotRestarted
+// published by restart policy and are zero after Restart.
+type Event struct {
+	Type     EventType
+	Bot      Bot
+	Status   BotStatus
+	Time     time.Time
+	Err      error
+	Restarts int
+	Delay    time.Duration
+}
+
+// defaultEventBuffer is buffer size of subscription channel.
+const defaultEventBuffer = 64
+
+// subscribers fans events out to subscription channels.
+//
+// Publishing never blocks: events which do not fit
+// into buffer of slow subscriber are dropped for it.
+type subscribers struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+func (s *subscribers) add(buffer int) chan Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subs == nil {
+		s.subs = make(map[chan Event]struct{})
+	}
+
+	ch := make(chan Event, buffer)
+	s.subs[ch] = struct{}{}
+	return ch
+}
+
+func (s *subscribers) remove(ch chan Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[ch]; ok {
+		delete(s.subs, ch)
+		close(ch)
+	}
+}
+
+func (s *subscribers) publish(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
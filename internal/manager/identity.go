@@ -0,0 +1,21 @@
+package manager
+
+import "context"
+
+// Identity is Telegram account of bot as reported by getMe.
+type Identity struct {
+	TelegramID              int64
+	Username                string
+	CanJoinGroups           bool
+	CanReadAllGroupMessages bool
+	SupportsInlineQueries   bool
+}
+
+// TokenValidator checks bot token before registration.
+//
+// Validate must return error wrapping ErrInvalidToken
+// for tokens rejected by Telegram, other errors mean
+// that validation could not be performed.
+type TokenValidator interface {
+	Validate(ctx context.Context, token string) (Identity, error)
+}
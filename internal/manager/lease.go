@@ -0,0 +1,244 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultLeaseTTL is lifetime of bot lease not renewed by its owner.
+	defaultLeaseTTL = 30 * time.Second
+	// releaseTimeout limits release of lease after bot stops.
+	releaseTimeout = 5 * time.Second
+)
+
+var (
+	// errBotDisabled stops bot paused by another instance.
+	errBotDisabled = errors.New("bot disabled in repository")
+	// errBotDeleted stops bot removed by another instance.
+	errBotDeleted = errors.New("bot deleted from repository")
+)
+
+// LeaseStore grants ownership of bots to manager instances,
+// so a bot runs on exactly one of replicas sharing the store.
+//
+// Acquire takes lease of bot for owner or extends lease owner
+// already holds, for ttl. It returns false if bot is leased by
+// another owner whose lease has not expired.
+// Release gives up lease of owner, it is no-op if owner
+// does not hold the lease.
+type LeaseStore interface {
+	Acquire(ctx context.Context, botID, owner string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, botID, owner string) error
+}
+
+// WithLeases makes bots run only while manager holds their lease in store.
+//
+// owner must be unique per manager instance. Leases are renewed
+// every ttl/3, bot which lease could not be renewed is stopped and
+// waits in StatusStandby until lease is free again, so bots of
+// stopped or hung instance fail over to another one after ttl.
+// ttl <= 0 means 30 seconds.
+//
+// Replicas must share repository as well. Bot record is re-read
+// when lease is acquired and on every renewal, so bot paused or
+// removed by another instance stops and config changed there is
+// applied. Run RunReconciler to pick up bots registered, resumed
+// or removed by other instances while they are not leased here.
+func WithLeases(store LeaseStore, owner string, ttl time.Duration) Option {
+	return func(m *Manager) {
+		if ttl <= 0 {
+			ttl = defaultLeaseTTL
+		}
+		m.leases = store
+		m.owner = owner
+		m.leaseTTL = ttl
+	}
+}
+
+// awaitLease blocks until manager holds lease of entry,
+// keeping entry in StatusStandby meanwhile.
+// Returns false if ctx is done first.
+func (m *Manager) awaitLease(ctx context.Context, entry *botEntry) bool {
+	ticker := time.NewTicker(m.leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		ok, err := m.leases.Acquire(ctx, entry.bot.ID, m.owner, m.leaseTTL)
+		if err == nil && ok {
+			m.mu.Lock()
+			if entry.status == StatusStandby {
+				entry.status = StatusStarting
+			}
+			m.mu.Unlock()
+			return true
+		}
+
+		m.mu.Lock()
+		if err != nil && ctx.Err() == nil {
+			entry.lastErr = fmt.Errorf("acquire lease: %w", err)
+		}
+		if entry.status == StatusStarting {
+			entry.status = StatusStandby
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// runLeased runs entry like runOnce while renewing its lease.
+// Runner is cancelled and ErrLeaseLost returned
+// if lease can not be renewed, errBotDisabled or errBotDeleted
+// if record of bot is disabled or deleted meanwhile.
+func (m *Manager) runLeased(ctx context.Context, entry *botEntry) error {
+	if m.leases == nil {
+		return m.runOnce(ctx, entry)
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	heartbeat := make(chan struct{})
+	go func() {
+		defer close(heartbeat)
+		m.heartbeat(runCtx, entry, cancel)
+	}()
+
+	err := m.runOnce(runCtx, entry)
+
+	cancel(nil)
+	<-heartbeat
+
+	// cause of heartbeat wins over error of cancelled runner
+	if cause := context.Cause(runCtx); ctx.Err() == nil && !errors.Is(cause, context.Canceled) {
+		return cause
+	}
+
+	return err
+}
+
+// heartbeat renews lease of entry and syncs its record
+// until ctx is done.
+//
+// Lease is considered lost when another owner holds it or
+// when renewal keeps failing so long that lease may expire
+// before the next attempt.
+func (m *Manager) heartbeat(ctx context.Context, entry *botEntry, cancel context.CancelCauseFunc) {
+	interval := m.leaseTTL / 3
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := m.leases.Acquire(ctx, entry.bot.ID, m.owner, m.leaseTTL)
+		switch {
+		case err == nil && ok:
+			renewed = time.Now()
+			if err := m.syncRecord(ctx, entry); err != nil {
+				cancel(err)
+				return
+			}
+		case err == nil:
+			cancel(ErrLeaseLost)
+			return
+		case ctx.Err() != nil:
+			return
+		case time.Since(renewed)+interval >= m.leaseTTL:
+			cancel(fmt.Errorf("%w: %w", ErrLeaseLost, err))
+			return
+		}
+	}
+}
+
+// releaseLease gives up lease of entry, so another instance
+// does not wait for its expiry.
+func (m *Manager) releaseLease(entry *botEntry) {
+	if m.leases == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	// lease which is not released expires anyway
+	_ = m.leases.Release(ctx, entry.bot.ID, m.owner)
+}
+
+// syncRecord re-reads record of entry, so bot is not run with
+// state which another instance sharing repository has changed.
+// Changed config is applied to entry and sent to its runner.
+//
+// Returns errBotDisabled or errBotDeleted if bot must not run.
+// Failure to read record is only logged, bot holding lease
+// keeps running.
+func (m *Manager) syncRecord(ctx context.Context, entry *botEntry) error {
+	if m.repo == nil {
+		return nil
+	}
+
+	// read under lock, so record saved by local Update
+	// is not overwritten by older one
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, err := m.repo.ByID(ctx, entry.bot.ID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return errBotDeleted
+	case err != nil:
+		if ctx.Err() == nil {
+			m.logger.Warn("failed to read bot record", "bot_id", entry.bot.ID, "err", err)
+		}
+		return nil
+	case !rec.Enabled:
+		return errBotDisabled
+	}
+
+	if entry.applyRecord(rec) {
+		select {
+		case <-entry.updates:
+		default:
+		}
+		entry.updates <- entry.config()
+	}
+
+	return nil
+}
+
+// retire stops entry after syncRecord returned err:
+// disabled bot stays in StatusPaused, deleted one is dropped.
+func (m *Manager) retire(entry *botEntry, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case entry.status == StatusStopping:
+		// stopped locally meanwhile, stopper completes it
+		entry.status = entry.stopStatus
+	case errors.Is(err, errBotDeleted):
+		entry.status = StatusStopped
+		if m.bots[entry.bot.ID] == entry {
+			delete(m.bots, entry.bot.ID)
+			entry.logs.close()
+		}
+	default:
+		entry.status = StatusPaused
+	}
+
+	m.publish(BotStopped, entry, err)
+}
@@ -0,0 +1,316 @@
+package manager
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// defaultLogCapacity is number of log records kept per bot.
+const defaultLogCapacity = 500
+
+// LogRecord is a log record written by bot runner or by Manager about bot.
+//
+// Attrs hold attributes of record, nested groups are flattened
+// to dotted keys ("request.id").
+type LogRecord struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   map[string]string
+}
+
+// LogQuery filters records returned by Manager.Logs.
+//
+// Zero Limit means all kept records.
+type LogQuery struct {
+	MinLevel slog.Level
+	Limit    int
+}
+
+// WithLogger sets logger every bot logger is derived from.
+//
+// Without this option slog.Default() is used.
+func WithLogger(l *slog.Logger) Option {
+	return func(m *Manager) {
+		m.logger = l
+	}
+}
+
+// WithBotLogs sets number of latest log records kept per bot
+// and minimal level of kept records.
+//
+// Records below level are still passed to manager logger.
+func WithBotLogs(capacity int, level slog.Leveler) Option {
+	return func(m *Manager) {
+		m.logCapacity = capacity
+		m.logLevel = level
+	}
+}
+
+type loggerKey struct{}
+
+// ContextWithLogger returns ctx carrying logger of bot, see Logger.
+//
+// Runners use it to pass logger of bot to update handlers
+// called outside of runner context.
+func ContextWithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// Logger returns logger of bot run with ctx.
+//
+// Manager passes to Runner context carrying logger enriched
+// with bot id and username, records written to it are kept
+// in bot logs, see Manager.Logs. Context without such logger
+// gets fallback.
+func Logger(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return fallback
+}
+
+// botLogger returns logger of entry. Caller must hold m.mu.
+func (m *Manager) botLogger(entry *botEntry) *slog.Logger {
+	h := &logHandler{
+		next:  m.logger.Handler(),
+		logs:  entry.logs,
+		level: m.logLevel,
+	}
+
+	l := slog.New(h).With("bot_id", entry.bot.ID)
+	if entry.bot.Identity.Username != "" {
+		l = l.With("bot_username", entry.bot.Identity.Username)
+	}
+	return l
+}
+
+// Logs returns latest log records of bot, oldest first.
+func (m *Manager) Logs(id string, q LogQuery) ([]LogRecord, bool) {
+	m.mu.RLock()
+	entry, ok := m.bots[id]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+	return entry.logs.records(q), true
+}
+
+// TailLogs subscribes to new log records of bot
+// with level not below minLevel.
+//
+// Records which do not fit into buffer of slow subscriber are dropped.
+// buffer <= 0 means default buffer size. Returned channel is closed
+// by cancel or when bot is removed.
+func (m *Manager) TailLogs(id string, minLevel slog.Level, buffer int) (<-chan LogRecord, func(), bool) {
+	m.mu.RLock()
+	entry, ok := m.bots[id]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, nil, false
+	}
+
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+
+	ch := entry.logs.subscribe(minLevel, buffer)
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() { entry.logs.unsubscribe(ch) })
+	}, true
+}
+
+// logBuffer is a bounded ring of log records of bot
+// fanned out to live subscribers.
+type logBuffer struct {
+	mu     sync.Mutex
+	ring   []LogRecord
+	next   int
+	full   bool
+	subs   map[chan LogRecord]slog.Level
+	closed bool
+}
+
+func newLogBuffer(capacity int) *logBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &logBuffer{
+		ring: make([]LogRecord, capacity),
+		subs: make(map[chan LogRecord]slog.Level),
+	}
+}
+
+func (b *logBuffer) add(rec LogRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.ring[b.next] = rec
+	b.next = (b.next + 1) % len(b.ring)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for ch, level := range b.subs {
+		if rec.Level < level {
+			continue
+		}
+		select {
+		case ch <- rec:
+		default:
+		}
+	}
+}
+
+func (b *logBuffer) records(q LogQuery) []LogRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ordered := b.ring[:b.next]
+	if b.full {
+		ordered = append(append([]LogRecord(nil), b.ring[b.next:]...), b.ring[:b.next]...)
+	}
+
+	result := make([]LogRecord, 0, len(ordered))
+	for _, rec := range ordered {
+		if rec.Level >= q.MinLevel {
+			result = append(result, rec)
+		}
+	}
+
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[len(result)-q.Limit:]
+	}
+
+	// records are never modified after add, sharing Attrs is safe
+	return result
+}
+
+func (b *logBuffer) subscribe(minLevel slog.Level, buffer int) chan LogRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan LogRecord, buffer)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+
+	b.subs[ch] = minLevel
+	return ch
+}
+
+func (b *logBuffer) unsubscribe(ch chan LogRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// close ends all subscriptions, records are kept.
+func (b *logBuffer) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// logHandler passes records to next handler
+// and keeps records not below level in logs.
+type logHandler struct {
+	next  slog.Handler
+	logs  *logBuffer
+	level slog.Leveler
+	// attrs are attributes added by With, keys are prefixed by groups.
+	attrs map[string]string
+	// group is prefix of keys of attributes added later.
+	group string
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() || h.next.Enabled(ctx, level)
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= h.level.Level() {
+		rec := LogRecord{
+			Time:    r.Time,
+			Level:   r.Level,
+			Message: r.Message,
+			Attrs:   make(map[string]string, len(h.attrs)+r.NumAttrs()),
+		}
+		for k, v := range h.attrs {
+			rec.Attrs[k] = v
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			flattenAttr(rec.Attrs, h.group, a)
+			return true
+		})
+		h.logs.add(rec)
+	}
+
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.next = h.next.WithAttrs(attrs)
+	next.attrs = make(map[string]string, len(h.attrs)+len(attrs))
+	for k, v := range h.attrs {
+		next.attrs[k] = v
+	}
+	for _, a := range attrs {
+		flattenAttr(next.attrs, h.group, a)
+	}
+	return &next
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	next := *h
+	next.next = h.next.WithGroup(name)
+	next.group = h.group + name + "."
+	return &next
+}
+
+// flattenAttr adds a to dst with key prefixed by group,
+// attributes of nested groups get dotted keys.
+func flattenAttr(dst map[string]string, group string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		dst[group+a.Key] = a.Value.String()
+		return
+	}
+
+	prefix := group
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, ga := range a.Value.Group() {
+		flattenAttr(dst, prefix, ga)
+	}
+}
@@ -3,74 +3,881 @@ package manager
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"strings"
 	"sync"
+	"time"
+)
+
+const (
+	// defaultCrashHistory is number of crashes kept per bot.
+	defaultCrashHistory = 10
+	// validateTimeout limits token validation on Register.
+	validateTimeout = 10 * time.Second
 )
 
 type Manager struct {
-	mu     sync.RWMutex
-	bots   map[string]*botEntry
-	runner Runner
+	mu           sync.RWMutex
+	bots         map[string]*botEntry
+	runner       Runner
+	policy       RestartPolicy
+	repo         BotRepository
+	cipher       TokenCipher
+	crashHistory int
+	events       subscribers
+	validator    TokenValidator
+	leases       LeaseStore
+	owner        string
+	leaseTTL     time.Duration
+	logger       *slog.Logger
+	logCapacity  int
+	logLevel     slog.Leveler
+}
+
+// Option configures Manager.
+type Option func(*Manager)
+
+// WithRestartPolicy sets default restart policy
+// used by Register.
+//
+// Without this option runners are never restarted.
+func WithRestartPolicy(p RestartPolicy) Option {
+	return func(m *Manager) {
+		m.policy = p
+	}
+}
+
+// WithRepository enables persistence of registered bots.
+//
+// Register and Remove write through to repo,
+// Restore starts bots saved in it. Tokens are encrypted
+// with cipher before they reach repo.
+func WithRepository(repo BotRepository, cipher TokenCipher) Option {
+	return func(m *Manager) {
+		m.repo = repo
+		m.cipher = cipher
+	}
+}
+
+// WithTokenValidator makes Register validate tokens with v
+// and store identity of bot.
+func WithTokenValidator(v TokenValidator) Option {
+	return func(m *Manager) {
+		m.validator = v
+	}
 }
 
-func NewManager(r Runner) *Manager {
-	return &Manager{
-		bots:   make(map[string]*botEntry),
-		runner: r,
+// WithCrashHistory sets number of latest crashes kept per bot.
+func WithCrashHistory(n int) Option {
+	return func(m *Manager) {
+		m.crashHistory = n
 	}
 }
 
+func NewManager(r Runner, opts ...Option) *Manager {
+	m := &Manager{
+		bots:         make(map[string]*botEntry),
+		runner:       r,
+		crashHistory: defaultCrashHistory,
+		logCapacity:  defaultLogCapacity,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.repo != nil && m.cipher == nil {
+		panic("manager: TokenCipher is nil")
+	}
+
+	if m.crashHistory < 1 {
+		m.crashHistory = 1
+	}
+
+	if m.logger == nil {
+		m.logger = slog.Default()
+	}
+
+	if m.logLevel == nil {
+		m.logLevel = slog.LevelInfo
+	}
+
+	return m
+}
+
+// Register starts bot with manager default restart policy.
 func (m *Manager) Register(name, token string) error {
+	return m.RegisterWithPolicy(name, token, m.policy)
+}
+
+// RegisterInShop starts bot of shop with default restart policy.
+func (m *Manager) RegisterInShop(name, token string, shopID int) error {
+	return m.RegisterWithConfig(token, BotConfig{Name: name, ShopID: shopID, Policy: m.policy})
+}
+
+// RegisterWithPolicy starts bot supervised by policy.
+func (m *Manager) RegisterWithPolicy(name, token string, policy RestartPolicy) error {
+	return m.RegisterWithConfig(token, BotConfig{Name: name, Policy: policy})
+}
+
+// RegisterWithConfig starts bot with cfg.
+//
+// The bot is addressed by BotID(token) afterwards.
+// With TokenValidator tokens rejected by Telegram
+// fail with ErrInvalidToken.
+func (m *Manager) RegisterWithConfig(token string, cfg BotConfig) error {
+	id := BotID(token)
+
+	// cheap check before validation request
+	m.mu.RLock()
+	_, exists := m.bots[id]
+	m.mu.RUnlock()
+
+	if exists {
+		return ErrDuplicationToken
+	}
+
+	var identity Identity
+	if m.validator != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), validateTimeout)
+		defer cancel()
+
+		var err error
+		identity, err = m.validator.Validate(ctx, token)
+		if err != nil {
+			return fmt.Errorf("validate token: %w", err)
+		}
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.bots[token]; exists {
+	if _, exists := m.bots[id]; exists {
 		return ErrDuplicationToken
 	}
 
+	entry := newEntry(token, cfg, identity)
+	if err := m.persist(entry, true); err != nil {
+		return err
+	}
+
+	m.start(entry)
+	m.publish(BotRegistered, entry, nil)
+
+	return nil
+}
+
+// Restore starts every enabled bot saved in repository,
+// disabled bots are restored in StatusPaused.
+//
+// Bots which are already registered are skipped.
+// Bots whose token cannot be decrypted are skipped
+// and reported in returned error.
+// Manager without repository restores nothing.
+func (m *Manager) Restore(ctx context.Context) error {
+	if m.repo == nil {
+		return nil
+	}
+
+	records, err := m.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list bots: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.restore(records)
+}
+
+// restore adds bots of records which are not registered yet.
+// Caller must hold m.mu.
+func (m *Manager) restore(records []BotRecord) error {
+	var errs []error
+	for _, rec := range records {
+		if _, exists := m.bots[rec.ID]; exists {
+			continue
+		}
+
+		token, err := m.cipher.Decrypt(rec.EncryptedToken)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("decrypt token of bot %s: %w", rec.ID, err))
+			continue
+		}
+
+		cfg := BotConfig{
+			Name:           rec.Name,
+			AllowedUpdates: rec.AllowedUpdates,
+			Storefront:     rec.Storefront,
+			ShopID:         rec.ShopID,
+			Policy:         m.policy,
+		}
+
+		entry := newEntry(string(token), cfg, rec.Identity)
+
+		if !rec.Enabled {
+			m.addPaused(entry)
+			continue
+		}
+
+		m.start(entry)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Reconcile brings bots in line with repository shared with
+// other instances: bots registered there are restored as by Restore,
+// bots resumed there are started and bots deleted there are stopped
+// and dropped. Waits for stopped runners until ctx is done.
+//
+// Running bots paused or changed elsewhere are handled by their
+// lease heartbeat, see WithLeases.
+func (m *Manager) Reconcile(ctx context.Context) error {
+	if m.repo == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+
+	// listed under lock, so bot registered meanwhile
+	// is not taken for deleted one
+	records, err := m.repo.List(ctx)
+	if err != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("list bots: %w", err)
+	}
+
+	saved := make(map[string]BotRecord, len(records))
+	for _, rec := range records {
+		saved[rec.ID] = rec
+	}
+
+	deleted := make(map[string]*botEntry)
+	for id, entry := range m.bots {
+		rec, ok := saved[id]
+		switch {
+		case entry.status == StatusStopping:
+			// local Pause, Remove or Restart completes it
+		case !ok:
+			entry.status = StatusStopping
+			deleted[id] = entry
+		case rec.Enabled && entry.status == StatusPaused:
+			entry.applyRecord(rec)
+			m.start(entry.renew())
+		}
+	}
+
+	restoreErr := m.restore(records)
+	m.mu.Unlock()
+
+	return errors.Join(restoreErr, m.stop(ctx, deleted))
+}
+
+// RunReconciler calls Reconcile every interval until ctx is done.
+// Each call waits for stopped runners at most interval.
+func (m *Manager) RunReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reconcileCtx, cancel := context.WithTimeout(ctx, interval)
+		if err := m.Reconcile(reconcileCtx); err != nil && ctx.Err() == nil {
+			m.logger.Error("failed to reconcile bots", "err", err)
+		}
+		cancel()
+	}
+}
+
+// ReencryptTokens re-encrypts every persisted token with current cipher.
+//
+// Call it after rotation of encryption key, so that records
+// no longer depend on retired keys. Cipher implementing
+// TokenRewrapper re-encrypts only records which need it,
+// without decrypting tokens.
+//
+// Records are re-read and saved under manager lock,
+// so no concurrent change of bot is overwritten.
+func (m *Manager) ReencryptTokens(ctx context.Context) error {
+	if m.repo == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records, err := m.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list bots: %w", err)
+	}
+
+	rewrapper, _ := m.cipher.(TokenRewrapper)
+
+	for _, rec := range records {
+		if rewrapper != nil && !rewrapper.NeedsRewrap(rec.EncryptedToken) {
+			continue
+		}
+
+		encrypted, err := m.reencrypt(rewrapper, rec.EncryptedToken)
+		if err != nil {
+			return fmt.Errorf("re-encrypt token of bot %s: %w", rec.ID, err)
+		}
+
+		rec.EncryptedToken = encrypted
+		if err := m.repo.Save(ctx, rec); err != nil {
+			return fmt.Errorf("save bot %s: %w", rec.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// reencrypt returns ciphertext re-encrypted with current key,
+// rewrapper is nil if cipher does not implement TokenRewrapper.
+func (m *Manager) reencrypt(rewrapper TokenRewrapper, ciphertext string) (string, error) {
+	if rewrapper != nil {
+		return rewrapper.Rewrap(ciphertext)
+	}
+
+	token, err := m.cipher.Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return m.cipher.Encrypt(token)
+}
+
+// start adds entry and spawns its supervised runner.
+// Caller must hold m.mu.
+func (m *Manager) start(entry *botEntry) {
 	ctx, cancel := context.WithCancel(context.Background())
-	done := make(chan struct{})
 
+	entry.cancel = cancel
+	entry.status = StatusStarting
+	m.attachLogs(entry)
+
+	m.bots[entry.bot.ID] = entry
+
+	go m.run(ctx, entry)
+}
+
+// addPaused adds entry without running it. Caller must hold m.mu.
+func (m *Manager) addPaused(entry *botEntry) {
+	entry.cancel = func() {}
+	entry.status = StatusPaused
+	close(entry.done)
+	m.attachLogs(entry)
+
+	m.bots[entry.bot.ID] = entry
+}
+
+// attachLogs gives entry logs and logger,
+// renewed entry keeps logs of bot. Caller must hold m.mu.
+func (m *Manager) attachLogs(entry *botEntry) {
+	if entry.logs == nil {
+		entry.logs = newLogBuffer(m.logCapacity)
+	}
+	entry.logger = m.botLogger(entry)
+}
+
+func newEntry(token string, cfg BotConfig, identity Identity) *botEntry {
 	entry := &botEntry{
 		bot: Bot{
-			Name:  name,
-			Token: token,
+			ID:        BotID(token),
+			TokenHint: RedactToken(token),
+			Identity:  identity,
 		},
-		cancel: cancel,
-		done:   done,
+		token:      token,
+		done:       make(chan struct{}),
+		updates:    make(chan BotConfig, 1),
+		stopStatus: StatusStopped,
 	}
 
-	m.bots[token] = entry
+	cfg = cfg.clone()
+	cfg.Policy = cfg.Policy.normalize()
+	entry.apply(cfg)
 
-	go func() {
-		defer close(done)
-		_ = m.runner.Run(ctx, token)
+	return entry
+}
+
+// persist saves entry to repository. Caller must hold m.mu.
+func (m *Manager) persist(entry *botEntry, enabled bool) error {
+	if m.repo == nil {
+		return nil
+	}
+
+	encrypted, err := m.cipher.Encrypt([]byte(entry.token))
+	if err != nil {
+		return fmt.Errorf("encrypt token: %w", err)
+	}
+
+	rec := BotRecord{
+		ID:             entry.bot.ID,
+		Name:           entry.bot.Name,
+		EncryptedToken: encrypted,
+		Enabled:        enabled,
+		AllowedUpdates: slices.Clone(entry.allowedUpdates),
+		Storefront:     entry.storefront.clone(),
+		ShopID:         entry.bot.ShopID,
+		Identity:       entry.bot.Identity,
+	}
+	if err := m.repo.Save(context.Background(), rec); err != nil {
+		return fmt.Errorf("save bot: %w", err)
+	}
+
+	return nil
+}
+
+// run executes runner for entry, tracks its lifecycle status
+// and restarts it according to entry policy.
+func (m *Manager) run(ctx context.Context, entry *botEntry) {
+	defer close(entry.done)
+	defer m.releaseLease(entry)
+
+	for {
+		if m.leases != nil {
+			if !m.awaitLease(ctx, entry) {
+				m.mu.Lock()
+				entry.status = entry.stopStatus
+				m.publish(BotStopped, entry, nil)
+				m.mu.Unlock()
+				return
+			}
+
+			// lease may be taken over from instance which
+			// paused, removed or changed the bot meanwhile
+			if err := m.syncRecord(ctx, entry); err != nil {
+				m.retire(entry, err)
+				return
+			}
+		}
+
+		m.mu.Lock()
+		if entry.status == StatusStarting {
+			entry.status = StatusRunning
+			entry.startedAt = time.Now()
+			m.publish(BotStarted, entry, nil)
+		}
+		m.mu.Unlock()
+
+		err := m.runLeased(ctx, entry)
+
+		if errors.Is(err, errBotDisabled) || errors.Is(err, errBotDeleted) {
+			m.retire(entry, err)
+			return
+		}
+
+		if errors.Is(err, ErrLeaseLost) {
+			// not a failure of bot, it waits for lease again
+			m.mu.Lock()
+			entry.lastErr = err
+			entry.status = StatusStandby
+			m.publish(BotStopped, entry, err)
+			m.mu.Unlock()
+			continue
+		}
+
+		delay, restart := m.handleExit(ctx, entry, err)
+		if !restart {
+			return
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			m.mu.Lock()
+			entry.status = entry.stopStatus
+			m.publish(BotStopped, entry, nil)
+			m.mu.Unlock()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// runOnce runs runner for entry once,
+// passing config to ConfigurableRunner.
+//
+// Panic of runner is returned as *PanicError,
+// so it cannot take down the process and other bots.
+func (m *Manager) runOnce(ctx context.Context, entry *botEntry) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: string(debug.Stack())}
+		}
 	}()
 
+	ctx = ContextWithLogger(ctx, entry.logger)
+
+	cr, ok := m.runner.(ConfigurableRunner)
+	if !ok {
+		return m.runner.Run(ctx, entry.token)
+	}
+
+	// Change queued for previous run is already in config.
+	select {
+	case <-entry.updates:
+	default:
+	}
+
+	m.mu.RLock()
+	cfg := entry.config()
+	m.mu.RUnlock()
+
+	return cr.RunWithConfig(ctx, entry.token, cfg, entry.updates)
+}
+
+// handleExit records result of runner and decides
+// whether it must be restarted and after which delay.
+func (m *Manager) handleExit(ctx context.Context, entry *botEntry, err error) (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		entry.lastErr = err
+	}
+
+	var panicErr *PanicError
+	switch {
+	case errors.As(err, &panicErr):
+		entry.recordCrash(Crash{Time: time.Now(), Err: err, Stack: panicErr.Stack}, m.crashHistory)
+		entry.logger.Error("bot runner panicked", "err", err)
+	case err != nil && ctx.Err() == nil:
+		entry.recordCrash(Crash{Time: time.Now(), Err: err}, m.crashHistory)
+		entry.logger.Error("bot runner failed", "err", err)
+	}
+
+	if ctx.Err() != nil {
+		entry.status = entry.stopStatus
+		m.publishExit(entry, err)
+		return 0, false
+	}
+
+	if !entry.policy.shouldRestart(err) {
+		if err != nil {
+			entry.status = StatusFailed
+		} else {
+			entry.status = StatusStopped
+		}
+		m.publishExit(entry, err)
+		return 0, false
+	}
+
+	now := time.Now()
+	recent := entry.restartTimes[:0]
+	for _, at := range entry.restartTimes {
+		if now.Sub(at) < entry.policy.Window {
+			recent = append(recent, at)
+		}
+	}
+	entry.restartTimes = recent
+
+	if len(recent) >= entry.policy.MaxRestarts {
+		if err != nil {
+			entry.lastErr = fmt.Errorf("%w: %w", ErrTooManyRestarts, err)
+		} else {
+			entry.lastErr = ErrTooManyRestarts
+		}
+		entry.status = StatusFailed
+		m.publishExit(entry, entry.lastErr)
+		return 0, false
+	}
+
+	delay := entry.policy.backoff(len(recent))
+
+	entry.restartTimes = append(entry.restartTimes, now)
+	entry.restarts++
+	entry.status = StatusStarting
+	entry.logger.Info("bot runner restarting", "restarts", entry.restarts, "delay", delay)
+
+	m.publishExit(entry, err)
+	m.events.publish(Event{
+		Type:     BotRestarted,
+		Bot:      entry.bot,
+		Status:   entry.status,
+		Time:     now,
+		Restarts: entry.restarts,
+		Delay:    delay,
+	})
+
+	return delay, true
+}
+
+// Subscribe returns channel receiving lifecycle events of all bots
+// and function cancelling subscription, which closes the channel.
+//
+// Events are published without waiting for subscribers:
+// an event is dropped for subscriber whose buffer is full.
+// buffer <= 0 means default buffer size.
+func (m *Manager) Subscribe(buffer int) (<-chan Event, func()) {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+
+	ch := m.events.add(buffer)
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() { m.events.remove(ch) })
+	}
+}
+
+// publish sends event about entry to subscribers. Caller must hold m.mu.
+func (m *Manager) publish(t EventType, entry *botEntry, err error) {
+	m.events.publish(Event{
+		Type:   t,
+		Bot:    entry.bot,
+		Status: entry.status,
+		Time:   time.Now(),
+		Err:    err,
+	})
+}
+
+// publishExit publishes BotFailed or BotStopped for runner
+// which returned err. Caller must hold m.mu.
+func (m *Manager) publishExit(entry *botEntry, err error) {
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) || (err != nil && !errors.Is(err, context.Canceled)) {
+		m.publish(BotFailed, entry, err)
+		return
+	}
+	m.publish(BotStopped, entry, nil)
+}
+
+// Remove stops bot and removes it from manager and repository.
+//
+// The bot stays visible with StatusStopping until its runner returns.
+// If runner does not return before ctx is done, Remove returns
+// *StopTimeoutError and the bot is dropped once runner returns.
+func (m *Manager) Remove(ctx context.Context, id string) error {
+	m.mu.Lock()
+
+	entry, ok := m.bots[id]
+	if !ok {
+		m.mu.Unlock()
+		return ErrNotFound
+	}
+
+	if m.repo != nil {
+		err := m.repo.Delete(context.Background(), id)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			m.mu.Unlock()
+			return fmt.Errorf("delete bot: %w", err)
+		}
+	}
+
+	entry.status = StatusStopping
+	m.mu.Unlock()
+
+	return m.stop(ctx, map[string]*botEntry{id: entry})
+}
+
+// Pause stops runner of bot but keeps bot registered
+// in StatusPaused until Resume. Pausing paused bot is no-op.
+//
+// Paused bot is saved disabled, so it stays paused after Restore.
+// If runner does not return before ctx is done, Pause returns
+// *StopTimeoutError and the bot is paused once runner returns.
+func (m *Manager) Pause(ctx context.Context, id string) error {
+	m.mu.Lock()
+
+	entry, ok := m.bots[id]
+	if !ok {
+		m.mu.Unlock()
+		return ErrNotFound
+	}
+
+	switch entry.status {
+	case StatusPaused:
+		m.mu.Unlock()
+		return nil
+	case StatusStopping:
+		m.mu.Unlock()
+		return ErrInvalidState
+	}
+
+	if err := m.persist(entry, false); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+
+	entry.status = StatusStopping
+	entry.stopStatus = StatusPaused
+	m.mu.Unlock()
+
+	entry.cancel()
+
+	return m.await(ctx, id, entry, func() error {
+		// runner may have exited on its own before cancel
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if m.bots[id] == entry {
+			entry.status = StatusPaused
+		}
+		return nil
+	})
+}
+
+// Resume starts runner of paused bot.
+//
+// Returns ErrInvalidState if bot is not paused.
+func (m *Manager) Resume(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.bots[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if entry.status != StatusPaused {
+		return ErrInvalidState
+	}
+
+	if err := m.persist(entry, true); err != nil {
+		return err
+	}
+
+	m.start(entry.renew())
+
 	return nil
 }
 
-func (m *Manager) Remove(token string) error {
+// Restart stops runner of bot and starts it again
+// with cleared restart history. Paused, stopped and failed
+// bots are started as well.
+//
+// If runner does not return before ctx is done, Restart returns
+// *StopTimeoutError and the bot is started once runner returns.
+func (m *Manager) Restart(ctx context.Context, id string) error {
 	m.mu.Lock()
 
-	entry, ok := m.bots[token]
+	entry, ok := m.bots[id]
 	if !ok {
 		m.mu.Unlock()
 		return ErrNotFound
 	}
-	delete(m.bots, token)
+
+	if entry.status == StatusStopping {
+		m.mu.Unlock()
+		return ErrInvalidState
+	}
+
+	if entry.status == StatusPaused {
+		if err := m.persist(entry, true); err != nil {
+			m.mu.Unlock()
+			return err
+		}
+	}
+
+	entry.status = StatusStopping
 	m.mu.Unlock()
 
 	entry.cancel()
-	<-entry.done
+
+	return m.await(ctx, id, entry, func() error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		// removed while stopping
+		if m.bots[id] != entry {
+			return ErrNotFound
+		}
+
+		restarted := entry.renew()
+		m.start(restarted)
+		m.publish(BotRestarted, restarted, nil)
+
+		return nil
+	})
+}
+
+// Update changes config of bot without stopping it.
+//
+// New restart policy applies to the next exit of runner,
+// other changes are sent to ConfigurableRunner while it runs.
+func (m *Manager) Update(id string, cfg BotConfig) error {
+	cfg.Name = strings.TrimSpace(cfg.Name)
+	if cfg.Name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidConfig)
+	}
+
+	cfg = cfg.clone()
+	cfg.Policy = cfg.Policy.normalize()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.bots[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	// bot never moves between shops
+	if cfg.ShopID != entry.bot.ShopID {
+		return fmt.Errorf("%w: shop of bot can not be changed", ErrInvalidConfig)
+	}
+
+	prev := entry.config()
+	entry.apply(cfg)
+
+	enabled := entry.status != StatusPaused && entry.stopStatus != StatusPaused
+	if err := m.persist(entry, enabled); err != nil {
+		entry.apply(prev)
+		return err
+	}
+
+	// Only the latest change matters, updates are sent under m.mu
+	// so the channel never blocks.
+	select {
+	case <-entry.updates:
+	default:
+	}
+	entry.updates <- cfg.clone()
 
 	return nil
 }
 
-func (m *Manager) Bot(token string) (Bot, bool) {
+// Config returns current config of bot.
+func (m *Manager) Config(id string) (BotConfig, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.bots[id]
+	if !ok {
+		return BotConfig{}, false
+	}
+	return entry.config(), true
+}
+
+// Crashes returns latest crashes of bot, oldest first.
+func (m *Manager) Crashes(id string) ([]Crash, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	entry, ok := m.bots[token]
+	entry, ok := m.bots[id]
+	if !ok {
+		return nil, false
+	}
+	return slices.Clone(entry.crashes), true
+}
+
+func (m *Manager) Bot(id string) (Bot, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.bots[id]
 	if !ok {
 		return Bot{}, false
 	}
@@ -88,20 +895,121 @@ func (m *Manager) List() []Bot {
 	return result
 }
 
-func (m *Manager) StopAll() {
-	m.mu.Lock()
-	entries := make([]*botEntry, 0, len(m.bots))
+// Status returns lifecycle snapshot of bot.
+func (m *Manager) Status(id string) (BotState, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.bots[id]
+	if !ok {
+		return BotState{}, false
+	}
+	return entry.state(time.Now()), true
+}
+
+// Statuses returns lifecycle snapshots of all bots.
+func (m *Manager) Statuses() []BotState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	result := make([]BotState, 0, len(m.bots))
 	for _, entry := range m.bots {
-		entries = append(entries, entry)
+		result = append(result, entry.state(now))
+	}
+	return result
+}
+
+// StopAll stops every bot without removing them from repository,
+// so they are started again by Restore.
+//
+// Runners are cancelled at once and awaited until ctx is done,
+// bots which did not stop in time are reported in *StopTimeoutError.
+func (m *Manager) StopAll(ctx context.Context) error {
+	m.mu.Lock()
+	entries := make(map[string]*botEntry, len(m.bots))
+	for id, entry := range m.bots {
+		entry.status = StatusStopping
+		entries[id] = entry
 	}
-	m.bots = make(map[string]*botEntry)
 	m.mu.Unlock()
 
+	return m.stop(ctx, entries)
+}
+
+// stop cancels runners of entries and drops entries from manager
+// as their runners return. Waits for runners until ctx is done.
+func (m *Manager) stop(ctx context.Context, entries map[string]*botEntry) error {
 	for _, entry := range entries {
 		entry.cancel()
 	}
 
-	for _, entry := range entries {
+	var stuck []string
+
+	for id, entry := range entries {
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+		}
+
+		// done is checked again: after deadline select
+		// may pick ctx even for stopped runner
+		select {
+		case <-entry.done:
+			m.drop(id, entry)
+		default:
+			stuck = append(stuck, id)
+
+			go func() {
+				<-entry.done
+				m.drop(id, entry)
+			}()
+		}
+	}
+
+	if len(stuck) > 0 {
+		slices.Sort(stuck)
+		return &StopTimeoutError{BotIDs: stuck, Err: ctx.Err()}
+	}
+
+	return nil
+}
+
+// await calls then once cancelled runner of entry returns.
+//
+// If ctx is done first, await returns *StopTimeoutError
+// and then is called in background once runner returns.
+func (m *Manager) await(ctx context.Context, id string, entry *botEntry, then func() error) error {
+	select {
+	case <-entry.done:
+		return then()
+	case <-ctx.Done():
+	}
+
+	// runner may have returned at deadline as well
+	select {
+	case <-entry.done:
+		return then()
+	default:
+	}
+
+	go func() {
 		<-entry.done
+		if err := then(); err != nil {
+			m.logger.Warn("failed to complete bot stop", "bot_id", id, "err", err)
+		}
+	}()
+
+	return &StopTimeoutError{BotIDs: []string{id}, Err: ctx.Err()}
+}
+
+// drop removes entry unless bot was registered again meanwhile.
+func (m *Manager) drop(id string, entry *botEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.bots[id] == entry {
+		delete(m.bots, id)
+		entry.logs.close()
 	}
 }
@@ -2,10 +2,16 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"botmanager/pkg/envelope"
 )
 
 type fakeRunner struct {
@@ -84,7 +90,7 @@ func TestRemoveBot(t *testing.T) {
 		t.Fatalf("unknown error: %v", err)
 	}
 
-	err = manager.Remove("token123")
+	err = manager.Remove(context.Background(), BotID("token123"))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -98,7 +104,7 @@ func TestRemoveUnknownBot(t *testing.T) {
 	runner := newFakeRunner()
 	manager := NewManager(runner)
 
-	err := manager.Remove("unknown")
+	err := manager.Remove(context.Background(), BotID("unknown"))
 	if err != ErrNotFound {
 		t.Fatalf("expected ErrNotFound, got %v", err)
 	}
@@ -144,7 +150,7 @@ func TestStressRegisterRemove(t *testing.T) {
 			case 0:
 				_ = manager.Register("bot", token)
 			case 1:
-				_ = manager.Remove(token)
+				_ = manager.Remove(context.Background(), BotID(token))
 			case 2:
 				_ = manager.List()
 			}
@@ -160,7 +166,7 @@ func TestBootLookup(t *testing.T) {
 
 	_ = manager.Register("bot1", "token123")
 
-	bot, ok := manager.Bot("token123")
+	bot, ok := manager.Bot(BotID("token123"))
 	if !ok {
 		t.Fatal("expected bot to exist")
 	}
@@ -174,7 +180,7 @@ func TestBootLookupUnknown(t *testing.T) {
 	runner := newFakeRunner()
 	manager := NewManager(runner)
 
-	_, ok := manager.Bot("unknown")
+	_, ok := manager.Bot(BotID("unknown"))
 	if ok {
 		t.Fatal("expected bot not to exists")
 	}
@@ -203,7 +209,7 @@ func TestRemoveStopsBot(t *testing.T) {
 	manager := NewManager(runner)
 
 	_ = manager.Register("bot1", "token123")
-	_ = manager.Remove("token123")
+	_ = manager.Remove(context.Background(), BotID("token123"))
 
 	select {
 	case tok := <-runner.done:
@@ -226,7 +232,7 @@ func TestStopAll(t *testing.T) {
 		)
 	}
 
-	m.StopAll()
+	m.StopAll(context.Background())
 
 	for i := 0; i < 10; i++ {
 		select {
@@ -243,7 +249,7 @@ func TestRemoveCancelContextRunner(t *testing.T) {
 
 	_ = m.Register("bot", "token123")
 
-	if err := m.Remove("token123"); err != nil {
+	if err := m.Remove(context.Background(), BotID("token123")); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -265,7 +271,7 @@ func TestStopAllCancelsAllContextRunners(t *testing.T) {
 		_ = m.Register("bot", fmt.Sprintf("token%d", i))
 	}
 
-	m.StopAll()
+	m.StopAll(context.Background())
 
 	for i := 0; i < 5; i++ {
 		select {
@@ -275,3 +281,1771 @@ func TestStopAllCancelsAllContextRunners(t *testing.T) {
 		}
 	}
 }
+
+type errRunner struct {
+	err error
+}
+
+func (e errRunner) Run(ctx context.Context, token string) error {
+	return e.err
+}
+
+func waitStatus(t *testing.T, m *Manager, token string, want BotStatus) BotState {
+	t.Helper()
+
+	deadline := time.After(time.Second)
+	for {
+		state, ok := m.Status(BotID(token))
+		if ok && state.Status == want {
+			return state
+		}
+
+		select {
+		case <-deadline:
+			t.Fatalf("expected status %s, got %s", want, state.Status)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestStatusRunning(t *testing.T) {
+	r := newFakeRunner()
+	m := NewManager(r)
+
+	_ = m.Register("bot1", "token123")
+	<-r.started
+
+	state := waitStatus(t, m, "token123", StatusRunning)
+	if state.StartedAt.IsZero() {
+		t.Fatal("expected start time to be set")
+	}
+	if state.LastError != nil {
+		t.Fatalf("unexpected last error: %v", state.LastError)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	state, _ = m.Status(BotID("token123"))
+	if state.Uptime <= 0 {
+		t.Fatal("expected positive uptime")
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestStatusFailedKeepsLastError(t *testing.T) {
+	runErr := errors.New("boom")
+	m := NewManager(errRunner{err: runErr})
+
+	_ = m.Register("bot1", "token123")
+
+	state := waitStatus(t, m, "token123", StatusFailed)
+	if !errors.Is(state.LastError, runErr) {
+		t.Fatalf("expected last error %v, got %v", runErr, state.LastError)
+	}
+	if state.Uptime != 0 {
+		t.Fatalf("expected zero uptime for failed bot, got %s", state.Uptime)
+	}
+}
+
+func TestStatusStoppedWhenRunnerReturns(t *testing.T) {
+	m := NewManager(errRunner{})
+
+	_ = m.Register("bot1", "token123")
+
+	waitStatus(t, m, "token123", StatusStopped)
+}
+
+func TestStatusUnknown(t *testing.T) {
+	m := NewManager(newFakeRunner())
+
+	if _, ok := m.Status(BotID("unknown")); ok {
+		t.Fatal("expected status of unknown bot not to exist")
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	r := newFakeRunner()
+	m := NewManager(r)
+
+	for i := 0; i < 3; i++ {
+		_ = m.Register("bot", fmt.Sprintf("token%d", i))
+	}
+
+	if got := len(m.Statuses()); got != 3 {
+		t.Fatalf("expected 3 statuses, got %d", got)
+	}
+
+	m.StopAll(context.Background())
+
+	if got := len(m.Statuses()); got != 0 {
+		t.Fatalf("expected 0 statuses after stop, got %d", got)
+	}
+}
+
+// flakyRunner fails first `failures` runs and then blocks until ctx is done.
+type flakyRunner struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	err      error
+}
+
+func (f *flakyRunner) Run(ctx context.Context, token string) error {
+	f.mu.Lock()
+	f.calls++
+	fail := f.calls <= f.failures
+	f.mu.Unlock()
+
+	if fail {
+		return f.err
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (f *flakyRunner) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func testPolicy(mode RestartMode) RestartPolicy {
+	return RestartPolicy{
+		Mode:           mode,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		MaxRestarts:    3,
+		Window:         time.Minute,
+	}
+}
+
+func TestRestartOnFailureRecovers(t *testing.T) {
+	r := &flakyRunner{failures: 2, err: errors.New("flaky")}
+	m := NewManager(r, WithRestartPolicy(testPolicy(RestartOnFailure)))
+
+	_ = m.Register("bot1", "token123")
+
+	state := waitStatus(t, m, "token123", StatusRunning)
+	for state.Restarts < 2 {
+		state = waitStatus(t, m, "token123", StatusRunning)
+	}
+
+	if r.Calls() != 3 {
+		t.Fatalf("expected 3 runs, got %d", r.Calls())
+	}
+	if state.Restarts != 2 {
+		t.Fatalf("expected 2 restarts, got %d", state.Restarts)
+	}
+
+	if err := m.Remove(context.Background(), BotID("token123")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRestartLimitMovesToFailed(t *testing.T) {
+	runErr := errors.New("always broken")
+	r := &flakyRunner{failures: 100, err: runErr}
+	m := NewManager(r)
+
+	_ = m.RegisterWithPolicy("bot1", "token123", testPolicy(RestartOnFailure))
+
+	state := waitStatus(t, m, "token123", StatusFailed)
+	if !errors.Is(state.LastError, ErrTooManyRestarts) {
+		t.Fatalf("expected ErrTooManyRestarts, got %v", state.LastError)
+	}
+	if !errors.Is(state.LastError, runErr) {
+		t.Fatalf("expected runner error to be wrapped, got %v", state.LastError)
+	}
+	if state.Restarts != 3 {
+		t.Fatalf("expected 3 restarts, got %d", state.Restarts)
+	}
+	if r.Calls() != 4 {
+		t.Fatalf("expected 4 runs, got %d", r.Calls())
+	}
+}
+
+func TestRestartNeverKeepsFailed(t *testing.T) {
+	r := &flakyRunner{failures: 1, err: errors.New("boom")}
+	m := NewManager(r, WithRestartPolicy(testPolicy(RestartNever)))
+
+	_ = m.Register("bot1", "token123")
+
+	state := waitStatus(t, m, "token123", StatusFailed)
+	if state.Restarts != 0 {
+		t.Fatalf("expected no restarts, got %d", state.Restarts)
+	}
+	if r.Calls() != 1 {
+		t.Fatalf("expected 1 run, got %d", r.Calls())
+	}
+}
+
+func TestRestartAlwaysRestartsCleanExit(t *testing.T) {
+	r := &flakyRunner{failures: 1}
+	m := NewManager(r, WithRestartPolicy(testPolicy(RestartAlways)))
+
+	_ = m.Register("bot1", "token123")
+
+	state := waitStatus(t, m, "token123", StatusRunning)
+	for state.Restarts < 1 {
+		state = waitStatus(t, m, "token123", StatusRunning)
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestRemoveDuringBackoff(t *testing.T) {
+	r := &flakyRunner{failures: 100, err: errors.New("boom")}
+	policy := testPolicy(RestartOnFailure)
+	policy.InitialBackoff = time.Hour
+	policy.MaxBackoff = time.Hour
+
+	m := NewManager(r)
+	_ = m.RegisterWithPolicy("bot1", "token123", policy)
+
+	waitStatus(t, m, "token123", StatusStarting)
+	for r.Calls() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_ = m.Remove(context.Background(), BotID("token123"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("remove blocked by restart backoff")
+	}
+}
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	p := RestartPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		Multiplier:     2,
+	}.normalize()
+
+	want := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		50 * time.Millisecond,
+		50 * time.Millisecond,
+	}
+
+	for n, w := range want {
+		if got := p.backoff(n); got != w {
+			t.Fatalf("attempt %d: expected %s, got %s", n, w, got)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		got := p.backoff(0)
+		if got < 5*time.Millisecond || got > 15*time.Millisecond {
+			t.Fatalf("jittered backoff out of range: %s", got)
+		}
+	}
+}
+
+// reverseCipher is a reversible fake cipher for tests.
+type reverseCipher struct{}
+
+func (reverseCipher) Encrypt(plaintext []byte) (string, error) {
+	out := make([]byte, len(plaintext))
+	for i, b := range plaintext {
+		out[len(plaintext)-1-i] = b
+	}
+	return "enc:" + string(out), nil
+}
+
+func (reverseCipher) Decrypt(ciphertext string) ([]byte, error) {
+	body, ok := strings.CutPrefix(ciphertext, "enc:")
+	if !ok {
+		return nil, errors.New("bad ciphertext")
+	}
+	plain, _ := reverseCipher{}.Encrypt([]byte(body))
+	return []byte(strings.TrimPrefix(plain, "enc:")), nil
+}
+
+func testRecord(name, token string, enabled bool) BotRecord {
+	encrypted, _ := reverseCipher{}.Encrypt([]byte(token))
+	return BotRecord{
+		ID:             BotID(token),
+		Name:           name,
+		EncryptedToken: encrypted,
+		Enabled:        enabled,
+	}
+}
+
+type fakeRepository struct {
+	mu      sync.Mutex
+	records map[string]BotRecord
+	saveErr error
+}
+
+func newFakeRepository(records ...BotRecord) *fakeRepository {
+	r := &fakeRepository{records: make(map[string]BotRecord)}
+	for _, rec := range records {
+		r.records[rec.ID] = rec
+	}
+	return r
+}
+
+func (f *fakeRepository) Save(ctx context.Context, rec BotRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	f.records[rec.ID] = rec
+	return nil
+}
+
+func (f *fakeRepository) ByID(ctx context.Context, id string) (BotRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rec, ok := f.records[id]
+	if !ok {
+		return BotRecord{}, ErrNotFound
+	}
+	return rec, nil
+}
+
+func (f *fakeRepository) Delete(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.records[id]; !ok {
+		return ErrNotFound
+	}
+	delete(f.records, id)
+	return nil
+}
+
+func (f *fakeRepository) List(ctx context.Context) ([]BotRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]BotRecord, 0, len(f.records))
+	for _, rec := range f.records {
+		result = append(result, rec)
+	}
+	return result, nil
+}
+
+func (f *fakeRepository) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.records)
+}
+
+func TestRegisterWritesThroughRepository(t *testing.T) {
+	repo := newFakeRepository()
+	m := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}))
+
+	if err := m.Register("bot1", "token123"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if repo.Len() != 1 {
+		t.Fatalf("expected 1 persisted bot, got %d", repo.Len())
+	}
+
+	if err := m.Remove(context.Background(), BotID("token123")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if repo.Len() != 0 {
+		t.Fatalf("expected 0 persisted bots, got %d", repo.Len())
+	}
+}
+
+func TestRegisterRepositoryError(t *testing.T) {
+	repo := newFakeRepository()
+	repo.saveErr = errors.New("db down")
+
+	m := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}))
+
+	err := m.Register("bot1", "token123")
+	if !errors.Is(err, repo.saveErr) {
+		t.Fatalf("expected repository error, got %v", err)
+	}
+
+	if len(m.List()) != 0 {
+		t.Fatal("expected bot not to be started")
+	}
+}
+
+func TestStopAllKeepsRepository(t *testing.T) {
+	repo := newFakeRepository()
+	m := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}))
+
+	_ = m.Register("bot1", "token1")
+	_ = m.Register("bot2", "token2")
+
+	m.StopAll(context.Background())
+
+	if repo.Len() != 2 {
+		t.Fatalf("expected 2 persisted bots, got %d", repo.Len())
+	}
+}
+
+func TestRestore(t *testing.T) {
+	repo := newFakeRepository(
+		testRecord("bot1", "token1", true),
+		testRecord("bot2", "token2", false),
+		testRecord("bot3", "token3", true),
+	)
+	r := newFakeRunner()
+	m := NewManager(r, WithRepository(repo, reverseCipher{}))
+
+	if err := m.Restore(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(m.List()) != 3 {
+		t.Fatalf("expected 3 restored bots, got %d", len(m.List()))
+	}
+
+	<-r.started
+	<-r.started
+
+	if state, _ := m.Status(BotID("token2")); state.Status != StatusPaused {
+		t.Fatalf("expected disabled bot to be paused, got %s", state.Status)
+	}
+
+	// second restore must not duplicate running bots
+	if err := m.Restore(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(m.List()) != 3 {
+		t.Fatalf("expected 3 bots after second restore, got %d", len(m.List()))
+	}
+
+	select {
+	case token := <-r.started:
+		t.Fatalf("unexpected start of %s", token)
+	default:
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestRestoreSkipsUndecryptableTokens(t *testing.T) {
+	broken := testRecord("bot2", "token2", true)
+	broken.EncryptedToken = "plain"
+
+	repo := newFakeRepository(testRecord("bot1", "token1", true), broken)
+	m := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}))
+
+	err := m.Restore(context.Background())
+	if err == nil {
+		t.Fatal("expected decryption error")
+	}
+
+	if len(m.List()) != 1 {
+		t.Fatalf("expected 1 restored bot, got %d", len(m.List()))
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestRepositoryStoresEncryptedToken(t *testing.T) {
+	repo := newFakeRepository()
+	m := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}))
+
+	_ = m.Register("bot1", "123:secret")
+
+	records, _ := repo.List(context.Background())
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	if strings.Contains(records[0].EncryptedToken, "123:secret") {
+		t.Fatal("expected token to be encrypted")
+	}
+
+	if records[0].ID != BotID("123:secret") {
+		t.Fatalf("unexpected record id %s", records[0].ID)
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestReencryptTokens(t *testing.T) {
+	repo := newFakeRepository(testRecord("bot1", "token1", true))
+	m := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}))
+
+	if err := m.ReencryptTokens(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, _ := repo.List(context.Background())
+	token, err := reverseCipher{}.Decrypt(records[0].EncryptedToken)
+	if err != nil || string(token) != "token1" {
+		t.Fatalf("expected token1, got %q, %v", token, err)
+	}
+}
+
+func TestReencryptTokensRewrapsRetiredKey(t *testing.T) {
+	oldKey := []byte(strings.Repeat("o", 32))
+	newKey := []byte(strings.Repeat("n", 32))
+
+	retired, _ := envelope.NewKeyring("old", map[string][]byte{"old": oldKey})
+	rotated, _ := envelope.NewKeyring("new", map[string][]byte{"old": oldKey, "new": newKey})
+
+	encrypted, _ := retired.Encrypt([]byte("token1"))
+	rec := testRecord("bot1", "token1", false)
+	rec.EncryptedToken = encrypted
+
+	repo := newFakeRepository(rec)
+	m := NewManager(newFakeRunner(), WithRepository(repo, rotated))
+
+	if err := m.ReencryptTokens(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, _ := repo.List(context.Background())
+	if rotated.NeedsRewrap(records[0].EncryptedToken) {
+		t.Fatal("expected token wrapped by primary key")
+	}
+	if records[0].Enabled {
+		t.Fatal("expected other fields of record kept")
+	}
+
+	token, err := rotated.Decrypt(records[0].EncryptedToken)
+	if err != nil || string(token) != "token1" {
+		t.Fatalf("expected token1, got %q, %v", token, err)
+	}
+}
+
+func TestListRedactsToken(t *testing.T) {
+	r := newFakeRunner()
+	m := NewManager(r)
+
+	_ = m.Register("bot1", "123456:secret")
+
+	bots := m.List()
+	if len(bots) != 1 {
+		t.Fatalf("expected 1 bot, got %d", len(bots))
+	}
+
+	if bots[0].ID != BotID("123456:secret") {
+		t.Fatalf("unexpected bot id %s", bots[0].ID)
+	}
+
+	if bots[0].TokenHint != "123456:****" {
+		t.Fatalf("unexpected token hint %s", bots[0].TokenHint)
+	}
+
+	// runner still receives raw token
+	if tok := <-r.started; tok != "123456:secret" {
+		t.Fatalf("unexpected runner token %s", tok)
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestBotIDStable(t *testing.T) {
+	if BotID("token1") != BotID("token1") {
+		t.Fatal("expected stable bot id")
+	}
+
+	if BotID("token1") == BotID("token2") {
+		t.Fatal("expected different ids for different tokens")
+	}
+
+	if strings.Contains(BotID("123:secret"), "secret") {
+		t.Fatal("bot id must not contain token")
+	}
+}
+
+func TestRedactToken(t *testing.T) {
+	cases := map[string]string{
+		"123456:secret": "123456:****",
+		"secret":        "****",
+		":secret":       "****",
+		"":              "****",
+	}
+
+	for in, want := range cases {
+		if got := RedactToken(in); got != want {
+			t.Fatalf("RedactToken(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	repo := newFakeRepository()
+	r := newFakeRunner()
+	m := NewManager(r, WithRepository(repo, reverseCipher{}))
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+
+	if err := m.Pause(context.Background(), BotID("token1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-r.done
+
+	waitStatus(t, m, "token1", StatusPaused)
+	if repo.records[BotID("token1")].Enabled {
+		t.Fatal("expected paused bot to be saved disabled")
+	}
+
+	// pausing paused bot is no-op
+	if err := m.Pause(context.Background(), BotID("token1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.Resume(BotID("token1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-r.started
+
+	waitStatus(t, m, "token1", StatusRunning)
+	if !repo.records[BotID("token1")].Enabled {
+		t.Fatal("expected resumed bot to be saved enabled")
+	}
+
+	if err := m.Resume(BotID("token1")); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestPauseFailedBot(t *testing.T) {
+	m := NewManager(errRunner{err: errors.New("boom")})
+
+	_ = m.Register("bot1", "token1")
+	waitStatus(t, m, "token1", StatusFailed)
+
+	if err := m.Pause(context.Background(), BotID("token1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitStatus(t, m, "token1", StatusPaused)
+}
+
+func TestPauseResumeUnknown(t *testing.T) {
+	m := NewManager(newFakeRunner())
+
+	if err := m.Pause(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := m.Resume("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := m.Restart(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRestartBot(t *testing.T) {
+	r := newFakeRunner()
+	m := NewManager(r)
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+
+	if err := m.Restart(context.Background(), BotID("token1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	<-r.done
+	<-r.started
+
+	state := waitStatus(t, m, "token1", StatusRunning)
+	if state.Bot.Name != "bot1" {
+		t.Fatalf("unexpected bot %+v", state.Bot)
+	}
+
+	m.StopAll(context.Background())
+}
+
+// configRunner records configs it runs with and receives.
+type configRunner struct {
+	started chan string
+	configs chan BotConfig
+}
+
+func newConfigRunner() *configRunner {
+	return &configRunner{
+		started: make(chan string, 100),
+		configs: make(chan BotConfig, 100),
+	}
+}
+
+func (c *configRunner) Run(ctx context.Context, token string) error {
+	return c.RunWithConfig(ctx, token, BotConfig{}, nil)
+}
+
+func (c *configRunner) RunWithConfig(ctx context.Context, token string, cfg BotConfig, updates <-chan BotConfig) error {
+	c.started <- token
+	c.configs <- cfg
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case cfg := <-updates:
+			c.configs <- cfg
+		}
+	}
+}
+
+func TestUpdateReconfiguresRunningBot(t *testing.T) {
+	repo := newFakeRepository()
+	r := newConfigRunner()
+	m := NewManager(r, WithRepository(repo, reverseCipher{}))
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+
+	if cfg := <-r.configs; cfg.Name != "bot1" {
+		t.Fatalf("unexpected initial config %+v", cfg)
+	}
+
+	err := m.Update(BotID("token1"), BotConfig{
+		Name:           "renamed",
+		AllowedUpdates: []string{"message"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case cfg := <-r.configs:
+		if cfg.Name != "renamed" || len(cfg.AllowedUpdates) != 1 {
+			t.Fatalf("unexpected config %+v", cfg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("runner did not receive config")
+	}
+
+	select {
+	case <-r.started:
+		t.Fatal("bot must not be restarted")
+	default:
+	}
+
+	bot, _ := m.Bot(BotID("token1"))
+	if bot.Name != "renamed" {
+		t.Fatalf("expected renamed bot, got %+v", bot)
+	}
+
+	rec := repo.records[BotID("token1")]
+	if rec.Name != "renamed" || len(rec.AllowedUpdates) != 1 || !rec.Enabled {
+		t.Fatalf("unexpected persisted record %+v", rec)
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestUpdateKeepsConfigAcrossRestarts(t *testing.T) {
+	r := newConfigRunner()
+	m := NewManager(r)
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+	<-r.configs
+
+	_ = m.Update(BotID("token1"), BotConfig{Name: "bot1", AllowedUpdates: []string{"callback_query"}})
+	<-r.configs
+
+	if err := m.Restart(context.Background(), BotID("token1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-r.started
+
+	cfg := <-r.configs
+	if len(cfg.AllowedUpdates) != 1 || cfg.AllowedUpdates[0] != "callback_query" {
+		t.Fatalf("expected config to survive restart, got %+v", cfg)
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestUpdatePausedBotStaysDisabled(t *testing.T) {
+	repo := newFakeRepository()
+	m := NewManager(newConfigRunner(), WithRepository(repo, reverseCipher{}))
+
+	_ = m.Register("bot1", "token1")
+	_ = m.Pause(context.Background(), BotID("token1"))
+
+	if err := m.Update(BotID("token1"), BotConfig{Name: "renamed"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rec := repo.records[BotID("token1")]; rec.Enabled || rec.Name != "renamed" {
+		t.Fatalf("unexpected persisted record %+v", rec)
+	}
+	waitStatus(t, m, "token1", StatusPaused)
+}
+
+func TestUpdateErrors(t *testing.T) {
+	repo := newFakeRepository()
+	m := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}))
+
+	if err := m.Update("missing", BotConfig{Name: "x"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	_ = m.Register("bot1", "token1")
+
+	if err := m.Update(BotID("token1"), BotConfig{Name: " "}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig, got %v", err)
+	}
+
+	repo.saveErr = errors.New("db down")
+	if err := m.Update(BotID("token1"), BotConfig{Name: "renamed"}); !errors.Is(err, repo.saveErr) {
+		t.Fatalf("expected repository error, got %v", err)
+	}
+
+	if cfg, _ := m.Config(BotID("token1")); cfg.Name != "bot1" {
+		t.Fatalf("failed update must not change config, got %+v", cfg)
+	}
+
+	m.StopAll(context.Background())
+}
+
+// stuckRunner ignores cancellation of tokens in stuck until release is closed.
+type stuckRunner struct {
+	stuck   map[string]bool
+	release chan struct{}
+}
+
+func (s *stuckRunner) Run(ctx context.Context, token string) error {
+	<-ctx.Done()
+	if s.stuck[token] {
+		<-s.release
+	}
+	return nil
+}
+
+func TestStopAllDeadline(t *testing.T) {
+	r := &stuckRunner{stuck: map[string]bool{"token2": true}, release: make(chan struct{})}
+	m := NewManager(r)
+
+	_ = m.Register("bot1", "token1")
+	_ = m.Register("bot2", "token2")
+	waitStatus(t, m, "token2", StatusRunning)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := m.StopAll(ctx)
+
+	var stopErr *StopTimeoutError
+	if !errors.As(err, &stopErr) {
+		t.Fatalf("expected StopTimeoutError, got %v", err)
+	}
+	if len(stopErr.BotIDs) != 1 || stopErr.BotIDs[0] != BotID("token2") {
+		t.Fatalf("unexpected stuck bots %v", stopErr.BotIDs)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+
+	if _, ok := m.Bot(BotID("token1")); ok {
+		t.Fatal("expected stopped bot to be removed")
+	}
+	if state, _ := m.Status(BotID("token2")); state.Status != StatusStopping {
+		t.Fatalf("expected stuck bot to stay stopping, got %s", state.Status)
+	}
+
+	close(r.release)
+
+	deadline := time.After(time.Second)
+	for len(m.List()) != 0 {
+		select {
+		case <-deadline:
+			t.Fatal("stuck bot was not dropped after it stopped")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestStopAllStopsInParallel(t *testing.T) {
+	m := NewManager(slowStopRunner{delay: 50 * time.Millisecond})
+
+	for i := 0; i < 5; i++ {
+		_ = m.Register(fmt.Sprintf("bot%d", i), fmt.Sprintf("token%d", i))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	if err := m.StopAll(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type slowStopRunner struct {
+	delay time.Duration
+}
+
+func (s slowStopRunner) Run(ctx context.Context, token string) error {
+	<-ctx.Done()
+	time.Sleep(s.delay)
+	return nil
+}
+
+func TestRemoveDeadline(t *testing.T) {
+	repo := newFakeRepository()
+	r := &stuckRunner{stuck: map[string]bool{"token1": true}, release: make(chan struct{})}
+	m := NewManager(r, WithRepository(repo, reverseCipher{}))
+
+	_ = m.Register("bot1", "token1")
+	waitStatus(t, m, "token1", StatusRunning)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var stopErr *StopTimeoutError
+	if err := m.Remove(ctx, BotID("token1")); !errors.As(err, &stopErr) {
+		t.Fatalf("expected StopTimeoutError, got %v", err)
+	}
+
+	if repo.Len() != 0 {
+		t.Fatal("expected bot to be deleted from repository")
+	}
+
+	close(r.release)
+}
+
+func TestPauseDeadline(t *testing.T) {
+	r := &stuckRunner{stuck: map[string]bool{"token1": true}, release: make(chan struct{})}
+	m := NewManager(r)
+
+	_ = m.Register("bot1", "token1")
+	waitStatus(t, m, "token1", StatusRunning)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var stopErr *StopTimeoutError
+	if err := m.Pause(ctx, BotID("token1")); !errors.As(err, &stopErr) {
+		t.Fatalf("expected StopTimeoutError, got %v", err)
+	}
+	if state, _ := m.Status(BotID("token1")); state.Status != StatusStopping {
+		t.Fatalf("expected stuck bot to stay stopping, got %s", state.Status)
+	}
+
+	close(r.release)
+	waitStatus(t, m, "token1", StatusPaused)
+}
+
+func TestRestartDeadline(t *testing.T) {
+	r := &stuckRunner{stuck: map[string]bool{"token1": true}, release: make(chan struct{})}
+	m := NewManager(r)
+
+	_ = m.Register("bot1", "token1")
+	waitStatus(t, m, "token1", StatusRunning)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var stopErr *StopTimeoutError
+	if err := m.Restart(ctx, BotID("token1")); !errors.As(err, &stopErr) {
+		t.Fatalf("expected StopTimeoutError, got %v", err)
+	}
+
+	// bot is started once stuck runner returns
+	close(r.release)
+
+	state := waitStatus(t, m, "token1", StatusRunning)
+	if state.Restarts != 0 {
+		t.Fatalf("expected cleared restart history, got %d", state.Restarts)
+	}
+}
+
+type panicRunner struct {
+	calls chan struct{}
+}
+
+func (p panicRunner) Run(ctx context.Context, token string) error {
+	p.calls <- struct{}{}
+	panic("boom")
+}
+
+func TestRunnerPanicIsIsolated(t *testing.T) {
+	panicking := panicRunner{calls: make(chan struct{}, 10)}
+	m := NewManager(panicking)
+
+	_ = m.Register("bot1", "token1")
+
+	state := waitStatus(t, m, "token1", StatusFailed)
+
+	var panicErr *PanicError
+	if !errors.As(state.LastError, &panicErr) || panicErr.Value != "boom" {
+		t.Fatalf("expected panic error, got %v", state.LastError)
+	}
+
+	crashes, ok := m.Crashes(BotID("token1"))
+	if !ok || len(crashes) != 1 {
+		t.Fatalf("expected 1 crash, got %v", crashes)
+	}
+	if !strings.Contains(crashes[0].Stack, "panicRunner.Run") {
+		t.Fatalf("expected stack trace of runner, got %q", crashes[0].Stack)
+	}
+}
+
+func TestRunnerPanicIsRestarted(t *testing.T) {
+	panicking := panicRunner{calls: make(chan struct{}, 10)}
+	m := NewManager(panicking, WithCrashHistory(2))
+
+	_ = m.RegisterWithPolicy("bot1", "token1", RestartPolicy{
+		Mode:           RestartOnFailure,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		MaxRestarts:    3,
+		Window:         time.Minute,
+	})
+
+	state := waitStatus(t, m, "token1", StatusFailed)
+	if !errors.Is(state.LastError, ErrTooManyRestarts) {
+		t.Fatalf("expected restart limit error, got %v", state.LastError)
+	}
+	if len(panicking.calls) != 4 {
+		t.Fatalf("expected 4 runs, got %d", len(panicking.calls))
+	}
+
+	crashes, _ := m.Crashes(BotID("token1"))
+	if len(crashes) != 2 {
+		t.Fatalf("expected crash history to be bounded to 2, got %d", len(crashes))
+	}
+	if crashes[0].Time.After(crashes[1].Time) {
+		t.Fatal("expected crashes ordered oldest first")
+	}
+
+	// crash history survives manual restart
+	_ = m.Restart(context.Background(), BotID("token1"))
+	waitStatus(t, m, "token1", StatusFailed)
+	if crashes, _ := m.Crashes(BotID("token1")); len(crashes) != 2 {
+		t.Fatalf("expected crash history to survive restart, got %d", len(crashes))
+	}
+}
+
+func TestCrashHistoryIgnoresCancellation(t *testing.T) {
+	r := newFakeRunner()
+	m := NewManager(r)
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+	_ = m.StopAll(context.Background())
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+	_ = m.Pause(context.Background(), BotID("token1"))
+
+	if crashes, _ := m.Crashes(BotID("token1")); len(crashes) != 0 {
+		t.Fatalf("expected no crashes, got %v", crashes)
+	}
+	_ = m.StopAll(context.Background())
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("expected event")
+		return Event{}
+	}
+}
+
+func expectEvents(t *testing.T, events <-chan Event, want ...EventType) []Event {
+	t.Helper()
+
+	var got []Event
+	for _, w := range want {
+		e := nextEvent(t, events)
+		if e.Type != w {
+			t.Fatalf("expected %s event, got %s (%+v)", w, e.Type, e)
+		}
+		got = append(got, e)
+	}
+	return got
+}
+
+func TestSubscribeLifecycle(t *testing.T) {
+	r := newFakeRunner()
+	m := NewManager(r)
+
+	events, cancel := m.Subscribe(0)
+	defer cancel()
+
+	_ = m.Register("bot1", "token1")
+	got := expectEvents(t, events, BotRegistered, BotStarted)
+	if got[0].Bot.ID != BotID("token1") || got[1].Status != StatusRunning {
+		t.Fatalf("unexpected events %+v", got)
+	}
+
+	_ = m.Restart(context.Background(), BotID("token1"))
+	expectEvents(t, events, BotStopped, BotRestarted, BotStarted)
+
+	_ = m.Pause(context.Background(), BotID("token1"))
+	if e := expectEvents(t, events, BotStopped)[0]; e.Status != StatusPaused {
+		t.Fatalf("expected paused status, got %s", e.Status)
+	}
+
+	_ = m.Remove(context.Background(), BotID("token1"))
+}
+
+func TestSubscribeFailures(t *testing.T) {
+	m := NewManager(errRunner{err: errors.New("boom")})
+
+	events, cancel := m.Subscribe(0)
+	defer cancel()
+
+	_ = m.RegisterWithPolicy("bot1", "token1", RestartPolicy{
+		Mode:           RestartOnFailure,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		MaxRestarts:    1,
+		Window:         time.Minute,
+	})
+
+	got := expectEvents(t, events,
+		BotRegistered, BotStarted, BotFailed, BotRestarted,
+		BotStarted, BotFailed,
+	)
+
+	if got[2].Status != StatusStarting || got[2].Err == nil {
+		t.Fatalf("expected failure followed by restart, got %+v", got[2])
+	}
+	if got[3].Restarts != 1 || got[3].Delay != time.Millisecond {
+		t.Fatalf("unexpected restart event %+v", got[3])
+	}
+	if got[5].Status != StatusFailed || !errors.Is(got[5].Err, ErrTooManyRestarts) {
+		t.Fatalf("expected final failure, got %+v", got[5])
+	}
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	m := NewManager(newFakeRunner())
+
+	events, cancel := m.Subscribe(1)
+	cancel()
+	cancel()
+
+	if _, ok := <-events; ok {
+		t.Fatal("expected closed channel")
+	}
+
+	// slow subscriber does not block manager
+	slow, cancelSlow := m.Subscribe(1)
+	defer cancelSlow()
+
+	for i := 0; i < 5; i++ {
+		_ = m.Register(fmt.Sprintf("bot%d", i), fmt.Sprintf("token%d", i))
+	}
+	_ = m.StopAll(context.Background())
+
+	if e := <-slow; e.Type != BotRegistered {
+		t.Fatalf("expected first event to be kept, got %s", e.Type)
+	}
+}
+
+type fakeValidator struct {
+	identities map[string]Identity
+	err        error
+	calls      int
+}
+
+func (f *fakeValidator) Validate(ctx context.Context, token string) (Identity, error) {
+	f.calls++
+	if f.err != nil {
+		return Identity{}, f.err
+	}
+	identity, ok := f.identities[token]
+	if !ok {
+		return Identity{}, fmt.Errorf("%w: Unauthorized", ErrInvalidToken)
+	}
+	return identity, nil
+}
+
+func TestRegisterValidatesToken(t *testing.T) {
+	repo := newFakeRepository()
+	v := &fakeValidator{identities: map[string]Identity{
+		"token1": {TelegramID: 1, Username: "shop_bot", SupportsInlineQueries: true},
+	}}
+	m := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}), WithTokenValidator(v))
+
+	if err := m.Register("bot1", "token1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bot, _ := m.Bot(BotID("token1"))
+	if bot.Identity.TelegramID != 1 || !bot.Identity.SupportsInlineQueries {
+		t.Fatalf("expected identity to be stored, got %+v", bot.Identity)
+	}
+	if bot.DisplayName() != "@shop_bot" {
+		t.Fatalf("unexpected display name %q", bot.DisplayName())
+	}
+	if rec := repo.records[BotID("token1")]; rec.Identity != bot.Identity {
+		t.Fatalf("expected identity to be persisted, got %+v", rec.Identity)
+	}
+
+	err := m.Register("bot2", "bad")
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+	if _, ok := m.Bot(BotID("bad")); ok {
+		t.Fatal("invalid bot must not be registered")
+	}
+
+	// duplicate is rejected without validation request
+	calls := v.calls
+	if err := m.Register("bot1", "token1"); !errors.Is(err, ErrDuplicationToken) {
+		t.Fatalf("expected ErrDuplicationToken, got %v", err)
+	}
+	if v.calls != calls {
+		t.Fatal("expected no validation of duplicate token")
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestRegisterValidationUnavailable(t *testing.T) {
+	v := &fakeValidator{err: errors.New("network down")}
+	m := NewManager(newFakeRunner(), WithTokenValidator(v))
+
+	err := m.Register("bot1", "token1")
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+func TestIdentitySurvivesRestore(t *testing.T) {
+	rec := testRecord("bot1", "token1", false)
+	rec.Identity = Identity{TelegramID: 7, Username: "restored_bot"}
+
+	m := NewManager(newFakeRunner(), WithRepository(newFakeRepository(rec), reverseCipher{}))
+	_ = m.Restore(context.Background())
+	_ = m.Resume(BotID("token1"))
+
+	bot, _ := m.Bot(BotID("token1"))
+	if bot.DisplayName() != "@restored_bot" {
+		t.Fatalf("expected restored identity, got %+v", bot)
+	}
+
+	m.StopAll(context.Background())
+}
+
+type fakeLeases struct {
+	mu     sync.Mutex
+	owners map[string]string
+	expiry map[string]time.Time
+	err    error
+}
+
+func newFakeLeases() *fakeLeases {
+	return &fakeLeases{
+		owners: make(map[string]string),
+		expiry: make(map[string]time.Time),
+	}
+}
+
+func (f *fakeLeases) Acquire(ctx context.Context, botID, owner string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return false, f.err
+	}
+
+	if holder, ok := f.owners[botID]; ok && holder != owner && time.Now().Before(f.expiry[botID]) {
+		return false, nil
+	}
+
+	f.owners[botID] = owner
+	f.expiry[botID] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (f *fakeLeases) Release(ctx context.Context, botID, owner string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.owners[botID] == owner {
+		delete(f.owners, botID)
+	}
+	return nil
+}
+
+func (f *fakeLeases) steal(botID, owner string, ttl time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.owners[botID] = owner
+	f.expiry[botID] = time.Now().Add(ttl)
+}
+
+func (f *fakeLeases) fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+const testLeaseTTL = 30 * time.Millisecond
+
+func TestLeasesRunBotOnSingleInstance(t *testing.T) {
+	leases := newFakeLeases()
+	r1, r2 := newFakeRunner(), newFakeRunner()
+	m1 := NewManager(r1, WithLeases(leases, "instance1", testLeaseTTL))
+	m2 := NewManager(r2, WithLeases(leases, "instance2", testLeaseTTL))
+
+	_ = m1.Register("bot1", "token1")
+	<-r1.started
+	_ = m2.Register("bot1", "token1")
+
+	waitStatus(t, m2, "token1", StatusStandby)
+
+	// a few renewals later bot still runs on the first instance only
+	time.Sleep(3 * testLeaseTTL)
+	if state, _ := m1.Status(BotID("token1")); state.Status != StatusRunning {
+		t.Fatalf("expected bot running on owner, got %s", state.Status)
+	}
+	select {
+	case <-r2.started:
+		t.Fatal("bot must not run on standby instance")
+	default:
+	}
+
+	// stopped owner releases lease, so bot fails over
+	if err := m1.StopAll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-r2.started:
+	case <-time.After(time.Second):
+		t.Fatal("expected bot to fail over")
+	}
+	waitStatus(t, m2, "token1", StatusRunning)
+
+	m2.StopAll(context.Background())
+}
+
+func TestLeaseLostStopsRunner(t *testing.T) {
+	leases := newFakeLeases()
+	r := newFakeRunner()
+	m := NewManager(r, WithLeases(leases, "instance1", testLeaseTTL))
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+
+	leases.steal(BotID("token1"), "instance2", 3*testLeaseTTL)
+
+	select {
+	case <-r.done:
+	case <-time.After(time.Second):
+		t.Fatal("expected runner to be stopped")
+	}
+
+	state := waitStatus(t, m, "token1", StatusStandby)
+	if !errors.Is(state.LastError, ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost, got %v", state.LastError)
+	}
+
+	// lease of the other owner expires and bot is taken back
+	select {
+	case <-r.started:
+	case <-time.After(time.Second):
+		t.Fatal("expected bot to be started again")
+	}
+
+	state = waitStatus(t, m, "token1", StatusRunning)
+	if state.Restarts != 0 {
+		t.Fatalf("lease loss must not count as restart, got %d", state.Restarts)
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestLeaseRenewalFailureStopsRunner(t *testing.T) {
+	leases := newFakeLeases()
+	r := newFakeRunner()
+	m := NewManager(r, WithLeases(leases, "instance1", testLeaseTTL))
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+
+	leases.fail(errors.New("db down"))
+
+	select {
+	case <-r.done:
+	case <-time.After(time.Second):
+		t.Fatal("expected runner to be stopped before lease expires")
+	}
+	waitStatus(t, m, "token1", StatusStandby)
+
+	leases.fail(nil)
+
+	select {
+	case <-r.started:
+	case <-time.After(time.Second):
+		t.Fatal("expected bot to be started again")
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestPauseStandbyBot(t *testing.T) {
+	leases := newFakeLeases()
+	leases.steal(BotID("token1"), "instance2", time.Hour)
+
+	m := NewManager(newFakeRunner(), WithLeases(leases, "instance1", testLeaseTTL))
+	_ = m.Register("bot1", "token1")
+	waitStatus(t, m, "token1", StatusStandby)
+
+	if err := m.Pause(context.Background(), BotID("token1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitStatus(t, m, "token1", StatusPaused)
+}
+
+func TestStandbyBotPausedElsewhereStaysPaused(t *testing.T) {
+	repo := newFakeRepository()
+	leases := newFakeLeases()
+	r1, r2 := newFakeRunner(), newFakeRunner()
+	m1 := NewManager(r1, WithRepository(repo, reverseCipher{}), WithLeases(leases, "instance1", testLeaseTTL))
+	m2 := NewManager(r2, WithRepository(repo, reverseCipher{}), WithLeases(leases, "instance2", testLeaseTTL))
+
+	_ = m1.Register("bot1", "token1")
+	<-r1.started
+
+	if err := m2.Restore(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitStatus(t, m2, "token1", StatusStandby)
+
+	// pause releases lease, standby takes it but must not run the bot
+	if err := m1.Pause(context.Background(), BotID("token1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitStatus(t, m2, "token1", StatusPaused)
+
+	select {
+	case <-r2.started:
+		t.Fatal("paused bot must not run on standby instance")
+	default:
+	}
+
+	m2.StopAll(context.Background())
+}
+
+func TestRunningBotRemovedElsewhereIsDropped(t *testing.T) {
+	repo := newFakeRepository()
+	r := newFakeRunner()
+	m := NewManager(r, WithRepository(repo, reverseCipher{}), WithLeases(newFakeLeases(), "instance1", testLeaseTTL))
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+
+	if err := repo.Delete(context.Background(), BotID("token1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-r.done:
+	case <-time.After(time.Second):
+		t.Fatal("expected runner to be stopped")
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		if _, ok := m.Status(BotID("token1")); !ok {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("expected removed bot to be dropped")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestRunningBotDisabledElsewhereIsPaused(t *testing.T) {
+	repo := newFakeRepository()
+	r := newFakeRunner()
+	m := NewManager(r, WithRepository(repo, reverseCipher{}), WithLeases(newFakeLeases(), "instance1", testLeaseTTL))
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+
+	rec, _ := repo.ByID(context.Background(), BotID("token1"))
+	rec.Enabled = false
+	_ = repo.Save(context.Background(), rec)
+
+	select {
+	case <-r.done:
+	case <-time.After(time.Second):
+		t.Fatal("expected runner to be stopped")
+	}
+	waitStatus(t, m, "token1", StatusPaused)
+
+	if err := m.Resume(BotID("token1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-r.started
+
+	m.StopAll(context.Background())
+}
+
+func TestHeartbeatAppliesConfigChangedElsewhere(t *testing.T) {
+	repo := newFakeRepository()
+	r := newConfigRunner()
+	m := NewManager(r, WithRepository(repo, reverseCipher{}), WithLeases(newFakeLeases(), "instance1", testLeaseTTL))
+
+	_ = m.Register("bot1", "token1")
+	<-r.started
+	<-r.configs
+
+	rec, _ := repo.ByID(context.Background(), BotID("token1"))
+	rec.Name = "renamed"
+	_ = repo.Save(context.Background(), rec)
+
+	select {
+	case cfg := <-r.configs:
+		if cfg.Name != "renamed" {
+			t.Fatalf("unexpected config %+v", cfg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("runner did not receive config")
+	}
+
+	if bot, _ := m.Bot(BotID("token1")); bot.Name != "renamed" {
+		t.Fatalf("expected renamed bot, got %+v", bot)
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestReconcile(t *testing.T) {
+	repo := newFakeRepository(
+		testRecord("bot1", "token1", true),
+		testRecord("bot2", "token2", false),
+	)
+	r := newFakeRunner()
+	m := NewManager(r, WithRepository(repo, reverseCipher{}))
+
+	if err := m.Restore(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-r.started
+
+	// changes of another instance
+	_ = repo.Delete(context.Background(), BotID("token1"))
+	rec, _ := repo.ByID(context.Background(), BotID("token2"))
+	rec.Enabled = true
+	rec.Name = "resumed"
+	_ = repo.Save(context.Background(), rec)
+	_ = repo.Save(context.Background(), testRecord("bot3", "token3", true))
+
+	if err := m.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := m.Status(BotID("token1")); ok {
+		t.Fatal("expected deleted bot to be dropped")
+	}
+	if token := <-r.done; token != "token1" {
+		t.Fatalf("expected runner of deleted bot to stop, got %s", token)
+	}
+
+	state := waitStatus(t, m, "token2", StatusRunning)
+	if state.Bot.Name != "resumed" {
+		t.Fatalf("expected config of record, got %+v", state.Bot)
+	}
+	waitStatus(t, m, "token3", StatusRunning)
+
+	if len(m.List()) != 2 {
+		t.Fatalf("expected 2 bots, got %d", len(m.List()))
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestUpdateStorefrontSurvivesRestore(t *testing.T) {
+	repo := newFakeRepository()
+	m := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}))
+
+	_ = m.Register("bot1", "token1")
+
+	sf := Storefront{CityIDs: []int{1, 2}, WelcomeText: "Hi", SupportContact: "@support"}
+	if err := m.Update(BotID("token1"), BotConfig{Name: "bot1", Storefront: sf}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// caller can not change config of bot through its slices
+	sf.CityIDs[0] = 99
+	cfg, _ := m.Config(BotID("token1"))
+	if cfg.Storefront.CityIDs[0] != 1 {
+		t.Fatalf("expected config to be copied, got %+v", cfg.Storefront)
+	}
+	m.StopAll(context.Background())
+
+	restored := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}))
+	_ = restored.Restore(context.Background())
+
+	cfg, _ = restored.Config(BotID("token1"))
+	if cfg.Storefront.WelcomeText != "Hi" || cfg.Storefront.SupportContact != "@support" ||
+		len(cfg.Storefront.CityIDs) != 2 {
+		t.Fatalf("expected storefront to be restored, got %+v", cfg.Storefront)
+	}
+
+	restored.StopAll(context.Background())
+}
+
+func TestRegisterWithConfigShop(t *testing.T) {
+	repo := newFakeRepository()
+	m := NewManager(newFakeRunner(), WithRepository(repo, reverseCipher{}))
+
+	if err := m.RegisterWithConfig("token1", BotConfig{Name: "bot1", ShopID: 3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bot, _ := m.Bot(BotID("token1"))
+	if bot.ShopID != 3 {
+		t.Fatalf("expected bot of shop 3, got %d", bot.ShopID)
+	}
+	if rec := repo.records[BotID("token1")]; rec.ShopID != 3 {
+		t.Fatalf("expected shop to be persisted, got %+v", rec)
+	}
+
+	err := m.Update(BotID("token1"), BotConfig{Name: "bot1", ShopID: 4})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig, got %v", err)
+	}
+
+	if err := m.Update(BotID("token1"), BotConfig{Name: "renamed", ShopID: 3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.StopAll(context.Background())
+}
+
+// logRunner writes logs to bot logger and waits for cancel.
+type logRunner struct {
+	started chan struct{}
+}
+
+func (r *logRunner) Run(ctx context.Context, token string) error {
+	logger := Logger(ctx, nil)
+	logger.Debug("polling tick")
+	logger.Info("bot started", "offset", 10)
+	logger.WithGroup("update").Warn("failed to handle update", "id", 7)
+	r.started <- struct{}{}
+
+	<-ctx.Done()
+	return nil
+}
+
+func TestBotLogs(t *testing.T) {
+	discard := slog.New(slog.NewTextHandler(io.Discard, nil))
+	runner := &logRunner{started: make(chan struct{}, 1)}
+	m := NewManager(runner, WithLogger(discard))
+
+	if err := m.Register("bot1", "token1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-runner.started
+
+	id := BotID("token1")
+
+	logs, ok := m.Logs(id, LogQuery{})
+	if !ok {
+		t.Fatal("expected logs of registered bot")
+	}
+	if len(logs) != 2 {
+		t.Fatalf("expected info and warn records, got %+v", logs)
+	}
+	if logs[0].Message != "bot started" || logs[0].Attrs["bot_id"] != id || logs[0].Attrs["offset"] != "10" {
+		t.Fatalf("unexpected record %+v", logs[0])
+	}
+	if logs[1].Attrs["update.id"] != "7" {
+		t.Fatalf("expected grouped attr, got %+v", logs[1].Attrs)
+	}
+
+	warns, _ := m.Logs(id, LogQuery{MinLevel: slog.LevelWarn})
+	if len(warns) != 1 || warns[0].Level != slog.LevelWarn {
+		t.Fatalf("expected only warn record, got %+v", warns)
+	}
+
+	last, _ := m.Logs(id, LogQuery{Limit: 1})
+	if len(last) != 1 || last[0].Message != "failed to handle update" {
+		t.Fatalf("expected latest record, got %+v", last)
+	}
+
+	if _, ok := m.Logs("unknown", LogQuery{}); ok {
+		t.Fatal("expected no logs of unknown bot")
+	}
+
+	m.StopAll(context.Background())
+}
+
+func TestBotLogsCapacity(t *testing.T) {
+	b := newLogBuffer(3)
+	for i := range 5 {
+		b.add(LogRecord{Message: fmt.Sprint(i)})
+	}
+
+	got := b.records(LogQuery{})
+	if len(got) != 3 || got[0].Message != "2" || got[2].Message != "4" {
+		t.Fatalf("expected 3 latest records, got %+v", got)
+	}
+}
+
+func TestTailLogs(t *testing.T) {
+	discard := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := NewManager(newCtxRunner(), WithLogger(discard))
+	_ = m.Register("bot1", "token1")
+
+	id := BotID("token1")
+	records, cancel, ok := m.TailLogs(id, slog.LevelWarn, 0)
+	if !ok {
+		t.Fatal("expected subscription to registered bot")
+	}
+	defer cancel()
+
+	m.mu.RLock()
+	logger := m.bots[id].logger
+	m.mu.RUnlock()
+
+	logger.Info("skipped")
+	logger.Error("delivered")
+
+	select {
+	case rec := <-records:
+		if rec.Message != "delivered" {
+			t.Fatalf("expected error record, got %+v", rec)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected record")
+	}
+
+	if err := m.Remove(context.Background(), id); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case _, open := <-records:
+		if open {
+			t.Fatal("expected channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected channel to be closed on remove")
+	}
+}
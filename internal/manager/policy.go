@@ -0,0 +1,123 @@
+package manager
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RestartMode defines when supervisor restarts bot runner.
+type RestartMode int
+
+const (
+	// RestartNever leaves bot stopped or failed after runner returns.
+	RestartNever RestartMode = iota
+	// RestartOnFailure restarts runner only when it returns an error.
+	RestartOnFailure
+	// RestartAlways restarts runner whenever it returns.
+	RestartAlways
+)
+
+// Default restart policy values applied to zero fields.
+const (
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+	defaultMultiplier     = 2.0
+	defaultMaxRestarts    = 5
+	defaultRestartWindow  = time.Minute
+)
+
+// RestartPolicy configures supervision of a single bot runner.
+//
+// Backoff between restarts grows exponentially from InitialBackoff
+// by Multiplier and is capped by MaxBackoff. Jitter is a fraction (0..1)
+// of randomization applied to every delay.
+//
+// If runner has been restarted MaxRestarts times within Window,
+// supervisor gives up and bot moves to StatusFailed.
+type RestartPolicy struct {
+	Mode           RestartMode
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+	MaxRestarts    int
+	Window         time.Duration
+}
+
+// DefaultRestartPolicy returns policy restarting failed runners
+// with exponential backoff.
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		Mode:           RestartOnFailure,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		Multiplier:     defaultMultiplier,
+		Jitter:         0.2,
+		MaxRestarts:    defaultMaxRestarts,
+		Window:         defaultRestartWindow,
+	}
+}
+
+// normalize fills zero fields with defaults.
+func (p RestartPolicy) normalize() RestartPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultInitialBackoff
+	}
+
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultMultiplier
+	}
+
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+
+	if p.MaxRestarts <= 0 {
+		p.MaxRestarts = defaultMaxRestarts
+	}
+
+	if p.Window <= 0 {
+		p.Window = defaultRestartWindow
+	}
+
+	return p
+}
+
+// shouldRestart reports whether runner must be restarted
+// after it returned with err.
+func (p RestartPolicy) shouldRestart(err error) bool {
+	switch p.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// backoff returns delay before restart attempt number n (starting from 0).
+func (p RestartPolicy) backoff(n int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 0; i < n && d < float64(p.MaxBackoff); i++ {
+		d *= p.Multiplier
+	}
+
+	if d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		d *= 1 - p.Jitter + 2*p.Jitter*rand.Float64()
+	}
+
+	return time.Duration(d)
+}
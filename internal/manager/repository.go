@@ -0,0 +1,28 @@
+package manager
+
+import "context"
+
+// BotRecord is persisted registration of a bot.
+//
+// Token is stored only in encrypted form produced by TokenCipher.
+type BotRecord struct {
+	ID             string
+	Name           string
+	EncryptedToken string
+	Enabled        bool
+	AllowedUpdates []string
+	Storefront     Storefront
+	ShopID         int
+	Identity       Identity
+}
+
+// BotRepository defines persistence of registered bots.
+//
+// Save must create or update record by ID.
+// ByID and Delete must return ErrNotFound if record does not exist.
+type BotRepository interface {
+	Save(ctx context.Context, rec BotRecord) error
+	ByID(ctx context.Context, id string) (BotRecord, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]BotRecord, error)
+}
@@ -5,3 +5,13 @@ import "context"
 type Runner interface {
 	Run(ctx context.Context, token string) error
 }
+
+// ConfigurableRunner is Runner applying bot configuration.
+//
+// Manager calls RunWithConfig instead of Run with current config of bot.
+// Changes made by Manager.Update while runner works are sent to updates,
+// runner must apply them without restart.
+type ConfigurableRunner interface {
+	Runner
+	RunWithConfig(ctx context.Context, token string, cfg BotConfig, updates <-chan BotConfig) error
+}
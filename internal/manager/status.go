@@ -1,5 +1,7 @@
 package manager
 
+import "time"
+
 type BotStatus int
 
 const (
@@ -8,4 +10,55 @@ const (
 	StatusStopping
 	StatusStopped
 	StatusFailed
+	// StatusPaused means bot was stopped by Pause
+	// and waits for Resume.
+	StatusPaused
+	// StatusStandby means bot waits for its lease,
+	// it is run by another instance meanwhile. See WithLeases.
+	StatusStandby
 )
+
+// String returns human readable status name.
+func (s BotStatus) String() string {
+	switch s {
+	case StatusStarting:
+		return "starting"
+	case StatusRunning:
+		return "running"
+	case StatusStopping:
+		return "stopping"
+	case StatusStopped:
+		return "stopped"
+	case StatusFailed:
+		return "failed"
+	case StatusPaused:
+		return "paused"
+	case StatusStandby:
+		return "standby"
+	default:
+		return "unknown"
+	}
+}
+
+// BotState is a point-in-time snapshot of bot lifecycle.
+//
+// LastError holds the last non-cancellation error returned by Runner.Run.
+// Uptime is measured from StartedAt and is zero unless bot is running.
+// Restarts counts how many times supervisor restarted the runner.
+type BotState struct {
+	Bot       Bot
+	Status    BotStatus
+	LastError error
+	StartedAt time.Time
+	Uptime    time.Duration
+	Restarts  int
+}
+
+// Crash is a failed run of bot runner.
+//
+// Stack is set when runner panicked, see PanicError.
+type Crash struct {
+	Time  time.Time
+	Err   error
+	Stack string
+}
@@ -0,0 +1,28 @@
+package manager
+
+import "slices"
+
+// Storefront scopes catalog shown by bot and customizes its texts,
+// so one deployment can run several regional storefronts.
+//
+// Empty CityIDs or CategoryIDs means all cities or categories.
+type Storefront struct {
+	CityIDs        []int
+	CategoryIDs    []int
+	WelcomeText    string
+	SupportContact string
+}
+
+// equal reports whether s and other scope and customize bot alike.
+func (s Storefront) equal(other Storefront) bool {
+	return slices.Equal(s.CityIDs, other.CityIDs) &&
+		slices.Equal(s.CategoryIDs, other.CategoryIDs) &&
+		s.WelcomeText == other.WelcomeText &&
+		s.SupportContact == other.SupportContact
+}
+
+func (s Storefront) clone() Storefront {
+	s.CityIDs = slices.Clone(s.CityIDs)
+	s.CategoryIDs = slices.Clone(s.CategoryIDs)
+	return s
+}
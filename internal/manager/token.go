@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// idLength is a number of hex chars of token digest used as bot id.
+const idLength = 16
+
+// TokenCipher encrypts bot tokens before they are persisted.
+type TokenCipher interface {
+	Encrypt(plaintext []byte) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+}
+
+// TokenRewrapper is implemented by TokenCipher able to move
+// ciphertext to current key without decrypting the token,
+// see envelope.Keyring.
+type TokenRewrapper interface {
+	NeedsRewrap(ciphertext string) bool
+	Rewrap(ciphertext string) (string, error)
+}
+
+// BotID returns stable internal identifier of bot with token.
+//
+// The identifier is derived from token digest, so it is the same
+// across restarts and does not disclose the token itself.
+func BotID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])[:idLength]
+}
+
+// RedactToken returns token safe to be shown in listings and logs.
+//
+// Telegram tokens look like "<bot id>:<secret>". Public bot id
+// is kept, the secret is replaced by asterisks.
+func RedactToken(token string) string {
+	const mask = "****"
+
+	botID, _, found := strings.Cut(token, ":")
+	if !found || botID == "" {
+		return mask
+	}
+
+	return botID + ":" + mask
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
+)
+
+// CartService orchestrates cart use cases
+// and turns carts into orders.
+type CartService struct {
+	carts    CartRepository
+	products VariantProductReader
+	orders   OrderRepository
+	stocks   StockRepository
+
+	paymentTTL time.Duration
+
+	bus    EventBus
+	tx     TxManager
+	logger *slog.Logger
+}
+
+// NewCartService creates a new CartService instance.
+//
+// logger may be nil, in that case slog.Default() is used.
+func NewCartService(
+	carts CartRepository,
+	products VariantProductReader,
+	orders OrderRepository,
+	stocks StockRepository,
+	bus EventBus,
+	tx TxManager,
+	logger *slog.Logger,
+) *CartService {
+	if carts == nil {
+		panic("service: CartRepository is nil")
+	}
+
+	if products == nil {
+		panic("service: VariantProductReader is nil")
+	}
+
+	if orders == nil {
+		panic("service: OrderRepository is nil")
+	}
+
+	if stocks == nil {
+		panic("service: StockRepository is nil")
+	}
+
+	if bus == nil {
+		panic("service: EventBus is nil")
+	}
+
+	if tx == nil {
+		panic("service: TxManager is nil")
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &CartService{
+		carts:    carts,
+		products: products,
+		orders:   orders,
+		stocks:   stocks,
+		bus:      bus,
+		tx:       tx,
+		logger:   logger,
+	}
+}
+
+// SetPaymentTTL sets time orders created by Checkout wait
+// for payment before they expire. Zero ttl means orders never expire.
+//
+// It must be called before service is used.
+func (s *CartService) SetPaymentTTL(ttl time.Duration) {
+	s.paymentTTL = ttl
+}
+
+// AddItem adds variant to active cart of user,
+// the cart is created on first item.
+//
+// Price of variant at this moment is kept as snapshot,
+// Checkout prices items again.
+func (s *CartService) AddItem(
+	ctx context.Context,
+	userID int,
+	variantID int,
+	quantity int,
+) (*domain.Cart, error) {
+	var result *domain.Cart
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		cart, err := s.carts.ActiveByUser(ctx, userID)
+		if errors.Is(err, domain.ErrCartNotFound) {
+			cart, err = s.newCart(ctx, userID)
+		}
+		if err != nil {
+			s.logger.Error("failed to load cart", "user_id", userID, "err", err)
+			return fmt.Errorf("load cart: %w", err)
+		}
+
+		_, variant, err := s.activeVariant(ctx, variantID)
+		if err != nil {
+			return err
+		}
+
+		if err := cart.AddItem(variantID, quantity, variant.Price()); err != nil {
+			s.logger.Warn(
+				"failed to add cart item",
+				"user_id", userID,
+				"variant_id", variantID,
+				"err", err,
+			)
+			return err
+		}
+
+		if err := s.carts.Save(ctx, cart); err != nil {
+			s.logger.Error("failed to save cart", "user_id", userID, "err", err)
+			return fmt.Errorf("save cart: %w", err)
+		}
+
+		result = cart
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ChangeQuantity sets quantity of variant in cart of user.
+func (s *CartService) ChangeQuantity(
+	ctx context.Context,
+	userID int,
+	cartID int,
+	variantID int,
+	quantity int,
+) error {
+	return s.update(ctx, userID, cartID, func(cart *domain.Cart) error {
+		return cart.ChangeQuantity(variantID, quantity)
+	})
+}
+
+// RemoveItem removes variant from cart of user.
+func (s *CartService) RemoveItem(ctx context.Context, userID int, cartID int, variantID int) error {
+	return s.update(ctx, userID, cartID, func(cart *domain.Cart) error {
+		return cart.RemoveItem(variantID)
+	})
+}
+
+// update applies change to cart of user and saves it.
+func (s *CartService) update(
+	ctx context.Context,
+	userID int,
+	cartID int,
+	change func(*domain.Cart) error,
+) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		cart, err := s.loadCart(ctx, userID, cartID)
+		if err != nil {
+			return err
+		}
+
+		if err := change(cart); err != nil {
+			s.logger.Warn("failed to change cart", "cart_id", cartID, "err", err)
+			return err
+		}
+
+		if err := s.carts.Save(ctx, cart); err != nil {
+			s.logger.Error("failed to save cart", "cart_id", cartID, "err", err)
+			return fmt.Errorf("save cart: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Checkout turns cart of user into pending order and closes the cart.
+//
+// Every item is priced by current price of its variant,
+// price snapshots of cart are not trusted. Items whose variant
+// was archived or removed fail with domain.ErrItemUnavailable.
+// Stock of items is reserved, lack of it fails with
+// domain.ErrInsufficientStock. Order, reservations and closed cart
+// are saved in one transaction.
+func (s *CartService) Checkout(ctx context.Context, userID int, cartID int) (*domain.Order, error) {
+	var created *domain.Order
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		s.logger.Info("checking out cart", "cart_id", cartID)
+
+		cart, err := s.loadCart(ctx, userID, cartID)
+		if err != nil {
+			return err
+		}
+
+		if cart.Status() != domain.CartStatusActive {
+			return domain.ErrCartNotActive
+		}
+
+		cartItems := cart.Items()
+		if len(cartItems) == 0 {
+			return domain.ErrCartEmpty
+		}
+
+		items := make([]domain.OrderItem, 0, len(cartItems))
+		shopIDs := make([]int, 0, len(cartItems))
+
+		for _, item := range cartItems {
+			product, variant, err := s.activeVariant(ctx, item.VariantID())
+			if err != nil {
+				return err
+			}
+
+			if variant.Price() != item.Price() {
+				s.logger.Info(
+					"cart item repriced",
+					"cart_id", cartID,
+					"variant_id", variant.ID(),
+					"old_price", item.Price(),
+					"new_price", variant.Price(),
+				)
+			}
+
+			items = append(items, domain.NewOrderItem(
+				product.ID(),
+				variant.ID(),
+				item.Quantity(),
+				variant.Price(),
+			))
+			shopIDs = append(shopIDs, product.ShopID())
+		}
+
+		order, err := domain.NewOrder(cart.UserID(), items, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if s.paymentTTL > 0 {
+			if err := order.SetPaymentTTL(s.paymentTTL); err != nil {
+				return err
+			}
+		}
+
+		// order belongs to shop selling the products,
+		// products of different shops can not be ordered together
+		for _, shopID := range shopIDs {
+			if shopID == 0 {
+				continue
+			}
+			if err := order.AssignShop(shopID); err != nil {
+				s.logger.Warn("cart mixes shops", "cart_id", cartID, "err", err)
+				return err
+			}
+		}
+
+		if err := reserveStock(ctx, s.stocks, items); err != nil {
+			s.logger.Warn("failed to reserve stock", "cart_id", cartID, "err", err)
+			return err
+		}
+
+		// cart is closed only after order is valid and its stock
+		// is reserved, failed checkout leaves it active
+		if err := cart.Checkout(); err != nil {
+			s.logger.Warn("failed to check out cart", "cart_id", cartID, "err", err)
+			return err
+		}
+
+		if err := s.orders.Save(ctx, order); err != nil {
+			s.logger.Error("failed to create order", "cart_id", cartID, "err", err)
+			return err
+		}
+
+		if err := s.carts.Save(ctx, cart); err != nil {
+			s.logger.Error("failed to save cart", "cart_id", cartID, "err", err)
+			return fmt.Errorf("save cart: %w", err)
+		}
+
+		events := append(cart.PullEvents(), order.PullEvents()...)
+		if len(events) > 0 {
+			if err := s.bus.Publish(ctx, events...); err != nil {
+				s.logger.Error(
+					"failed to publish checkout events",
+					"cart_id", cartID,
+					"err", err,
+				)
+				return fmt.Errorf("publish events: %w", err)
+			}
+		}
+
+		created = order
+
+		s.logger.Info(
+			"cart checked out successfully",
+			"cart_id", cartID,
+			"order_id", order.ID(),
+			"total", order.Total(),
+		)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// newCart creates empty cart of user in shop of ctx.
+func (s *CartService) newCart(ctx context.Context, userID int) (*domain.Cart, error) {
+	cart, err := domain.NewCart(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := assignShop(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	return cart, nil
+}
+
+// loadCart returns cart of user by id.
+func (s *CartService) loadCart(ctx context.Context, userID int, cartID int) (*domain.Cart, error) {
+	cart, err := s.carts.ByID(ctx, cartID)
+	if err != nil {
+		if errors.Is(err, domain.ErrCartNotFound) {
+			return nil, domain.ErrCartNotFound
+		}
+
+		s.logger.Error("failed to load cart", "cart_id", cartID, "err", err)
+		return nil, fmt.Errorf("load cart: %w", err)
+	}
+
+	// cart of another user or shop does not exist for caller
+	if cart.UserID() != userID || !tenant.Allows(ctx, cart.ShopID()) {
+		s.logger.Warn("cart access denied", "cart_id", cartID, "user_id", userID)
+		return nil, domain.ErrCartNotFound
+	}
+
+	return cart, nil
+}
+
+// activeVariant returns variant available for purchase with its product.
+//
+// Archived variants, removed variants and variants of other shops
+// fail with domain.ErrItemUnavailable.
+func (s *CartService) activeVariant(
+	ctx context.Context,
+	variantID int,
+) (*domain.Product, *domain.ProductVariant, error) {
+	product, err := s.products.ByVariantID(ctx, variantID)
+	if err != nil {
+		if errors.Is(err, domain.ErrVariantNotFound) || errors.Is(err, domain.ErrProductNotFound) {
+			return nil, nil, fmt.Errorf("variant %d: %w", variantID, domain.ErrItemUnavailable)
+		}
+
+		s.logger.Error("failed to load product", "variant_id", variantID, "err", err)
+		return nil, nil, fmt.Errorf("load product: %w", err)
+	}
+
+	if !tenant.Allows(ctx, product.ShopID()) {
+		return nil, nil, fmt.Errorf("variant %d: %w", variantID, domain.ErrItemUnavailable)
+	}
+
+	// archived variants are not returned
+	variant, err := product.VariantByID(variantID)
+	if err != nil {
+		s.logger.Warn("variant is not available", "variant_id", variantID, "err", err)
+		return nil, nil, fmt.Errorf("variant %d: %w", variantID, domain.ErrItemUnavailable)
+	}
+
+	return product, variant, nil
+}
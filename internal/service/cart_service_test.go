@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
+)
+
+type stubCartRepository struct {
+	carts map[int]*domain.Cart
+	saved int
+}
+
+func newStubCartRepository(carts ...*domain.Cart) *stubCartRepository {
+	r := &stubCartRepository{carts: make(map[int]*domain.Cart)}
+	for _, c := range carts {
+		_ = r.Save(context.Background(), c)
+	}
+	r.saved = 0
+	return r
+}
+
+func (s *stubCartRepository) Save(ctx context.Context, cart *domain.Cart) error {
+	if cart.ID() == 0 {
+		cart.SetID(len(s.carts) + 1)
+	}
+	s.carts[cart.ID()] = cart
+	s.saved++
+	return nil
+}
+
+func (s *stubCartRepository) ByID(ctx context.Context, id int) (*domain.Cart, error) {
+	cart, ok := s.carts[id]
+	if !ok {
+		return nil, domain.ErrCartNotFound
+	}
+	return cart, nil
+}
+
+func (s *stubCartRepository) ActiveByUser(ctx context.Context, userID int) (*domain.Cart, error) {
+	for _, cart := range s.carts {
+		if cart.UserID() == userID && cart.Status() == domain.CartStatusActive {
+			return cart, nil
+		}
+	}
+	return nil, domain.ErrCartNotFound
+}
+
+type stubVariantProductReader struct {
+	products []*domain.Product
+}
+
+func (s stubVariantProductReader) ByVariantID(ctx context.Context, variantID int) (*domain.Product, error) {
+	for _, p := range s.products {
+		for _, v := range p.VariantsForUpdate() {
+			if v.ID() == variantID {
+				return p, nil
+			}
+		}
+	}
+	return nil, domain.ErrVariantNotFound
+}
+
+// cartProduct returns product with variants 1000 (1500) and 1001 (900).
+func cartProduct() *domain.Product {
+	categoryID := 1
+	return domain.NewProductFromDB(100, &categoryID, "Arabica", "", nil, 1, []domain.ProductVariant{
+		*domain.NewProductVariantFromDB(1000, "250g", 10, 1500, nil),
+		*domain.NewProductVariantFromDB(1001, "100g", 10, 900, nil),
+	})
+}
+
+func TestCartService_Checkout(t *testing.T) {
+	t.Run("creates order repriced by current variants", func(t *testing.T) {
+		product := cartProduct()
+		cart, _ := domain.NewCart(7)
+		_ = cart.AddItem(1000, 2, 1200) // stale price snapshot
+		_ = cart.AddItem(1001, 3, 900)
+
+		carts := newStubCartRepository(cart)
+		orders := &stubProductRepository{}
+		stocks := newStubStockRepository(map[int]int{1000: 10, 1001: 10})
+		svc := NewCartService(
+			carts,
+			stubVariantProductReader{products: []*domain.Product{product}},
+			orders,
+			stocks,
+			stubEventBus{},
+			stubTxManager{},
+			nil,
+		)
+
+		order, err := svc.Checkout(context.Background(), 7, cart.ID())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if orders.saved != order {
+			t.Fatal("expected order to be saved")
+		}
+
+		if want := int64(2*1500 + 3*900); order.Total() != want {
+			t.Fatalf("expected total %d, got %d", want, order.Total())
+		}
+
+		items := order.Items()
+		if len(items) != 2 || items[0].Quantity() != 2 || items[1].Quantity() != 3 ||
+			items[0].ProductID() != 100 {
+			t.Fatalf("unexpected order items %+v", items)
+		}
+
+		if cart.Status() != domain.CartStatusCheckedOut || carts.saved != 1 {
+			t.Fatalf("expected closed cart to be saved, got %s", cart.Status())
+		}
+
+		if stocks.stocks[1000].Reserved() != 2 || stocks.stocks[1001].Reserved() != 3 {
+			t.Fatal("expected stock of items to be reserved")
+		}
+	})
+
+	t.Run("rejects items out of stock", func(t *testing.T) {
+		cart, _ := domain.NewCart(7)
+		_ = cart.AddItem(1000, 1, 1500)
+		_ = cart.AddItem(1001, 4, 900)
+
+		orders := &stubProductRepository{}
+		stocks := newStubStockRepository(map[int]int{1000: 10, 1001: 3})
+		svc := NewCartService(
+			newStubCartRepository(cart),
+			stubVariantProductReader{products: []*domain.Product{cartProduct()}},
+			orders,
+			stocks,
+			stubEventBus{},
+			stubTxManager{},
+			nil,
+		)
+
+		_, err := svc.Checkout(context.Background(), 7, cart.ID())
+		if !errors.Is(err, domain.ErrInsufficientStock) {
+			t.Fatalf("expected ErrInsufficientStock, got %v", err)
+		}
+
+		if orders.saved != nil || cart.Status() != domain.CartStatusActive {
+			t.Fatal("expected no order and active cart")
+		}
+
+		// cart can be checked out once variant is restocked
+		_ = stocks.stocks[1001].Restock(1)
+
+		if _, err := svc.Checkout(context.Background(), 7, cart.ID()); err != nil {
+			t.Fatalf("expected no error after restock, got %v", err)
+		}
+
+		if orders.saved == nil || cart.Status() != domain.CartStatusCheckedOut {
+			t.Fatal("expected order and closed cart")
+		}
+	})
+
+	t.Run("rejects archived variant", func(t *testing.T) {
+		product := cartProduct()
+		_ = product.ArchiveVariant(1001, time.Now())
+
+		cart, _ := domain.NewCart(7)
+		_ = cart.AddItem(1000, 1, 1500)
+		_ = cart.AddItem(1001, 1, 900)
+
+		orders := &stubProductRepository{}
+		svc := NewCartService(
+			newStubCartRepository(cart),
+			stubVariantProductReader{products: []*domain.Product{product}},
+			orders,
+			newStubStockRepository(map[int]int{1000: 10, 1001: 10}),
+			stubEventBus{},
+			stubTxManager{},
+			nil,
+		)
+
+		_, err := svc.Checkout(context.Background(), 7, cart.ID())
+		if !errors.Is(err, domain.ErrItemUnavailable) {
+			t.Fatalf("expected ErrItemUnavailable, got %v", err)
+		}
+
+		if orders.saved != nil || cart.Status() != domain.CartStatusActive {
+			t.Fatal("expected no order and active cart")
+		}
+	})
+
+	t.Run("rejects products of different shops", func(t *testing.T) {
+		other := domain.NewProductFromDB(200, nil, "Robusta", "", nil, 1, []domain.ProductVariant{
+			*domain.NewProductVariantFromDB(2000, "250g", 10, 700, nil),
+		})
+		_ = other.AssignShop(4)
+		product := cartProduct()
+		_ = product.AssignShop(3)
+
+		cart, _ := domain.NewCart(7)
+		_ = cart.AddItem(1000, 1, 1500)
+		_ = cart.AddItem(2000, 1, 700)
+
+		orders := &stubProductRepository{}
+		svc := NewCartService(
+			newStubCartRepository(cart),
+			stubVariantProductReader{products: []*domain.Product{product, other}},
+			orders,
+			newStubStockRepository(map[int]int{1000: 10, 2000: 10}),
+			stubEventBus{},
+			stubTxManager{},
+			nil,
+		)
+
+		_, err := svc.Checkout(context.Background(), 7, cart.ID())
+		if !errors.Is(err, domain.ErrShopAlreadyAssigned) {
+			t.Fatalf("expected ErrShopAlreadyAssigned, got %v", err)
+		}
+
+		if orders.saved != nil || cart.Status() != domain.CartStatusActive {
+			t.Fatal("expected no order and active cart")
+		}
+	})
+
+	t.Run("rejects empty cart", func(t *testing.T) {
+		cart, _ := domain.NewCart(7)
+		svc := NewCartService(
+			newStubCartRepository(cart),
+			stubVariantProductReader{},
+			&stubProductRepository{},
+			newStubStockRepository(map[int]int{1000: 10, 1001: 10}),
+			stubEventBus{},
+			stubTxManager{},
+			nil,
+		)
+
+		if _, err := svc.Checkout(context.Background(), 7, cart.ID()); !errors.Is(err, domain.ErrCartEmpty) {
+			t.Fatalf("expected ErrCartEmpty, got %v", err)
+		}
+	})
+
+	t.Run("unknown cart", func(t *testing.T) {
+		svc := NewCartService(
+			newStubCartRepository(),
+			stubVariantProductReader{},
+			&stubProductRepository{},
+			newStubStockRepository(map[int]int{1000: 10, 1001: 10}),
+			stubEventBus{},
+			stubTxManager{},
+			nil,
+		)
+
+		if _, err := svc.Checkout(context.Background(), 7, 42); !errors.Is(err, domain.ErrCartNotFound) {
+			t.Fatalf("expected ErrCartNotFound, got %v", err)
+		}
+	})
+}
+
+func TestCartService_AddItem(t *testing.T) {
+	carts := newStubCartRepository()
+	svc := NewCartService(
+		carts,
+		stubVariantProductReader{products: []*domain.Product{cartProduct()}},
+		&stubProductRepository{},
+		newStubStockRepository(map[int]int{1000: 10, 1001: 10}),
+		stubEventBus{},
+		stubTxManager{},
+		nil,
+	)
+
+	cart, err := svc.AddItem(context.Background(), 7, 1000, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	again, err := svc.AddItem(context.Background(), 7, 1001, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if again != cart || len(cart.Items()) != 2 || cart.Total() != 2*1500+900 {
+		t.Fatalf("expected both items in one cart, got %+v", cart.Items())
+	}
+
+	if _, err := svc.AddItem(context.Background(), 7, 5555, 1); !errors.Is(err, domain.ErrItemUnavailable) {
+		t.Fatalf("expected ErrItemUnavailable, got %v", err)
+	}
+}
+
+func TestCartService_AddItemAssignsShop(t *testing.T) {
+	product := cartProduct()
+	_ = product.AssignShop(3)
+
+	svc := NewCartService(
+		newStubCartRepository(),
+		stubVariantProductReader{products: []*domain.Product{product}},
+		&stubProductRepository{},
+		newStubStockRepository(map[int]int{1000: 10, 1001: 10}),
+		stubEventBus{},
+		stubTxManager{},
+		nil,
+	)
+
+	cart, err := svc.AddItem(tenant.WithShop(context.Background(), 3), 7, 1000, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cart.ShopID() != 3 {
+		t.Fatalf("expected cart of shop 3, got %d", cart.ShopID())
+	}
+}
+
+func TestCartService_ForeignCart(t *testing.T) {
+	ops := map[string]func(svc *CartService, ctx context.Context, userID, cartID int) error{
+		"change quantity": func(svc *CartService, ctx context.Context, userID, cartID int) error {
+			return svc.ChangeQuantity(ctx, userID, cartID, 1000, 5)
+		},
+		"remove item": func(svc *CartService, ctx context.Context, userID, cartID int) error {
+			return svc.RemoveItem(ctx, userID, cartID, 1000)
+		},
+		"checkout": func(svc *CartService, ctx context.Context, userID, cartID int) error {
+			_, err := svc.Checkout(ctx, userID, cartID)
+			return err
+		},
+	}
+
+	cases := []struct {
+		name   string
+		ctx    context.Context
+		userID int
+		want   error
+	}{
+		{"owner", tenant.WithShop(context.Background(), 1), 7, nil},
+		{"instance", context.Background(), 7, nil},
+		{"another user", tenant.WithShop(context.Background(), 1), 8, domain.ErrCartNotFound},
+		{"another shop", tenant.WithShop(context.Background(), 2), 7, domain.ErrCartNotFound},
+	}
+
+	for opName, op := range ops {
+		for _, tc := range cases {
+			t.Run(opName+" by "+tc.name, func(t *testing.T) {
+				cart, _ := domain.NewCart(7)
+				_ = cart.AssignShop(1)
+				_ = cart.AddItem(1000, 2, 1500)
+
+				product := cartProduct()
+				_ = product.AssignShop(1)
+
+				carts := newStubCartRepository(cart)
+				svc := NewCartService(
+					carts,
+					stubVariantProductReader{products: []*domain.Product{product}},
+					&stubProductRepository{},
+					newStubStockRepository(map[int]int{1000: 10, 1001: 10}),
+					stubEventBus{},
+					stubTxManager{},
+					nil,
+				)
+
+				err := op(svc, tc.ctx, tc.userID, cart.ID())
+				if !errors.Is(err, tc.want) {
+					t.Fatalf("expected %v, got %v", tc.want, err)
+				}
+
+				if tc.want != nil && carts.saved != 0 {
+					t.Fatal("foreign cart must not be saved")
+				}
+			})
+		}
+	}
+}
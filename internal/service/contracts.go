@@ -22,7 +22,14 @@ type ProductReader interface {
 type OrderRepository interface {
 	Save(ctx context.Context, order *domain.Order) error
 	ByID(ctx context.Context, id int) (*domain.Order, error)
-	Cancel(now time.Time) error
+}
+
+// OverdueOrderFinder finds pending orders
+// not paid until their payment deadline.
+type OverdueOrderFinder interface {
+	// OverdueIDs returns at most limit ids greater than afterID
+	// of orders overdue at now, in ascending order.
+	OverdueIDs(ctx context.Context, now time.Time, afterID int, limit int) ([]int, error)
 }
 
 // UserRepository defines persistence operations
@@ -30,4 +37,46 @@ type OrderRepository interface {
 type UserRepository interface {
 	Save(ctx context.Context, u *domain.User) error
 	ByID(ctx context.Context, id int) (*domain.User, error)
+	// ByTelegramID returns domain.ErrUserNotFound if user does not exist.
+	ByTelegramID(ctx context.Context, tgID int64) (*domain.User, error)
+}
+
+// ShopRepository defines persistence operations
+// required by ShopService.
+type ShopRepository interface {
+	// Save creates shop without id or updates existing one.
+	Save(ctx context.Context, shop *domain.Shop) error
+	// ByID returns domain.ErrShopNotFound if shop does not exist.
+	ByID(ctx context.Context, id int) (*domain.Shop, error)
+}
+
+// CartRepository defines persistence operations
+// required by CartService.
+type CartRepository interface {
+	// Save creates cart without id or updates existing one.
+	Save(ctx context.Context, cart *domain.Cart) error
+	// ByID returns domain.ErrCartNotFound if cart does not exist.
+	ByID(ctx context.Context, id int) (*domain.Cart, error)
+	// ActiveByUser returns domain.ErrCartNotFound
+	// if user has no active cart.
+	ActiveByUser(ctx context.Context, userID int) (*domain.Cart, error)
+}
+
+// VariantProductReader defines lookup of product by its variant,
+// required by cart use cases.
+type VariantProductReader interface {
+	// ByVariantID returns product owning variant
+	// or domain.ErrVariantNotFound.
+	ByVariantID(ctx context.Context, variantID int) (*domain.Product, error)
+}
+
+// StockRepository defines persistence operations
+// required by stock reservation of orders.
+//
+// Variant is stocked in a single warehouse of its district.
+type StockRepository interface {
+	// ByVariantID returns stock of variant locked until end
+	// of transaction, or domain.ErrStockNotFound.
+	ByVariantID(ctx context.Context, variantID int) (*domain.Stock, error)
+	Save(ctx context.Context, stock *domain.Stock) error
 }
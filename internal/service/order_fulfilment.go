@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
+)
+
+// StartProcessing starts fulfilment of paid order.
+func (s *OrderService) StartProcessing(ctx context.Context, orderID int) error {
+	return s.changeStatus(ctx, orderID, domain.OrderStatusProcessing, (*domain.Order).StartProcessing)
+}
+
+// MarkReady marks processed order as ready for delivery or pickup.
+func (s *OrderService) MarkReady(ctx context.Context, orderID int) error {
+	return s.changeStatus(ctx, orderID, domain.OrderStatusReady, (*domain.Order).MarkReady)
+}
+
+// MarkDelivered marks ready order as handed over to user.
+func (s *OrderService) MarkDelivered(ctx context.Context, orderID int) error {
+	return s.changeStatus(ctx, orderID, domain.OrderStatusDelivered, (*domain.Order).MarkDelivered)
+}
+
+// Complete closes delivered order.
+func (s *OrderService) Complete(ctx context.Context, orderID int) error {
+	return s.changeStatus(ctx, orderID, domain.OrderStatusCompleted, (*domain.Order).Complete)
+}
+
+// changeStatus applies fulfilment transition to order, saves it
+// and publishes its events in one transaction.
+func (s *OrderService) changeStatus(
+	ctx context.Context,
+	orderID int,
+	status domain.OrderStatus,
+	change func(*domain.Order, time.Time) error,
+) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		s.logger.Info("changing order status", "order_id", orderID, "status", status)
+
+		order, err := s.orders.ByID(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, domain.ErrOrderNotFound) {
+				return domain.ErrOrderNotFound
+			}
+
+			s.logger.Error(
+				"failed to load order",
+				"order_id", orderID,
+				"err", err,
+			)
+			return fmt.Errorf("load order: %w", err)
+		}
+
+		// order of another shop does not exist for caller
+		if !tenant.Allows(ctx, order.ShopID()) {
+			return domain.ErrOrderNotFound
+		}
+
+		if err := change(order, time.Now()); err != nil {
+			s.logger.Warn(
+				"failed to change order status",
+				"order_id", orderID,
+				"status", status,
+				"err", err,
+			)
+			return err
+		}
+
+		if err := s.orders.Save(ctx, order); err != nil {
+			s.logger.Error(
+				"failed to update order",
+				"order_id", orderID,
+				"err", err,
+			)
+			return domain.ErrOrderUpdate
+		}
+
+		events := order.PullEvents()
+		if len(events) > 0 {
+			if err := s.bus.Publish(ctx, events...); err != nil {
+				s.logger.Error(
+					"failed to publish order events",
+					"order_id", orderID,
+					"err", err,
+				)
+				return fmt.Errorf("publish events: %w", err)
+			}
+		}
+
+		s.logger.Info("order status changed", "order_id", orderID, "status", status)
+
+		return nil
+	})
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
+)
+
+type recordingEventBus struct {
+	names []string
+}
+
+func (b *recordingEventBus) Publish(ctx context.Context, events ...domain.Event) error {
+	for _, e := range events {
+		b.names = append(b.names, e.Name())
+	}
+	return nil
+}
+
+// paidOrder returns paid order 7 of shop.
+func paidOrder(shopID int) *domain.Order {
+	items := []domain.OrderItem{domain.NewOrderItem(100, 1000, 1, 1500)}
+	order, _ := domain.NewOrder(1, items, time.Now())
+	order.SetID(7)
+	_ = order.AssignShop(shopID)
+	_ = order.MarkPaid(time.Now())
+	_ = order.PullEvents()
+	return order
+}
+
+func TestOrderService_Fulfilment(t *testing.T) {
+	newService := func(orders *stubOrderStore, bus EventBus) *OrderService {
+		return NewOrderService(
+			stubProductReader{},
+			orders,
+			&stubUserRepository{},
+			newStubStockRepository(nil),
+			bus,
+			stubTxManager{},
+			nil,
+		)
+	}
+
+	t.Run("order goes through fulfilment", func(t *testing.T) {
+		orders := newStubOrderStore(paidOrder(3))
+		bus := &recordingEventBus{}
+		svc := newService(orders, bus)
+		ctx := tenant.WithShop(context.Background(), 3)
+
+		steps := []func(context.Context, int) error{
+			svc.StartProcessing,
+			svc.MarkReady,
+			svc.MarkDelivered,
+			svc.Complete,
+		}
+		for _, step := range steps {
+			if err := step(ctx, 7); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		if status := orders.orders[7].Status(); status != domain.OrderStatusCompleted {
+			t.Fatalf("expected completed order, got %s", status)
+		}
+
+		if len(bus.names) != 4 || bus.names[3] != domain.NameOrderCompleted {
+			t.Fatalf("expected 4 events ending with completion, got %v", bus.names)
+		}
+	})
+
+	t.Run("transition out of order", func(t *testing.T) {
+		orders := newStubOrderStore(paidOrder(3))
+		bus := &recordingEventBus{}
+		svc := newService(orders, bus)
+
+		err := svc.MarkDelivered(context.Background(), 7)
+		if !errors.Is(err, domain.ErrOrderTransition) {
+			t.Fatalf("expected ErrOrderTransition, got %v", err)
+		}
+
+		if len(bus.names) != 0 {
+			t.Fatalf("expected no events, got %v", bus.names)
+		}
+	})
+
+	t.Run("order of another shop is not found", func(t *testing.T) {
+		svc := newService(newStubOrderStore(paidOrder(3)), &recordingEventBus{})
+
+		err := svc.ConfirmRefund(tenant.WithShop(context.Background(), 4), 7)
+		if !errors.Is(err, domain.ErrOrderNotFound) {
+			t.Fatalf("expected ErrOrderNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
+)
+
+// Refund returns amount of paid order to balance of its user.
+//
+// Refunds may be partial, their sum never exceeds order total.
+// Every item is returned to stock once its whole price is refunded
+// (see domain.Order.RefundedItems), once total is refunded
+// order becomes refunded.
+func (s *OrderService) Refund(
+	ctx context.Context,
+	orderID int,
+	amount int64,
+	reason string,
+) error {
+	return s.refund(ctx, orderID, reason, true, func(*domain.Order) int64 {
+		return amount
+	})
+}
+
+// ConfirmRefund marks paid order as refunded after external refund
+// of the rest of its total and returns its items to stock.
+//
+// Use this method when money was returned outside of internal balance
+// workflow, for example via payment gateway.
+func (s *OrderService) ConfirmRefund(ctx context.Context, orderID int) error {
+	return s.refund(ctx, orderID, "", false, func(order *domain.Order) int64 {
+		return order.Total() - order.Refunded()
+	})
+}
+
+// refund refunds amount of order and, if toBalance is set,
+// credits it to user balance.
+func (s *OrderService) refund(
+	ctx context.Context,
+	orderID int,
+	reason string,
+	toBalance bool,
+	amountOf func(*domain.Order) int64,
+) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		s.logger.Info("refunding order", "order_id", orderID)
+
+		order, err := s.orders.ByID(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, domain.ErrOrderNotFound) {
+				return domain.ErrOrderNotFound
+			}
+
+			s.logger.Error(
+				"failed to load order",
+				"order_id", orderID,
+				"err", err,
+			)
+			return fmt.Errorf("load order: %w", err)
+		}
+
+		// order of another shop does not exist for caller
+		if !tenant.Allows(ctx, order.ShopID()) {
+			return domain.ErrOrderNotFound
+		}
+
+		// items refunded before were already returned to stock
+		restocked := len(order.RefundedItems())
+
+		amount := amountOf(order)
+		if err := order.Refund(amount, reason, time.Now()); err != nil {
+			s.logger.Warn(
+				"failed to refund order",
+				"order_id", orderID,
+				"amount", amount,
+				"err", err,
+			)
+			return err
+		}
+
+		var user *domain.User
+		if toBalance {
+			user, err = s.users.ByID(ctx, order.UserID())
+			if err != nil {
+				s.logger.Error(
+					"failed to load user",
+					"user_id", order.UserID(),
+					"order_id", orderID,
+					"err", err,
+				)
+				return fmt.Errorf("load user: %w", err)
+			}
+
+			if !tenant.Allows(ctx, user.ShopID()) {
+				return fmt.Errorf("load user: %w", domain.ErrUserNotFound)
+			}
+
+			if err := user.AddBalance(amount); err != nil {
+				return err
+			}
+		}
+
+		if items := order.RefundedItems()[restocked:]; len(items) > 0 {
+			if err := restockStock(ctx, s.stocks, items); err != nil {
+				s.logger.Error(
+					"failed to return stock",
+					"order_id", orderID,
+					"err", err,
+				)
+				return err
+			}
+		}
+
+		if user != nil {
+			if err := s.users.Save(ctx, user); err != nil {
+				s.logger.Error(
+					"failed to save user",
+					"user_id", user.ID(),
+					"err", err,
+				)
+				return fmt.Errorf("save user: %w", err)
+			}
+		}
+
+		if err := s.orders.Save(ctx, order); err != nil {
+			s.logger.Error(
+				"failed to update order",
+				"order_id", orderID,
+				"err", err,
+			)
+			return domain.ErrOrderUpdate
+		}
+
+		events := order.PullEvents()
+		if len(events) > 0 {
+			if err := s.bus.Publish(ctx, events...); err != nil {
+				s.logger.Error(
+					"failed to publish order events",
+					"order_id", orderID,
+					"err", err,
+				)
+				return fmt.Errorf("publish events: %w", err)
+			}
+		}
+
+		s.logger.Info(
+			"order refunded successfully",
+			"order_id", orderID,
+			"amount", amount,
+			"refunded", order.Refunded(),
+		)
+
+		return nil
+	})
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"botmanager/internal/domain"
+)
+
+func TestOrderService_Refund(t *testing.T) {
+	type fixture struct {
+		orders *stubOrderStore
+		users  *stubUserRepository
+		stocks *stubStockRepository
+		bus    *recordingEventBus
+		svc    *OrderService
+	}
+
+	// order 7 of 1500 is paid, 1 unit of variant 1000 was sold
+	setup := func() fixture {
+		tgID := int64(42)
+		user, _ := domain.NewUser(domain.NewUserParams{TgID: &tgID, ShopID: 3})
+
+		f := fixture{
+			orders: newStubOrderStore(paidOrder(3)),
+			users:  &stubUserRepository{user: user},
+			stocks: newStubStockRepository(map[int]int{1000: 4}),
+			bus:    &recordingEventBus{},
+		}
+		f.svc = NewOrderService(
+			stubProductReader{},
+			f.orders,
+			f.users,
+			f.stocks,
+			f.bus,
+			stubTxManager{},
+			nil,
+		)
+		return f
+	}
+
+	t.Run("partial refunds up to total", func(t *testing.T) {
+		f := setup()
+
+		if err := f.svc.Refund(context.Background(), 7, 500, "damaged"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		order := f.orders.orders[7]
+		if order.Status() != domain.OrderStatusPaid || order.Refunded() != 500 {
+			t.Fatalf("expected paid order with 500 refunded, got %s with %d", order.Status(), order.Refunded())
+		}
+
+		if f.users.saved.Balance() != 500 {
+			t.Fatalf("expected balance 500, got %d", f.users.saved.Balance())
+		}
+
+		if stock := f.stocks.stocks[1000]; stock.Quantity() != 4 {
+			t.Fatalf("expected stock to be kept on partial refund, got %d", stock.Quantity())
+		}
+
+		err := f.svc.Refund(context.Background(), 7, 1001, "damaged")
+		if !errors.Is(err, domain.ErrRefundExceedsTotal) {
+			t.Fatalf("expected ErrRefundExceedsTotal, got %v", err)
+		}
+
+		if err := f.svc.Refund(context.Background(), 7, 1000, "not delivered"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if order.Status() != domain.OrderStatusRefunded {
+			t.Fatalf("expected refunded order, got %s", order.Status())
+		}
+
+		if f.users.saved.Balance() != 1500 {
+			t.Fatalf("expected balance 1500, got %d", f.users.saved.Balance())
+		}
+
+		if stock := f.stocks.stocks[1000]; stock.Quantity() != 5 {
+			t.Fatalf("expected item to be returned to stock, got %d", stock.Quantity())
+		}
+
+		if len(f.bus.names) != 2 || f.bus.names[1] != domain.NameOrderRefunded {
+			t.Fatalf("expected 2 refund events, got %v", f.bus.names)
+		}
+	})
+
+	t.Run("partial refund returns whole items to stock", func(t *testing.T) {
+		f := setup()
+
+		// order 8 of 2500, 1 unit of variant 1000 and 2 units of 1001
+		items := []domain.OrderItem{
+			domain.NewOrderItem(100, 1000, 1, 1500),
+			domain.NewOrderItem(100, 1001, 2, 500),
+		}
+		order, _ := domain.NewOrder(1, items, time.Now())
+		order.SetID(8)
+		_ = order.AssignShop(3)
+		_ = order.MarkPaid(time.Now())
+		f.orders.orders[8] = order
+		f.stocks.stocks[1001] = domain.NewStockFromDB(1001, 1, 1001, 6, 0)
+
+		if err := f.svc.Refund(context.Background(), 8, 1500, "damaged"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if stock := f.stocks.stocks[1000]; stock.Quantity() != 5 {
+			t.Fatalf("expected refunded item to be returned to stock, got %d", stock.Quantity())
+		}
+
+		if stock := f.stocks.stocks[1001]; stock.Quantity() != 6 {
+			t.Fatalf("expected item not refunded to be kept, got %d", stock.Quantity())
+		}
+
+		if err := f.svc.Refund(context.Background(), 8, 999, "damaged"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if stock := f.stocks.stocks[1001]; stock.Quantity() != 6 {
+			t.Fatalf("expected partly refunded item to be kept, got %d", stock.Quantity())
+		}
+
+		if err := f.svc.Refund(context.Background(), 8, 1, "damaged"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if order.Status() != domain.OrderStatusRefunded {
+			t.Fatalf("expected refunded order, got %s", order.Status())
+		}
+
+		// every item is returned to stock once
+		if a, b := f.stocks.stocks[1000].Quantity(), f.stocks.stocks[1001].Quantity(); a != 5 || b != 8 {
+			t.Fatalf("expected stocks 5 and 8, got %d and %d", a, b)
+		}
+	})
+
+	t.Run("refunded order", func(t *testing.T) {
+		f := setup()
+		_ = f.orders.orders[7].MarkRefunded(time.Now())
+
+		err := f.svc.Refund(context.Background(), 7, 100, "damaged")
+		if !errors.Is(err, domain.ErrOrderTransition) {
+			t.Fatalf("expected ErrOrderTransition, got %v", err)
+		}
+
+		if f.users.saved != nil {
+			t.Fatal("expected no balance to be credited")
+		}
+	})
+
+	t.Run("external refund keeps balance", func(t *testing.T) {
+		f := setup()
+
+		if err := f.svc.ConfirmRefund(context.Background(), 7); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if order := f.orders.orders[7]; order.Refunded() != order.Total() {
+			t.Fatalf("expected whole total refunded, got %d", order.Refunded())
+		}
+
+		if f.users.saved != nil {
+			t.Fatal("expected no balance to be credited")
+		}
+
+		if stock := f.stocks.stocks[1000]; stock.Quantity() != 5 {
+			t.Fatalf("expected item to be returned to stock, got %d", stock.Quantity())
+		}
+	})
+}
@@ -12,13 +12,24 @@ import (
 	"time"
 
 	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
 )
 
 // OrderService orchestrates order-related use cases.
+//
+// Stock of ordered items is reserved when order is created,
+// released when it is cancelled and decreased when it is paid,
+// in the same transaction as the order change.
+//
+// Orders created with payment TTL expire when they are not paid
+// in time, see Expire and OrderSweeper.
 type OrderService struct {
 	products ProductReader
 	orders   OrderRepository
 	users    UserRepository
+	stocks   StockRepository
+
+	paymentTTL time.Duration
 
 	bus    EventBus
 	tx     TxManager
@@ -32,6 +43,7 @@ func NewOrderService(
 	products ProductReader,
 	orders OrderRepository,
 	users UserRepository,
+	stocks StockRepository,
 	bus EventBus,
 	tx TxManager,
 	logger *slog.Logger,
@@ -48,6 +60,10 @@ func NewOrderService(
 		panic("service: UserRepository is nil")
 	}
 
+	if stocks == nil {
+		panic("service: StockRepository is nil")
+	}
+
 	if tx == nil {
 		panic("service: TxManager is nil")
 	}
@@ -64,12 +80,21 @@ func NewOrderService(
 		products: products,
 		orders:   orders,
 		users:    users,
+		stocks:   stocks,
 		bus:      bus,
 		tx:       tx,
 		logger:   logger,
 	}
 }
 
+// SetPaymentTTL sets time new orders wait for payment
+// before they expire. Zero ttl means orders never expire.
+//
+// It must be called before service is used.
+func (s *OrderService) SetPaymentTTL(ttl time.Duration) {
+	s.paymentTTL = ttl
+}
+
 // Create creates a new order for a selected product variant.
 func (s *OrderService) CreateForVariant(
 	ctx context.Context,
@@ -98,6 +123,10 @@ func (s *OrderService) CreateForVariant(
 			return fmt.Errorf("load product: %w", err)
 		}
 
+		if !tenant.Allows(ctx, product.ShopID()) {
+			return fmt.Errorf("load product: %w", domain.ErrProductNotFound)
+		}
+
 		variant, err := product.VariantByID(variantID)
 		if err != nil {
 			s.logger.Warn(
@@ -118,6 +147,29 @@ func (s *OrderService) CreateForVariant(
 			return err
 		}
 
+		if s.paymentTTL > 0 {
+			if err := order.SetPaymentTTL(s.paymentTTL); err != nil {
+				return err
+			}
+		}
+
+		// order belongs to shop selling the product
+		if shopID := product.ShopID(); shopID != 0 {
+			if err := order.AssignShop(shopID); err != nil {
+				return err
+			}
+		}
+
+		if err := reserveStock(ctx, s.stocks, items); err != nil {
+			s.logger.Warn(
+				"failed to reserve stock",
+				"user_id", userID,
+				"variant_id", variantID,
+				"err", err,
+			)
+			return err
+		}
+
 		if err := s.orders.Save(ctx, order); err != nil {
 			s.logger.Error(
 				"failed to create order",
@@ -171,6 +223,11 @@ func (s *OrderService) ConfirmPayment(
 			return fmt.Errorf("load order: %w", err)
 		}
 
+		// order of another shop does not exist for caller
+		if !tenant.Allows(ctx, order.ShopID()) {
+			return domain.ErrOrderNotFound
+		}
+
 		if err := order.MarkPaid(time.Now()); err != nil {
 			s.logger.Warn(
 				"failed to mark order as paid",
@@ -180,6 +237,15 @@ func (s *OrderService) ConfirmPayment(
 			return err
 		}
 
+		if err := decreaseStock(ctx, s.stocks, order.Items()); err != nil {
+			s.logger.Error(
+				"failed to decrease stock",
+				"order_id", orderID,
+				"err", err,
+			)
+			return err
+		}
+
 		if err := s.orders.Save(ctx, order); err != nil {
 			s.logger.Error(
 				"filed to update order",
@@ -233,6 +299,11 @@ func (s *OrderService) PayFromBalance(
 			return fmt.Errorf("load order: %w", err)
 		}
 
+		// order of another shop does not exist for caller
+		if !tenant.Allows(ctx, order.ShopID()) {
+			return domain.ErrOrderNotFound
+		}
+
 		user, err := s.users.ByID(ctx, order.UserID())
 		if err != nil {
 			s.logger.Error(
@@ -244,6 +315,10 @@ func (s *OrderService) PayFromBalance(
 			return fmt.Errorf("load user: %w", err)
 		}
 
+		if !tenant.Allows(ctx, user.ShopID()) {
+			return fmt.Errorf("load user: %w", domain.ErrUserNotFound)
+		}
+
 		if err := user.DeductBalance(order.Total()); err != nil {
 			s.logger.Warn(
 				"failed to deduct user balance",
@@ -264,6 +339,15 @@ func (s *OrderService) PayFromBalance(
 			return err
 		}
 
+		if err := decreaseStock(ctx, s.stocks, order.Items()); err != nil {
+			s.logger.Error(
+				"failed to decrease stock",
+				"order_id", orderID,
+				"err", err,
+			)
+			return err
+		}
+
 		if err := s.users.Save(ctx, user); err != nil {
 			s.logger.Error(
 				"failed to save user",
@@ -324,6 +408,11 @@ func (s *OrderService) Cancel(ctx context.Context, orderID int) error {
 			return fmt.Errorf("load order: %w", err)
 		}
 
+		// order of another shop does not exist for caller
+		if !tenant.Allows(ctx, order.ShopID()) {
+			return domain.ErrOrderNotFound
+		}
+
 		if err := order.Cancel(time.Now()); err != nil {
 			s.logger.Warn(
 				"failed to cancel order",
@@ -333,6 +422,15 @@ func (s *OrderService) Cancel(ctx context.Context, orderID int) error {
 			return domain.ErrOrderCancel
 		}
 
+		if err := releaseStock(ctx, s.stocks, order.Items()); err != nil {
+			s.logger.Error(
+				"failed to release stock",
+				"order_id", orderID,
+				"err", err,
+			)
+			return err
+		}
+
 		if err := s.orders.Save(ctx, order); err != nil {
 			s.logger.Error(
 				"failed to update order",
@@ -362,3 +460,68 @@ func (s *OrderService) Cancel(ctx context.Context, orderID int) error {
 		return nil
 	})
 }
+
+// Expire expires pending order not paid until its payment deadline
+// and releases its stock reservations.
+//
+// Fails with domain.ErrOrderNotOverdue if deadline is not reached.
+func (s *OrderService) Expire(ctx context.Context, orderID int) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		order, err := s.orders.ByID(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, domain.ErrOrderNotFound) {
+				return domain.ErrOrderNotFound
+			}
+
+			s.logger.Error(
+				"failed to load order",
+				"order_id", orderID,
+				"err", err,
+			)
+			return fmt.Errorf("load order: %w", err)
+		}
+
+		// order of another shop does not exist for caller
+		if !tenant.Allows(ctx, order.ShopID()) {
+			return domain.ErrOrderNotFound
+		}
+
+		if err := order.Expire(time.Now()); err != nil {
+			return err
+		}
+
+		if err := releaseStock(ctx, s.stocks, order.Items()); err != nil {
+			s.logger.Error(
+				"failed to release stock",
+				"order_id", orderID,
+				"err", err,
+			)
+			return err
+		}
+
+		if err := s.orders.Save(ctx, order); err != nil {
+			s.logger.Error(
+				"failed to update order",
+				"order_id", orderID,
+				"err", err,
+			)
+			return domain.ErrOrderUpdate
+		}
+
+		events := order.PullEvents()
+		if len(events) > 0 {
+			if err := s.bus.Publish(ctx, events...); err != nil {
+				s.logger.Error(
+					"failed to publish order events",
+					"order_id", orderID,
+					"err", err,
+				)
+				return fmt.Errorf("publish events: %w", err)
+			}
+		}
+
+		s.logger.Info("order expired", "order_id", orderID)
+
+		return nil
+	})
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"testing"
 
 	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
 )
 
 type stubProductReader struct {
@@ -42,6 +45,10 @@ func (s *stubUserRepository) ByID(ctx context.Context, id int) (*domain.User, er
 	return s.user, s.byIDErr
 }
 
+func (s *stubUserRepository) ByTelegramID(ctx context.Context, tgID int64) (*domain.User, error) {
+	return s.user, s.byIDErr
+}
+
 func (s *stubUserRepository) Save(ctx context.Context, user *domain.User) error {
 	s.saved = user
 	return s.saveErr
@@ -65,3 +72,161 @@ type stubEventBus struct {
 func (s stubEventBus) Publish(ctx context.Context, events ...domain.Event) error {
 	return s.err
 }
+
+func shopProduct(shopID int) *domain.Product {
+	categoryID := 1
+	p := domain.NewProductFromDB(100, &categoryID, "Arabica", "", nil, 1, []domain.ProductVariant{
+		*domain.NewProductVariantFromDB(1000, "250g", 10, 1500, nil),
+	})
+	_ = p.AssignShop(shopID)
+	return p
+}
+
+func TestOrderService_CreateForVariantTenant(t *testing.T) {
+	t.Run("order belongs to shop of product", func(t *testing.T) {
+		orders := &stubProductRepository{}
+		svc := NewOrderService(
+			stubProductReader{product: shopProduct(3)},
+			orders,
+			&stubUserRepository{},
+			newStubStockRepository(map[int]int{1000: 5}),
+			stubEventBus{},
+			stubTxManager{},
+			nil,
+		)
+
+		order, err := svc.CreateForVariant(tenant.WithShop(context.Background(), 3), 1, 100, 1000)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if order.ShopID() != 3 || orders.saved != order {
+			t.Fatalf("expected saved order of shop 3, got shop %d", order.ShopID())
+		}
+	})
+
+	t.Run("product of another shop is not found", func(t *testing.T) {
+		orders := &stubProductRepository{}
+		svc := NewOrderService(
+			stubProductReader{product: shopProduct(3)},
+			orders,
+			&stubUserRepository{},
+			newStubStockRepository(map[int]int{1000: 5}),
+			stubEventBus{},
+			stubTxManager{},
+			nil,
+		)
+
+		_, err := svc.CreateForVariant(tenant.WithShop(context.Background(), 4), 1, 100, 1000)
+		if !errors.Is(err, domain.ErrProductNotFound) {
+			t.Fatalf("expected ErrProductNotFound, got %v", err)
+		}
+
+		if orders.saved != nil {
+			t.Fatal("expected no order to be saved")
+		}
+	})
+}
+
+type stubStockRepository struct {
+	stocks map[int]*domain.Stock
+}
+
+// newStubStockRepository returns stocks with quantities by variant id.
+func newStubStockRepository(quantities map[int]int) *stubStockRepository {
+	r := &stubStockRepository{stocks: make(map[int]*domain.Stock)}
+	for variantID, quantity := range quantities {
+		r.stocks[variantID] = domain.NewStockFromDB(variantID, 1, variantID, quantity, 0)
+	}
+	return r
+}
+
+func (s *stubStockRepository) ByVariantID(ctx context.Context, variantID int) (*domain.Stock, error) {
+	stock, ok := s.stocks[variantID]
+	if !ok {
+		return nil, domain.ErrStockNotFound
+	}
+	return stock, nil
+}
+
+func (s *stubStockRepository) Save(ctx context.Context, stock *domain.Stock) error {
+	s.stocks[stock.VariantID()] = stock
+	return nil
+}
+
+func TestOrderService_Stock(t *testing.T) {
+	newService := func(stocks *stubStockRepository, orders *stubProductRepository) *OrderService {
+		return NewOrderService(
+			stubProductReader{product: shopProduct(3)},
+			orders,
+			&stubUserRepository{},
+			stocks,
+			stubEventBus{},
+			stubTxManager{},
+			nil,
+		)
+	}
+
+	t.Run("creation reserves and payment decreases stock", func(t *testing.T) {
+		stocks := newStubStockRepository(map[int]int{1000: 5})
+		orders := &stubProductRepository{}
+		svc := newService(stocks, orders)
+
+		order, err := svc.CreateForVariant(context.Background(), 1, 100, 1000)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		stock := stocks.stocks[1000]
+		if stock.Reserved() != 1 || stock.Quantity() != 5 {
+			t.Fatalf("expected 1 reserved of 5, got %d of %d", stock.Reserved(), stock.Quantity())
+		}
+
+		orders.order = order
+		if err := svc.ConfirmPayment(context.Background(), order.ID()); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if stock.Reserved() != 0 || stock.Quantity() != 4 {
+			t.Fatalf("expected 4 left without reservation, got %d of %d", stock.Reserved(), stock.Quantity())
+		}
+	})
+
+	t.Run("cancel releases stock", func(t *testing.T) {
+		stocks := newStubStockRepository(map[int]int{1000: 5})
+		orders := &stubProductRepository{}
+		svc := newService(stocks, orders)
+
+		order, _ := svc.CreateForVariant(context.Background(), 1, 100, 1000)
+		orders.order = order
+
+		if err := svc.Cancel(context.Background(), order.ID()); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if stock := stocks.stocks[1000]; stock.Reserved() != 0 || stock.Available() != 5 {
+			t.Fatalf("expected reservation to be released, got %d reserved", stock.Reserved())
+		}
+	})
+
+	t.Run("insufficient stock", func(t *testing.T) {
+		for name, quantities := range map[string]map[int]int{
+			"sold out": {1000: 0},
+			"no stock": {},
+		} {
+			t.Run(name, func(t *testing.T) {
+				orders := &stubProductRepository{}
+				svc := newService(newStubStockRepository(quantities), orders)
+
+				_, err := svc.CreateForVariant(context.Background(), 1, 100, 1000)
+				if !errors.Is(err, domain.ErrInsufficientStock) {
+					t.Fatalf("expected ErrInsufficientStock, got %v", err)
+				}
+
+				if orders.saved != nil {
+					t.Fatal("expected no order to be saved")
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"botmanager/internal/domain"
+)
+
+const (
+	defaultSweepInterval = time.Minute
+	defaultSweepBatch    = 100
+)
+
+// OrderSweeper periodically expires pending orders
+// not paid until their payment deadline.
+//
+// Every order is expired in its own transaction by OrderService.Expire,
+// which also releases stock reserved for it.
+type OrderSweeper struct {
+	finder   OverdueOrderFinder
+	orders   *OrderService
+	interval time.Duration
+	batch    int
+	logger   *slog.Logger
+}
+
+// NewOrderSweeper creates a new OrderSweeper instance.
+//
+// interval <= 0 and batch <= 0 mean defaults (1 minute, 100 orders).
+// logger may be nil, in that case slog.Default() is used.
+func NewOrderSweeper(
+	finder OverdueOrderFinder,
+	orders *OrderService,
+	interval time.Duration,
+	batch int,
+	logger *slog.Logger,
+) *OrderSweeper {
+	if finder == nil {
+		panic("service: OverdueOrderFinder is nil")
+	}
+
+	if orders == nil {
+		panic("service: OrderService is nil")
+	}
+
+	if interval <= 0 {
+		interval = defaultSweepInterval
+	}
+
+	if batch <= 0 {
+		batch = defaultSweepBatch
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &OrderSweeper{
+		finder:   finder,
+		orders:   orders,
+		interval: interval,
+		batch:    batch,
+		logger:   logger,
+	}
+}
+
+// Run sweeps overdue orders every interval until ctx is done.
+func (s *OrderSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("failed to sweep overdue orders", "err", err)
+			}
+		}
+	}
+}
+
+// Sweep expires orders overdue now in batches
+// and returns number of expired orders.
+//
+// Every overdue order is tried once per sweep. Orders paid or
+// cancelled meanwhile are skipped, orders failed to expire
+// are logged and tried again by the next sweep.
+func (s *OrderSweeper) Sweep(ctx context.Context) (int, error) {
+	var (
+		now     = time.Now()
+		expired int
+		afterID int
+	)
+
+	for {
+		var ids []int
+		// orders are read in transaction, so that they are
+		// not changed by concurrent use cases meanwhile
+		err := s.orders.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			ids, err = s.finder.OverdueIDs(ctx, now, afterID, s.batch)
+			return err
+		})
+		if err != nil {
+			return expired, err
+		}
+
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return expired, err
+			}
+
+			err := s.orders.Expire(ctx, id)
+			switch {
+			case err == nil:
+				expired++
+			case errors.Is(err, domain.ErrOrderNotOverdue),
+				errors.Is(err, domain.ErrOrderTransition),
+				errors.Is(err, domain.ErrOrderNotFound):
+				// order changed after it was found
+			default:
+				s.logger.Error("failed to expire order", "order_id", id, "err", err)
+			}
+		}
+
+		if len(ids) < s.batch {
+			break
+		}
+		afterID = ids[len(ids)-1]
+	}
+
+	if expired > 0 {
+		s.logger.Info("overdue orders expired", "count", expired)
+	}
+
+	return expired, nil
+}
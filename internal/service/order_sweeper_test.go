@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"botmanager/internal/domain"
+)
+
+type stubOrderStore struct {
+	orders map[int]*domain.Order
+	finds  int
+}
+
+func newStubOrderStore(orders ...*domain.Order) *stubOrderStore {
+	s := &stubOrderStore{orders: make(map[int]*domain.Order)}
+	for _, order := range orders {
+		s.orders[order.ID()] = order
+	}
+	return s
+}
+
+func (s *stubOrderStore) ByID(ctx context.Context, id int) (*domain.Order, error) {
+	order, ok := s.orders[id]
+	if !ok {
+		return nil, domain.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (s *stubOrderStore) Save(ctx context.Context, order *domain.Order) error {
+	s.orders[order.ID()] = order
+	return nil
+}
+
+func (s *stubOrderStore) OverdueIDs(ctx context.Context, now time.Time, afterID int, limit int) ([]int, error) {
+	s.finds++
+
+	var ids []int
+	for id, order := range s.orders {
+		if id > afterID && order.IsOverdue(now) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+// overdueOrder returns pending order of variant 1000
+// with payment deadline passed an hour ago.
+func overdueOrder(id int) *domain.Order {
+	return overdueOrderOf(id, 1000)
+}
+
+// overdueOrderOf returns overdue order of variant.
+func overdueOrderOf(id int, variantID int) *domain.Order {
+	items := []domain.OrderItem{domain.NewOrderItem(100, variantID, 1, 1500)}
+	order, _ := domain.NewOrder(1, items, time.Now().Add(-2*time.Hour))
+	_ = order.SetPaymentTTL(time.Hour)
+	order.SetID(id)
+	return order
+}
+
+func newExpiryService(orders *stubOrderStore, stocks *stubStockRepository) *OrderService {
+	return NewOrderService(
+		stubProductReader{product: shopProduct(3)},
+		orders,
+		&stubUserRepository{},
+		stocks,
+		stubEventBus{},
+		stubTxManager{},
+		nil,
+	)
+}
+
+func TestOrderService_Expire(t *testing.T) {
+	t.Run("overdue order expires and releases stock", func(t *testing.T) {
+		stocks := newStubStockRepository(nil)
+		stocks.stocks[1000] = domain.NewStockFromDB(1000, 1, 1000, 5, 1)
+		svc := newExpiryService(newStubOrderStore(overdueOrder(1)), stocks)
+
+		if err := svc.Expire(context.Background(), 1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if stock := stocks.stocks[1000]; stock.Reserved() != 0 || stock.Available() != 5 {
+			t.Fatalf("expected reservation to be released, got %d reserved", stock.Reserved())
+		}
+	})
+
+	t.Run("order in time does not expire", func(t *testing.T) {
+		stocks := newStubStockRepository(map[int]int{1000: 5})
+		svc := newExpiryService(newStubOrderStore(), stocks)
+		svc.SetPaymentTTL(time.Hour)
+
+		order, err := svc.CreateForVariant(context.Background(), 1, 100, 1000)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if order.ExpiresAt() == nil {
+			t.Fatal("expected order to have payment deadline")
+		}
+
+		err = svc.Expire(context.Background(), order.ID())
+		if !errors.Is(err, domain.ErrOrderNotOverdue) {
+			t.Fatalf("expected ErrOrderNotOverdue, got %v", err)
+		}
+
+		if stock := stocks.stocks[1000]; stock.Reserved() != 1 {
+			t.Fatalf("expected reservation to be kept, got %d reserved", stock.Reserved())
+		}
+	})
+}
+
+func TestOrderSweeper_Sweep(t *testing.T) {
+	t.Run("expires overdue orders in batches", func(t *testing.T) {
+		paid := overdueOrder(4)
+		_ = paid.MarkPaid(time.Now())
+
+		orders := newStubOrderStore(overdueOrder(1), overdueOrder(2), overdueOrder(3), paid)
+		stocks := newStubStockRepository(nil)
+		stocks.stocks[1000] = domain.NewStockFromDB(1000, 1, 1000, 5, 3)
+
+		sweeper := NewOrderSweeper(orders, newExpiryService(orders, stocks), time.Minute, 2, nil)
+
+		expired, err := sweeper.Sweep(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if expired != 3 {
+			t.Fatalf("expected 3 expired orders, got %d", expired)
+		}
+
+		if orders.finds != 2 {
+			t.Fatalf("expected 2 batches, got %d", orders.finds)
+		}
+
+		for _, id := range []int{1, 2, 3} {
+			if status := orders.orders[id].Status(); status != domain.OrderStatusExpired {
+				t.Fatalf("expected order %d to be expired, got %s", id, status)
+			}
+		}
+
+		if status := paid.Status(); status != domain.OrderStatusPaid {
+			t.Fatalf("expected paid order to stay paid, got %s", status)
+		}
+
+		if stock := stocks.stocks[1000]; stock.Reserved() != 0 {
+			t.Fatalf("expected reservations to be released, got %d reserved", stock.Reserved())
+		}
+	})
+
+	t.Run("pages past orders failing to expire", func(t *testing.T) {
+		// no stock of variant 2000 to release, its orders fail
+		orders := newStubOrderStore(
+			overdueOrderOf(1, 2000),
+			overdueOrderOf(2, 2000),
+			overdueOrder(3),
+		)
+		stocks := newStubStockRepository(nil)
+		stocks.stocks[1000] = domain.NewStockFromDB(1000, 1, 1000, 5, 1)
+
+		sweeper := NewOrderSweeper(orders, newExpiryService(orders, stocks), time.Minute, 2, nil)
+
+		expired, err := sweeper.Sweep(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if expired != 1 || orders.orders[3].Status() != domain.OrderStatusExpired {
+			t.Fatalf("expected order 3 to be expired, got %d expired", expired)
+		}
+
+		if orders.finds != 2 {
+			t.Fatalf("expected 2 batches, got %d", orders.finds)
+		}
+	})
+}
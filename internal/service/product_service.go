@@ -5,6 +5,7 @@ import (
 	"log/slog"
 
 	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
 )
 
 type ProductService struct {
@@ -62,6 +63,10 @@ func (s *ProductService) Create(
 			return err
 		}
 
+		if err := assignShop(ctx, p); err != nil {
+			return err
+		}
+
 		if err := s.repo.Save(ctx, p); err != nil {
 			s.logger.Error("failed to save product", "err", err)
 			return err
@@ -96,6 +101,10 @@ func (s *ProductService) AddVariant(
 			return err
 		}
 
+		if !tenant.Allows(ctx, product.ShopID()) {
+			return domain.ErrProductNotFound
+		}
+
 		if err := product.AddVariant(packSize, districtID, price); err != nil {
 			s.logger.Warn(
 				"failed to add variant",
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
+)
+
+// ShopService orchestrates shop (tenant) use cases.
+//
+// Caller bound to a shop, see tenant.WithShop,
+// sees and manages only that shop.
+type ShopService struct {
+	repo   ShopRepository
+	tx     TxManager
+	logger *slog.Logger
+}
+
+// NewShopService creates a new ShopService instance.
+//
+// logger may be nil, in that case slog.Default() is used.
+func NewShopService(repo ShopRepository, tx TxManager, logger *slog.Logger) *ShopService {
+	if repo == nil {
+		panic("service: ShopRepository is nil")
+	}
+
+	if tx == nil {
+		panic("service: TxManager is nil")
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &ShopService{
+		repo:   repo,
+		tx:     tx,
+		logger: logger,
+	}
+}
+
+// Create creates a new enabled shop.
+func (s *ShopService) Create(ctx context.Context, name string) (*domain.Shop, error) {
+	var shop *domain.Shop
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		sh, err := domain.NewShop(name)
+		if err != nil {
+			s.logger.Warn("failed to create shop", "err", err)
+			return err
+		}
+
+		if err := s.repo.Save(ctx, sh); err != nil {
+			s.logger.Error("failed to save shop", "err", err)
+			return err
+		}
+
+		s.logger.Info("shop created", "shop_id", sh.ID())
+
+		shop = sh
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shop, nil
+}
+
+// ByID returns shop visible to caller.
+func (s *ShopService) ByID(ctx context.Context, id int) (*domain.Shop, error) {
+	if !tenant.Allows(ctx, id) {
+		return nil, domain.ErrShopNotFound
+	}
+
+	return s.repo.ByID(ctx, id)
+}
+
+// SetEnabled enables or disables shop.
+func (s *ShopService) SetEnabled(ctx context.Context, id int, enabled bool) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		shop, err := s.ByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if enabled {
+			shop.Enable()
+		} else {
+			shop.Disable()
+		}
+
+		if err := s.repo.Save(ctx, shop); err != nil {
+			s.logger.Error("failed to save shop", "shop_id", id, "err", err)
+			return err
+		}
+
+		s.logger.Info("shop state changed", "shop_id", id, "enabled", enabled)
+		return nil
+	})
+}
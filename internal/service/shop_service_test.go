@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
+)
+
+type stubShopRepository struct {
+	shops map[int]*domain.Shop
+}
+
+func (s *stubShopRepository) Save(ctx context.Context, shop *domain.Shop) error {
+	if shop.ID() == 0 {
+		shop.SetID(len(s.shops) + 1)
+	}
+	s.shops[shop.ID()] = shop
+	return nil
+}
+
+func (s *stubShopRepository) ByID(ctx context.Context, id int) (*domain.Shop, error) {
+	shop, ok := s.shops[id]
+	if !ok {
+		return nil, domain.ErrShopNotFound
+	}
+	return shop, nil
+}
+
+func TestShopService(t *testing.T) {
+	repo := &stubShopRepository{shops: make(map[int]*domain.Shop)}
+	svc := NewShopService(repo, stubTxManager{}, nil)
+
+	shop, err := svc.Create(context.Background(), "Coffee House")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	other, _ := svc.Create(context.Background(), "Tea House")
+
+	ctx := tenant.WithShop(context.Background(), shop.ID())
+
+	if _, err := svc.ByID(ctx, shop.ID()); err != nil {
+		t.Fatalf("expected own shop, got %v", err)
+	}
+
+	if _, err := svc.ByID(ctx, other.ID()); !errors.Is(err, domain.ErrShopNotFound) {
+		t.Fatalf("expected ErrShopNotFound for other shop, got %v", err)
+	}
+
+	if err := svc.SetEnabled(ctx, other.ID(), false); !errors.Is(err, domain.ErrShopNotFound) {
+		t.Fatalf("expected other shop to be untouched, got %v", err)
+	}
+
+	if err := svc.SetEnabled(ctx, shop.ID(), false); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if shop.IsEnabled() {
+		t.Fatal("expected shop to be disabled")
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"botmanager/internal/domain"
+)
+
+// reserveStock reserves stock for items of new order.
+//
+// Variant without stock fails with domain.ErrInsufficientStock.
+func reserveStock(ctx context.Context, stocks StockRepository, items []domain.OrderItem) error {
+	err := changeStock(ctx, stocks, items, (*domain.Stock).Reserve)
+	if errors.Is(err, domain.ErrStockNotFound) {
+		return fmt.Errorf("%w: %w", domain.ErrInsufficientStock, err)
+	}
+	return err
+}
+
+// releaseStock returns stock reserved for items of cancelled order.
+func releaseStock(ctx context.Context, stocks StockRepository, items []domain.OrderItem) error {
+	return changeStock(ctx, stocks, items, (*domain.Stock).Release)
+}
+
+// decreaseStock removes stock reserved for items of paid order.
+func decreaseStock(ctx context.Context, stocks StockRepository, items []domain.OrderItem) error {
+	return changeStock(ctx, stocks, items, (*domain.Stock).Decrease)
+}
+
+// restockStock returns sold stock of items of refunded order.
+func restockStock(ctx context.Context, stocks StockRepository, items []domain.OrderItem) error {
+	return changeStock(ctx, stocks, items, (*domain.Stock).Restock)
+}
+
+// changeStock applies op to stock of every variant of items
+// with total quantity of variant and saves changed stocks.
+//
+// Stocks are loaded in order of variant ids, so that
+// concurrent orders of the same variants do not deadlock.
+// Nothing is saved unless op succeeds for every stock.
+func changeStock(
+	ctx context.Context,
+	stocks StockRepository,
+	items []domain.OrderItem,
+	op func(s *domain.Stock, n int) error,
+) error {
+	quantities := make(map[int]int, len(items))
+	for _, item := range items {
+		quantities[item.VariantID()] += item.Quantity()
+	}
+
+	changed := make([]*domain.Stock, 0, len(quantities))
+	for _, variantID := range slices.Sorted(maps.Keys(quantities)) {
+		stock, err := stocks.ByVariantID(ctx, variantID)
+		if err != nil {
+			return fmt.Errorf("load stock of variant %d: %w", variantID, err)
+		}
+
+		if err := op(stock, quantities[variantID]); err != nil {
+			return fmt.Errorf("variant %d: %w", variantID, err)
+		}
+
+		changed = append(changed, stock)
+	}
+
+	for _, stock := range changed {
+		if err := stocks.Save(ctx, stock); err != nil {
+			return fmt.Errorf("save stock of variant %d: %w", stock.VariantID(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"botmanager/internal/tenant"
+)
+
+// shopOwned is entity which belongs to a shop, see domain.ShopOwned.
+type shopOwned interface {
+	AssignShop(shopID int) error
+}
+
+// assignShop binds new entity to shop of ctx.
+// Entity created outside of shop is left unbound.
+func assignShop(ctx context.Context, e shopOwned) error {
+	shopID, ok := tenant.ShopID(ctx)
+	if !ok {
+		return nil
+	}
+	return e.AssignShop(shopID)
+}
@@ -32,11 +32,11 @@ type EventBusSpy struct {
 	mu sync.Mutex
 
 	Called    bool
-	Published []domain.DomainEvent
+	Published []domain.Event
 	Err       error
 }
 
-func (b *EventBusSpy) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+func (b *EventBusSpy) Publish(ctx context.Context, events ...domain.Event) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -52,7 +52,7 @@ func (b *EventBusSpy) Publish(ctx context.Context, events ...domain.DomainEvent)
 // Subscribe If yor EventBus interface also has Subscribe, keep it as noop in tests.
 func (b *EventBusSpy) Subscribe(
 	enventName string,
-	handler func(context.Context, domain.DomainEvent) error,
+	handler func(context.Context, domain.Event) error,
 ) {
 	// noop
 }
@@ -71,11 +71,13 @@ func (b *EventBusSpy) Reset() {
 // UserRepoMock is a function-field mock for UserRepository.
 // Set ByIDFn/SaveFn per-test for strict behavior.
 type UserRepoMock struct {
-	SaveFn func(ctx context.Context, u *domain.User) error
-	ByIDFn func(ctx context.Context, id int) (*domain.User, error)
+	SaveFn         func(ctx context.Context, u *domain.User) error
+	ByIDFn         func(ctx context.Context, id int) (*domain.User, error)
+	ByTelegramIDFn func(ctx context.Context, tgID int64) (*domain.User, error)
 
-	SaveCalls int
-	ByIDCalls int
+	SaveCalls         int
+	ByIDCalls         int
+	ByTelegramIDCalls int
 }
 
 func (m *UserRepoMock) Save(ctx context.Context, u *domain.User) error {
@@ -94,6 +96,14 @@ func (m *UserRepoMock) ByID(ctx context.Context, id int) (*domain.User, error) {
 	return m.ByIDFn(ctx, id)
 }
 
+func (m *UserRepoMock) ByTelegramID(ctx context.Context, tgID int64) (*domain.User, error) {
+	m.ByTelegramIDCalls++
+	if m.ByTelegramIDFn == nil {
+		panic("UserRepoMock.ByTelegramIDFn is nil (unexpected call)")
+	}
+	return m.ByTelegramIDFn(ctx, tgID)
+}
+
 // ---- OrderRepository ----
 
 type OrderRepoMock struct {
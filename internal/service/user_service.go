@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	"botmanager/internal/domain"
@@ -69,6 +70,10 @@ func (s *UserService) Create(
 			return err
 		}
 
+		if err := assignShop(ctx, u); err != nil {
+			return err
+		}
+
 		if err := s.repo.Save(ctx, u); err != nil {
 			s.logger.Error("failed to save user", "err", err)
 			return err
@@ -80,3 +85,52 @@ func (s *UserService) Create(
 
 	return user, err
 }
+
+// EnsureTelegramUser returns user with Telegram id,
+// creating a new customer on first contact.
+func (s *UserService) EnsureTelegramUser(
+	ctx context.Context,
+	tgID int64,
+	tgName string,
+) (*domain.User, error) {
+	var user *domain.User
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		u, err := s.repo.ByTelegramID(ctx, tgID)
+		if err == nil {
+			user = u
+			return nil
+		}
+
+		if !errors.Is(err, domain.ErrUserNotFound) {
+			s.logger.Error("failed to load user", "tg_id", tgID, "err", err)
+			return err
+		}
+
+		u, err = domain.NewUser(domain.NewUserParams{
+			TgID:   &tgID,
+			TgName: tgName,
+		})
+		if err != nil {
+			s.logger.Warn("failed to create user", "tg_id", tgID, "err", err)
+			return err
+		}
+
+		// customers are registered per shop they came to
+		if err := assignShop(ctx, u); err != nil {
+			return err
+		}
+
+		if err := s.repo.Save(ctx, u); err != nil {
+			s.logger.Error("failed to save user", "tg_id", tgID, "err", err)
+			return err
+		}
+
+		s.logger.Info("telegram user registered", "user_id", u.ID(), "tg_id", tgID)
+
+		user = u
+		return nil
+	})
+
+	return user, err
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
 )
 
 func TestUserService_Create(t *testing.T) {
@@ -33,3 +35,70 @@ func TestUserService_Create(t *testing.T) {
 		t.Fatal("expected user to be saved")
 	}
 }
+
+func TestUserService_EnsureTelegramUser(t *testing.T) {
+	t.Run("creates user on first contact", func(t *testing.T) {
+		repo := &stubUserRepository{byIDErr: domain.ErrUserNotFound}
+		svc := NewUserService(repo, stubTxManager{}, stubEventBus{}, nil)
+
+		user, err := svc.EnsureTelegramUser(context.Background(), 42, "john")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if repo.saved != user {
+			t.Fatal("expected new user to be saved")
+		}
+
+		if id, ok := user.TelegramID(); !ok || id != 42 {
+			t.Fatalf("expected telegram id 42, got %d", id)
+		}
+	})
+
+	t.Run("registers user in shop of context", func(t *testing.T) {
+		repo := &stubUserRepository{byIDErr: domain.ErrUserNotFound}
+		svc := NewUserService(repo, stubTxManager{}, stubEventBus{}, nil)
+
+		ctx := tenant.WithShop(context.Background(), 7)
+		user, err := svc.EnsureTelegramUser(ctx, 42, "john")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if user.ShopID() != 7 {
+			t.Fatalf("expected user of shop 7, got %d", user.ShopID())
+		}
+	})
+
+	t.Run("returns existing user", func(t *testing.T) {
+		tgID := int64(42)
+		existing, _ := domain.NewUser(domain.NewUserParams{TgID: &tgID})
+
+		repo := &stubUserRepository{user: existing}
+		svc := NewUserService(repo, stubTxManager{}, stubEventBus{}, nil)
+
+		user, err := svc.EnsureTelegramUser(context.Background(), 42, "john")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if user != existing {
+			t.Fatal("expected existing user")
+		}
+
+		if repo.saved != nil {
+			t.Fatal("expected existing user not to be saved")
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repoErr := errors.New("db down")
+		repo := &stubUserRepository{byIDErr: repoErr}
+		svc := NewUserService(repo, stubTxManager{}, stubEventBus{}, nil)
+
+		_, err := svc.EnsureTelegramUser(context.Background(), 42, "john")
+		if !errors.Is(err, repoErr) {
+			t.Fatalf("expected repository error, got %v", err)
+		}
+	})
+}
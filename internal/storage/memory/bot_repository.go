@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"botmanager/internal/manager"
+)
+
+var _ manager.BotRepository = (*BotRepository)(nil)
+
+// BotRepository implements manager.BotRepository in memory.
+type BotRepository struct {
+	mu   sync.RWMutex
+	bots map[string]manager.BotRecord
+}
+
+// NewBotRepository creates a new in-memory bot repository.
+func NewBotRepository() *BotRepository {
+	return &BotRepository{
+		bots: make(map[string]manager.BotRecord),
+	}
+}
+
+// Save creates or updates bot record by id.
+func (r *BotRepository) Save(ctx context.Context, rec manager.BotRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.bots[rec.ID] = cloneRecord(rec)
+	return nil
+}
+
+// ByID returns bot record by id.
+func (r *BotRepository) ByID(ctx context.Context, id string) (manager.BotRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rec, ok := r.bots[id]
+	if !ok {
+		return manager.BotRecord{}, manager.ErrNotFound
+	}
+
+	return cloneRecord(rec), nil
+}
+
+// Delete removes bot record by id.
+func (r *BotRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.bots[id]; !ok {
+		return manager.ErrNotFound
+	}
+
+	delete(r.bots, id)
+	return nil
+}
+
+// List returns all bot records ordered by name.
+func (r *BotRepository) List(ctx context.Context) ([]manager.BotRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]manager.BotRecord, 0, len(r.bots))
+	for _, rec := range r.bots {
+		result = append(result, cloneRecord(rec))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// cloneRecord copies slices of rec, so callers can not change stored records.
+func cloneRecord(rec manager.BotRecord) manager.BotRecord {
+	rec.AllowedUpdates = slices.Clone(rec.AllowedUpdates)
+	rec.Storefront.CityIDs = slices.Clone(rec.Storefront.CityIDs)
+	rec.Storefront.CategoryIDs = slices.Clone(rec.Storefront.CategoryIDs)
+	return rec
+}
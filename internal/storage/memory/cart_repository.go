@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/service"
+)
+
+var _ service.CartRepository = (*CartRepository)(nil)
+
+// CartRepository implements service.CartRepository in memory.
+//
+// Carts are stored and returned as copies, so changes
+// of a failed operation never reach the repository.
+type CartRepository struct {
+	mu     sync.RWMutex
+	carts  map[int]*domain.Cart
+	nextID int
+}
+
+// NewCartRepository creates a new in-memory cart repository.
+func NewCartRepository() *CartRepository {
+	return &CartRepository{
+		carts:  make(map[int]*domain.Cart),
+		nextID: 1,
+	}
+}
+
+// Save creates cart without id or updates existing one.
+func (r *CartRepository) Save(ctx context.Context, cart *domain.Cart) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cart.ID() == 0 {
+		cart.SetID(r.nextID)
+		r.nextID++
+	} else if _, ok := r.carts[cart.ID()]; !ok {
+		return domain.ErrCartNotFound
+	}
+
+	r.carts[cart.ID()] = cart.Clone()
+	return nil
+}
+
+// ByID returns cart by id.
+func (r *CartRepository) ByID(ctx context.Context, id int) (*domain.Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cart, ok := r.carts[id]
+	if !ok {
+		return nil, domain.ErrCartNotFound
+	}
+
+	return cart.Clone(), nil
+}
+
+// ActiveByUser returns active cart of user.
+func (r *CartRepository) ActiveByUser(ctx context.Context, userID int) (*domain.Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, cart := range r.carts {
+		if cart.UserID() == userID && cart.Status() == domain.CartStatusActive {
+			return cart.Clone(), nil
+		}
+	}
+
+	return nil, domain.ErrCartNotFound
+}
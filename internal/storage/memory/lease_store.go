@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"botmanager/internal/manager"
+)
+
+var _ manager.LeaseStore = (*LeaseStore)(nil)
+
+// LeaseStore implements manager.LeaseStore in memory,
+// it coordinates managers of a single process.
+type LeaseStore struct {
+	mu     sync.Mutex
+	leases map[string]lease
+}
+
+type lease struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// NewLeaseStore creates a new in-memory lease store.
+func NewLeaseStore() *LeaseStore {
+	return &LeaseStore{
+		leases: make(map[string]lease),
+	}
+}
+
+// Acquire takes or extends lease of bot for owner
+// unless another owner holds not expired lease.
+func (s *LeaseStore) Acquire(ctx context.Context, botID, owner string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	l, ok := s.leases[botID]
+	if ok && l.owner != owner && now.Before(l.expiresAt) {
+		return false, nil
+	}
+
+	s.leases[botID] = lease{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Release removes lease of bot held by owner.
+func (s *LeaseStore) Release(ctx context.Context, botID, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.leases[botID]; ok && l.owner == owner {
+		delete(s.leases, botID)
+	}
+
+	return nil
+}
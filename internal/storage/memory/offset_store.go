@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"botmanager/internal/infrastructure/telegram"
+)
+
+var _ telegram.OffsetStore = (*OffsetStore)(nil)
+
+// OffsetStore implements telegram.OffsetStore in memory.
+type OffsetStore struct {
+	mu      sync.RWMutex
+	offsets map[string]int64
+}
+
+// NewOffsetStore creates a new in-memory offset store.
+func NewOffsetStore() *OffsetStore {
+	return &OffsetStore{
+		offsets: make(map[string]int64),
+	}
+}
+
+// Load returns saved offset of bot or 0.
+func (s *OffsetStore) Load(ctx context.Context, botID string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.offsets[botID], nil
+}
+
+// Save stores offset of bot.
+func (s *OffsetStore) Save(ctx context.Context, botID string, offset int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offsets[botID] = offset
+	return nil
+}
@@ -2,39 +2,100 @@ package memory
 
 import (
 	"context"
+	"slices"
+	"sync"
+	"time"
 
 	"botmanager/internal/domain"
+	"botmanager/internal/service"
+	"botmanager/internal/tenant"
 )
 
+var (
+	_ service.OrderRepository    = (*OrderRepository)(nil)
+	_ service.OverdueOrderFinder = (*OrderRepository)(nil)
+)
+
+// OrderRepository implements service.OrderRepository in memory.
+//
+// Orders are stored and returned as copies, so changes
+// of a failed operation never reach the repository.
 type OrderRepository struct {
+	mu     sync.RWMutex
 	orders map[int]*domain.Order
+	nextID int
 }
 
 func NewOrderRepository() *OrderRepository {
 	return &OrderRepository{
 		orders: make(map[int]*domain.Order),
+		nextID: 1,
 	}
 }
 
 func (r *OrderRepository) Create(ctx context.Context, order *domain.Order) error {
-	r.orders[order.ID()] = order
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.orders[order.ID()] = order.Clone()
+
+	return nil
+}
+
+// Save creates order without id or updates existing one.
+func (r *OrderRepository) Save(ctx context.Context, order *domain.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if order.ID() == 0 {
+		order.SetID(r.nextID)
+		r.nextID++
+	} else if stored, ok := r.orders[order.ID()]; !ok || !tenant.Allows(ctx, stored.ShopID()) {
+		return domain.ErrOrderNotFound
+	}
 
+	r.orders[order.ID()] = order.Clone()
 	return nil
 }
 
 func (r *OrderRepository) ByID(ctx context.Context, id int) (*domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	order, ok := r.orders[id]
-	if !ok {
+	if !ok || !tenant.Allows(ctx, order.ShopID()) {
 		return nil, domain.ErrOrderNotFound
 	}
-	return order, nil
+	return order.Clone(), nil
 }
 
 func (r *OrderRepository) Update(ctx context.Context, order *domain.Order) error {
-	if _, ok := r.orders[order.ID()]; !ok {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.orders[order.ID()]; !ok || !tenant.Allows(ctx, stored.ShopID()) {
 		return domain.ErrOrderNotFound
 	}
 
-	r.orders[order.ID()] = order
+	r.orders[order.ID()] = order.Clone()
 	return nil
 }
+
+// OverdueIDs implements [service.OverdueOrderFinder].
+func (r *OrderRepository) OverdueIDs(ctx context.Context, now time.Time, afterID int, limit int) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []int
+	for id, order := range r.orders {
+		if id > afterID && order.IsOverdue(now) && tenant.Allows(ctx, order.ShopID()) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
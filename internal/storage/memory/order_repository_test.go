@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/service"
+)
+
+type nopEventBus struct{}
+
+func (nopEventBus) Publish(ctx context.Context, events ...domain.Event) error {
+	return nil
+}
+
+type nopUserRepository struct{}
+
+func (nopUserRepository) ByID(ctx context.Context, id int) (*domain.User, error) {
+	return nil, domain.ErrUserNotFound
+}
+
+func (nopUserRepository) ByTelegramID(ctx context.Context, tgID int64) (*domain.User, error) {
+	return nil, domain.ErrUserNotFound
+}
+
+func (nopUserRepository) Save(ctx context.Context, user *domain.User) error {
+	return nil
+}
+
+// TestOrderSweeperWithCheckout runs sweeper alongside checkouts,
+// it is meant to be run with -race.
+func TestOrderSweeperWithCheckout(t *testing.T) {
+	ctx := context.Background()
+
+	products := NewProductRepository(&sync.Mutex{})
+	categoryID := 1
+	_ = products.Create(ctx, domain.NewProductFromDB(1, &categoryID, "Arabica", "", nil, 1, []domain.ProductVariant{
+		*domain.NewProductVariantFromDB(1000, "250g", 10, 1500, nil),
+	}))
+
+	stocks := NewStockRepository()
+	stock, _ := domain.NewStock(1, 1000, 100)
+	_ = stocks.Save(ctx, stock)
+
+	carts := NewCartRepository()
+	orders := NewOrderRepository()
+	tx := NewTxManager(&sync.Mutex{})
+
+	orderService := service.NewOrderService(products, orders, nopUserRepository{}, stocks, nopEventBus{}, tx, nil)
+	cartService := service.NewCartService(carts, products, orders, stocks, nopEventBus{}, tx, nil)
+	// every order is overdue at once
+	cartService.SetPaymentTTL(time.Nanosecond)
+
+	sweeper := service.NewOrderSweeper(orders, orderService, time.Millisecond, 2, nil)
+
+	sweepCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sweeper.Run(sweepCtx)
+	}()
+
+	for userID := 1; userID <= 20; userID++ {
+		cart, err := cartService.AddItem(ctx, userID, 1000, 1)
+		if err != nil {
+			t.Fatalf("add item: %v", err)
+		}
+		if _, err := cartService.Checkout(ctx, userID, cart.ID()); err != nil {
+			t.Fatalf("checkout: %v", err)
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	stop()
+	<-done
+
+	if _, err := sweeper.Sweep(ctx); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+
+	left, _ := stocks.ByVariantID(ctx, 1000)
+	if left.Reserved() != 0 {
+		t.Fatalf("expected all reservations to be released, got %d reserved", left.Reserved())
+	}
+}
+
+func TestOrderRepositoryKeepsFailedChangesOut(t *testing.T) {
+	ctx := context.Background()
+
+	products := NewProductRepository(&sync.Mutex{})
+	categoryID := 1
+	_ = products.Create(ctx, domain.NewProductFromDB(1, &categoryID, "Arabica", "", nil, 1, []domain.ProductVariant{
+		*domain.NewProductVariantFromDB(1000, "250g", 10, 1500, nil),
+	}))
+
+	stocks := NewStockRepository()
+	stock, _ := domain.NewStock(1, 1000, 5)
+	_ = stocks.Save(ctx, stock)
+
+	orders := NewOrderRepository()
+	svc := service.NewOrderService(products, orders, NewUserRepository(), stocks, nopEventBus{}, NewTxManager(&sync.Mutex{}), nil)
+
+	order, err := svc.CreateForVariant(ctx, 1, 1, 1000)
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	// reservation is lost, so stock can not be decreased on payment
+	_ = stocks.Save(ctx, domain.NewStockFromDB(stock.ID(), 1, 1000, 5, 0))
+
+	if err := svc.ConfirmPayment(ctx, order.ID()); !errors.Is(err, domain.ErrInsufficientReservedStock) {
+		t.Fatalf("expected ErrInsufficientReservedStock, got %v", err)
+	}
+
+	stored, err := orders.ByID(ctx, order.ID())
+	if err != nil {
+		t.Fatalf("load order: %v", err)
+	}
+
+	if stored.Status() != domain.OrderStatusPending {
+		t.Fatalf("expected failed payment to keep order pending, got %s", stored.Status())
+	}
+}
@@ -2,11 +2,17 @@ package memory
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
 )
 
+// ProductRepository stores products in memory.
+//
+// Products are stored and returned as copies, so changes
+// of a failed operation never reach the repository.
 type ProductRepository struct {
 	mu       *sync.Mutex
 	products map[int]*domain.Product
@@ -28,8 +34,14 @@ func (r *ProductRepository) Create(
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.products[r.nextID] = product
-	r.nextID++
+	if product.ID() == 0 {
+		product.SetID(r.nextID)
+	}
+	if product.ID() >= r.nextID {
+		r.nextID = product.ID() + 1
+	}
+
+	r.products[product.ID()] = product.Clone()
 
 	return nil
 }
@@ -42,18 +54,68 @@ func (r *ProductRepository) ByID(
 	defer r.mu.Unlock()
 
 	product, ok := r.products[id]
-	if !ok {
+	if !ok || !tenant.Allows(ctx, product.ShopID()) {
 		return nil, domain.ErrProductNotFound
 	}
 
-	return product, nil
+	return product.Clone(), nil
+}
+
+// ByVariantID returns product owning variant, archived variants included.
+func (r *ProductRepository) ByVariantID(
+	ctx context.Context,
+	variantID int,
+) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.products {
+		if !tenant.Allows(ctx, p.ShopID()) {
+			continue
+		}
+		for _, v := range p.VariantsForUpdate() {
+			if v.ID() == variantID {
+				return p.Clone(), nil
+			}
+		}
+	}
+
+	return nil, domain.ErrVariantNotFound
+}
+
+func (r *ProductRepository) ListByCategory(
+	ctx context.Context,
+	categoryID int,
+) ([]*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*domain.Product
+	for _, p := range r.products {
+		if !tenant.Allows(ctx, p.ShopID()) {
+			continue
+		}
+		if id := p.CategoryID(); id != nil && *id == categoryID {
+			result = append(result, p.Clone())
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID() < result[j].ID()
+	})
+
+	return result, nil
 }
 
 func (r *ProductRepository) Update(ctx context.Context, product *domain.Product) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.products[product.ID()] = product
+	if p, ok := r.products[product.ID()]; ok && !tenant.Allows(ctx, p.ShopID()) {
+		return domain.ErrProductNotFound
+	}
+
+	r.products[product.ID()] = product.Clone()
 	return nil
 }
 
@@ -64,6 +126,10 @@ func (r *ProductRepository) Delete(
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if p, ok := r.products[id]; ok && !tenant.Allows(ctx, p.ShopID()) {
+		return domain.ErrProductNotFound
+	}
+
 	delete(r.products, id)
 	return nil
 }
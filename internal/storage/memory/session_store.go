@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"botmanager/internal/storage"
+)
+
+var _ storage.SessionStore = (*SessionStore)(nil)
+
+// SessionStore implements storage.SessionStore in memory.
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[storage.SessionKey]storage.SessionRecord
+}
+
+// NewSessionStore creates a new in-memory session store.
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		sessions: make(map[storage.SessionKey]storage.SessionRecord),
+	}
+}
+
+// Load returns not expired session of key.
+func (s *SessionStore) Load(ctx context.Context, key storage.SessionKey, now time.Time) (storage.SessionRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.sessions[key]
+	if !ok || r.Expired(now) {
+		return storage.SessionRecord{}, storage.ErrSessionNotFound
+	}
+
+	r.Data = append([]byte(nil), r.Data...)
+	return r, nil
+}
+
+// Save stores session if its version matches stored one.
+func (s *SessionStore) Save(ctx context.Context, r storage.SessionRecord, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current int
+	if stored, ok := s.sessions[r.Key]; ok && !stored.Expired(now) {
+		current = stored.Version
+	}
+	if r.Version != current {
+		return 0, storage.ErrSessionConflict
+	}
+
+	r.Version = current + 1
+	r.Data = append([]byte(nil), r.Data...)
+	s.sessions[r.Key] = r
+
+	return r.Version, nil
+}
+
+// Delete removes session of key if its version matches stored one.
+func (s *SessionStore) Delete(ctx context.Context, key storage.SessionKey, version int, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current int
+	if stored, ok := s.sessions[key]; ok && !stored.Expired(now) {
+		current = stored.Version
+	}
+	if version != current {
+		return storage.ErrSessionConflict
+	}
+
+	delete(s.sessions, key)
+	return nil
+}
+
+// DeleteExpired removes sessions expired at now.
+func (s *SessionStore) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	for key, r := range s.sessions {
+		if r.Expired(now) {
+			delete(s.sessions, key)
+			n++
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/service"
+)
+
+var _ service.ShopRepository = (*ShopRepository)(nil)
+
+// ShopRepository implements service.ShopRepository in memory.
+type ShopRepository struct {
+	mu     sync.RWMutex
+	shops  map[int]*domain.Shop
+	nextID int
+}
+
+// NewShopRepository creates a new in-memory shop repository.
+func NewShopRepository() *ShopRepository {
+	return &ShopRepository{
+		shops:  make(map[int]*domain.Shop),
+		nextID: 1,
+	}
+}
+
+// Save creates shop without id or updates existing one.
+func (r *ShopRepository) Save(ctx context.Context, shop *domain.Shop) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if shop.ID() == 0 {
+		shop.SetID(r.nextID)
+		r.nextID++
+	} else if _, ok := r.shops[shop.ID()]; !ok {
+		return domain.ErrShopNotFound
+	}
+
+	r.shops[shop.ID()] = shop
+	return nil
+}
+
+// ByID returns shop by id.
+func (r *ShopRepository) ByID(ctx context.Context, id int) (*domain.Shop, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shop, ok := r.shops[id]
+	if !ok {
+		return nil, domain.ErrShopNotFound
+	}
+
+	return shop, nil
+}
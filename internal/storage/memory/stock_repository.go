@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/service"
+)
+
+var _ service.StockRepository = (*StockRepository)(nil)
+
+// StockRepository implements service.StockRepository in memory.
+//
+// Stocks are stored and returned as copies, so changes
+// of a failed operation never reach the repository.
+type StockRepository struct {
+	mu     sync.RWMutex
+	stocks map[int]domain.Stock
+	nextID int
+}
+
+// NewStockRepository creates a new in-memory stock repository.
+func NewStockRepository() *StockRepository {
+	return &StockRepository{
+		stocks: make(map[int]domain.Stock),
+		nextID: 1,
+	}
+}
+
+// ByVariantID returns stock of variant.
+func (r *StockRepository) ByVariantID(ctx context.Context, variantID int) (*domain.Stock, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock, ok := r.stocks[variantID]
+	if !ok {
+		return nil, domain.ErrStockNotFound
+	}
+
+	return &stock, nil
+}
+
+// Save creates or replaces stock of variant.
+func (r *StockRepository) Save(ctx context.Context, stock *domain.Stock) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stock.ID() == 0 {
+		stock.SetID(r.nextID)
+		r.nextID++
+	}
+
+	r.stocks[stock.VariantID()] = *stock
+	return nil
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/service"
+	"botmanager/internal/tenant"
+)
+
+var _ service.UserRepository = (*UserRepository)(nil)
+
+// UserRepository implements service.UserRepository in memory.
+//
+// Users are stored and returned as copies, so changes
+// of a failed operation never reach the repository.
+type UserRepository struct {
+	mu     sync.RWMutex
+	users  map[int]*domain.User
+	nextID int
+}
+
+// NewUserRepository creates a new in-memory user repository.
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users:  make(map[int]*domain.User),
+		nextID: 1,
+	}
+}
+
+// Save creates user without id or updates existing one.
+func (r *UserRepository) Save(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user.ID() == 0 {
+		user.SetID(r.nextID)
+		r.nextID++
+	} else if stored, ok := r.users[user.ID()]; !ok || !tenant.Allows(ctx, stored.ShopID()) {
+		return domain.ErrUserNotFound
+	}
+
+	r.users[user.ID()] = user.Clone()
+	return nil
+}
+
+// ByID returns user by id.
+func (r *UserRepository) ByID(ctx context.Context, id int) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || !tenant.Allows(ctx, user.ShopID()) {
+		return nil, domain.ErrUserNotFound
+	}
+	return user.Clone(), nil
+}
+
+// ByTelegramID returns user with Telegram id.
+func (r *UserRepository) ByTelegramID(ctx context.Context, tgID int64) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if id, ok := user.TelegramID(); ok && id == tgID && tenant.Allows(ctx, user.ShopID()) {
+			return user.Clone(), nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"botmanager/internal/manager"
+)
+
+var _ manager.BotRepository = (*BotRepository)(nil)
+
+// BotRepository implements manager.BotRepository for PostgreSQL.
+type BotRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewBotRepository creates a new bot repository.
+func NewBotRepository(db *sql.DB, logger *slog.Logger) *BotRepository {
+	return &BotRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Save creates bot record or updates existing one with the same bot id.
+func (r *BotRepository) Save(ctx context.Context, rec manager.BotRecord) error {
+	storefront, err := json.Marshal(storefrontJSON(rec.Storefront))
+	if err != nil {
+		return fmt.Errorf("encode storefront: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO bots (
+			bot_id, name, encrypted_token, is_enabled, allowed_updates,
+			telegram_id, username, can_join_groups, can_read_all_group_messages, supports_inline_queries,
+			storefront, shop_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, 0))
+		ON CONFLICT (bot_id) DO UPDATE
+		SET name=EXCLUDED.name,
+		    encrypted_token=EXCLUDED.encrypted_token,
+		    is_enabled=EXCLUDED.is_enabled,
+		    allowed_updates=EXCLUDED.allowed_updates,
+		    telegram_id=EXCLUDED.telegram_id,
+		    username=EXCLUDED.username,
+		    can_join_groups=EXCLUDED.can_join_groups,
+		    can_read_all_group_messages=EXCLUDED.can_read_all_group_messages,
+		    supports_inline_queries=EXCLUDED.supports_inline_queries,
+		    storefront=EXCLUDED.storefront,
+		    shop_id=EXCLUDED.shop_id,
+		    updated_at=NOW()
+	`,
+		rec.ID, rec.Name, rec.EncryptedToken, rec.Enabled, strings.Join(rec.AllowedUpdates, ","),
+		rec.Identity.TelegramID,
+		rec.Identity.Username,
+		rec.Identity.CanJoinGroups,
+		rec.Identity.CanReadAllGroupMessages,
+		rec.Identity.SupportsInlineQueries,
+		storefront,
+		rec.ShopID,
+	)
+	if err != nil {
+		r.logger.Error("failed to save bot", "bot_id", rec.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes bot record by bot id.
+func (r *BotRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM bots WHERE bot_id=$1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete bot", "bot_id", id, "err", err)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return manager.ErrNotFound
+	}
+
+	return nil
+}
+
+// selectBots selects columns scanned by scanBot.
+const selectBots = `
+	SELECT bot_id, name, encrypted_token, is_enabled, allowed_updates,
+	       telegram_id, username, can_join_groups, can_read_all_group_messages, supports_inline_queries,
+	       storefront, COALESCE(shop_id, 0)
+	FROM bots
+`
+
+// ByID returns bot record by bot id.
+func (r *BotRepository) ByID(ctx context.Context, id string) (manager.BotRecord, error) {
+	row := r.db.QueryRowContext(ctx, selectBots+` WHERE bot_id=$1`, id)
+
+	rec, err := scanBot(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return manager.BotRecord{}, manager.ErrNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get bot", "bot_id", id, "err", err)
+		return manager.BotRecord{}, err
+	}
+
+	return rec, nil
+}
+
+// List returns all bot records ordered by name.
+func (r *BotRepository) List(ctx context.Context) ([]manager.BotRecord, error) {
+	rows, err := r.db.QueryContext(ctx, selectBots+` ORDER BY name, id`)
+	if err != nil {
+		r.logger.Error("failed to query bots", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []manager.BotRecord
+
+	for rows.Next() {
+		rec, err := scanBot(rows)
+		if err != nil {
+			r.logger.Error("failed to scan bot", "err", err)
+			return nil, err
+		}
+
+		result = append(result, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// scanBot scans row selected by selectBots.
+func scanBot(row rowScanner) (manager.BotRecord, error) {
+	var (
+		rec            manager.BotRecord
+		allowedUpdates string
+		storefront     []byte
+	)
+	err := row.Scan(
+		&rec.ID, &rec.Name, &rec.EncryptedToken, &rec.Enabled, &allowedUpdates,
+		&rec.Identity.TelegramID,
+		&rec.Identity.Username,
+		&rec.Identity.CanJoinGroups,
+		&rec.Identity.CanReadAllGroupMessages,
+		&rec.Identity.SupportsInlineQueries,
+		&storefront,
+		&rec.ShopID,
+	)
+	if err != nil {
+		return manager.BotRecord{}, err
+	}
+	if allowedUpdates != "" {
+		rec.AllowedUpdates = strings.Split(allowedUpdates, ",")
+	}
+
+	var sf storefrontJSON
+	if err := json.Unmarshal(storefront, &sf); err != nil {
+		return manager.BotRecord{}, fmt.Errorf("decode storefront of bot %s: %w", rec.ID, err)
+	}
+	rec.Storefront = manager.Storefront(sf)
+
+	return rec, nil
+}
+
+// storefrontJSON is stored form of manager.Storefront.
+type storefrontJSON struct {
+	CityIDs        []int  `json:"city_ids,omitempty"`
+	CategoryIDs    []int  `json:"category_ids,omitempty"`
+	WelcomeText    string `json:"welcome_text,omitempty"`
+	SupportContact string `json:"support_contact,omitempty"`
+}
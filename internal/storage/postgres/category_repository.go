@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/storage"
+)
+
+var _ storage.CategoryRepository = (*CategoryRepository)(nil)
+
+// selectCategories selects categories of shop $1.
+const selectCategories = `SELECT cat.id, cat.name, cat.description, COALESCE(cat.shop_id, 0)
+	FROM categories cat
+	WHERE ($1 = 0 OR cat.shop_id = $1)`
+
+// CategoryRepository stores categories in postgres.
+type CategoryRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewCategoryRepository creates a new category repository.
+func NewCategoryRepository(db *sql.DB, logger *slog.Logger) *CategoryRepository {
+	return &CategoryRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// List returns all categories ordered by name.
+func (r *CategoryRepository) List(ctx context.Context) ([]domain.Category, error) {
+	return r.list(ctx, selectCategories+` ORDER BY cat.name, cat.id`)
+}
+
+// ListByCity returns categories of products sold in city.
+func (r *CategoryRepository) ListByCity(ctx context.Context, cityID int) ([]domain.Category, error) {
+	return r.list(ctx, selectCategories+` AND EXISTS (
+			SELECT 1 FROM products p
+			JOIN product_variants v ON v.product_id = p.id
+			JOIN districts d ON d.id = v.district_id
+			WHERE p.category_id = cat.id AND v.archived_at IS NULL AND d.city_id = $2
+		)
+		ORDER BY cat.name, cat.id`, cityID)
+}
+
+// ListByDistrict returns categories of products sold in district.
+func (r *CategoryRepository) ListByDistrict(ctx context.Context, districtID int) ([]domain.Category, error) {
+	return r.list(ctx, selectCategories+` AND EXISTS (
+			SELECT 1 FROM products p
+			JOIN product_variants v ON v.product_id = p.id
+			WHERE p.category_id = cat.id AND v.archived_at IS NULL AND v.district_id = $2
+		)
+		ORDER BY cat.name, cat.id`, districtID)
+}
+
+// ByID returns category by id.
+func (r *CategoryRepository) ByID(ctx context.Context, id int) (*domain.Category, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx,
+		selectCategories+` AND cat.id = $2`, shopFilter(ctx), id,
+	)
+
+	category, err := scanCategory(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrCategoryNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to load category", "id", id, "err", err)
+		return nil, err
+	}
+	return category, nil
+}
+
+// Create inserts category and sets its id.
+func (r *CategoryRepository) Create(ctx context.Context, category *domain.Category) error {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`INSERT INTO categories (name, description, shop_id)
+		 VALUES ($1, $2, NULLIF($3, 0)) RETURNING id`,
+		category.Name(), category.Desecription(), category.ShopID(),
+	).Scan(&id)
+	if err != nil {
+		r.logger.Error("failed to insert category", "name", category.Name(), "err", err)
+		return err
+	}
+
+	category.SetID(id)
+	return nil
+}
+
+// Update saves name and description of category.
+func (r *CategoryRepository) Update(ctx context.Context, category *domain.Category) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE categories SET name=$1, description=$2
+		 WHERE id=$3 AND ($4 = 0 OR shop_id = $4)`,
+		category.Name(), category.Desecription(), category.ID(), shopFilter(ctx),
+	)
+	return r.affected(res, err, "update", category.ID())
+}
+
+// DeleteByID removes category, its products are left without category.
+func (r *CategoryRepository) DeleteByID(ctx context.Context, id int) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM categories WHERE id=$1 AND ($2 = 0 OR shop_id = $2)`,
+		id, shopFilter(ctx),
+	)
+	return r.affected(res, err, "delete", id)
+}
+
+func (r *CategoryRepository) list(ctx context.Context, query string, args ...any) ([]domain.Category, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, append([]any{shopFilter(ctx)}, args...)...)
+	if err != nil {
+		r.logger.Error("failed to query categories", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []domain.Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			r.logger.Error("failed to scan category", "err", err)
+			return nil, err
+		}
+		categories = append(categories, *category)
+	}
+	return categories, rows.Err()
+}
+
+// affected checks result of statement changing category with id.
+func (r *CategoryRepository) affected(res sql.Result, err error, op string, id int) error {
+	if err != nil {
+		r.logger.Error("failed to "+op+" category", "id", id, "err", err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrCategoryNotFound
+	}
+	return nil
+}
+
+func scanCategory(row rowScanner) (*domain.Category, error) {
+	var (
+		id, shopID        int
+		name, description string
+	)
+	if err := row.Scan(&id, &name, &description, &shopID); err != nil {
+		return nil, err
+	}
+
+	category := domain.NewCategoryFromDB(id, name, description)
+	if shopID != 0 {
+		if err := category.AssignShop(shopID); err != nil {
+			return nil, err
+		}
+	}
+	return category, nil
+}
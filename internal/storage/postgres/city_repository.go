@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/storage"
+)
+
+var _ storage.CityRepository = (*CityRepository)(nil)
+
+// CityRepository stores cities in postgres.
+type CityRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewCityRepository creates a new city repository.
+func NewCityRepository(db *sql.DB, logger *slog.Logger) *CityRepository {
+	return &CityRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// List returns cities of shop ctx is bound to ordered by name.
+func (r *CityRepository) List(ctx context.Context) ([]domain.City, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx,
+		`SELECT id, name, COALESCE(shop_id, 0)
+		 FROM cities WHERE ($1 = 0 OR shop_id = $1)
+		 ORDER BY name, id`,
+		shopFilter(ctx),
+	)
+	if err != nil {
+		r.logger.Error("failed to query cities", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cities []domain.City
+	for rows.Next() {
+		city, err := scanCity(rows)
+		if err != nil {
+			r.logger.Error("failed to scan city", "err", err)
+			return nil, err
+		}
+		cities = append(cities, *city)
+	}
+	return cities, rows.Err()
+}
+
+// ByID returns city by id.
+func (r *CityRepository) ByID(ctx context.Context, id int) (*domain.City, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx,
+		`SELECT id, name, COALESCE(shop_id, 0)
+		 FROM cities WHERE id=$1 AND ($2 = 0 OR shop_id = $2)`,
+		id, shopFilter(ctx),
+	)
+
+	city, err := scanCity(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrCityNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to load city", "id", id, "err", err)
+		return nil, err
+	}
+	return city, nil
+}
+
+// Create inserts city and sets its id.
+func (r *CityRepository) Create(ctx context.Context, city *domain.City) error {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`INSERT INTO cities (name, shop_id) VALUES ($1, NULLIF($2, 0)) RETURNING id`,
+		city.Name(), city.ShopID(),
+	).Scan(&id)
+	if err != nil {
+		r.logger.Error("failed to insert city", "name", city.Name(), "err", err)
+		return err
+	}
+
+	city.SetID(id)
+	return nil
+}
+
+// Update saves name of city.
+func (r *CityRepository) Update(ctx context.Context, city *domain.City) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE cities SET name=$1 WHERE id=$2 AND ($3 = 0 OR shop_id = $3)`,
+		city.Name(), city.ID(), shopFilter(ctx),
+	)
+	return r.affected(res, err, "update", city.ID())
+}
+
+// Delete removes city with its districts.
+func (r *CityRepository) Delete(ctx context.Context, id int) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM cities WHERE id=$1 AND ($2 = 0 OR shop_id = $2)`,
+		id, shopFilter(ctx),
+	)
+	return r.affected(res, err, "delete", id)
+}
+
+// affected checks result of statement changing city with id.
+func (r *CityRepository) affected(res sql.Result, err error, op string, id int) error {
+	if err != nil {
+		r.logger.Error("failed to "+op+" city", "id", id, "err", err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrCityNotFound
+	}
+	return nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCity(row rowScanner) (*domain.City, error) {
+	var (
+		id, shopID int
+		name       string
+	)
+	if err := row.Scan(&id, &name, &shopID); err != nil {
+		return nil, err
+	}
+
+	city := domain.NewCityFromDB(id, name)
+	if shopID != 0 {
+		if err := city.AssignShop(shopID); err != nil {
+			return nil, err
+		}
+	}
+	return city, nil
+}
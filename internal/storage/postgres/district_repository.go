@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/storage"
+)
+
+var _ storage.DistrictRepository = (*DistrictRepository)(nil)
+
+// selectDistricts selects districts of cities of shop $1.
+const selectDistricts = `SELECT d.id, d.city_id, d.name
+	FROM districts d JOIN cities c ON c.id = d.city_id
+	WHERE ($1 = 0 OR c.shop_id = $1)`
+
+// DistrictRepository stores districts in postgres.
+//
+// Districts belong to shop of their city.
+type DistrictRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewDistrictRepository creates a new district repository.
+func NewDistrictRepository(db *sql.DB, logger *slog.Logger) *DistrictRepository {
+	return &DistrictRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// List returns all districts ordered by name.
+func (r *DistrictRepository) List(ctx context.Context) ([]domain.District, error) {
+	return r.list(ctx, selectDistricts+` ORDER BY d.name, d.id`)
+}
+
+// ListByCity returns districts of city ordered by name.
+func (r *DistrictRepository) ListByCity(ctx context.Context, cityID int) ([]domain.District, error) {
+	return r.list(ctx, selectDistricts+` AND d.city_id = $2 ORDER BY d.name, d.id`, cityID)
+}
+
+// ListByCategory returns districts selling products of category.
+func (r *DistrictRepository) ListByCategory(ctx context.Context, categoryID int) ([]domain.District, error) {
+	return r.list(ctx, selectDistricts+` AND EXISTS (
+			SELECT 1 FROM product_variants v JOIN products p ON p.id = v.product_id
+			WHERE v.district_id = d.id AND v.archived_at IS NULL AND p.category_id = $2
+		)
+		ORDER BY d.name, d.id`, categoryID)
+}
+
+// ListByProduct returns districts selling product.
+func (r *DistrictRepository) ListByProduct(ctx context.Context, productID int) ([]domain.District, error) {
+	return r.list(ctx, selectDistricts+` AND EXISTS (
+			SELECT 1 FROM product_variants v
+			WHERE v.district_id = d.id AND v.archived_at IS NULL AND v.product_id = $2
+		)
+		ORDER BY d.name, d.id`, productID)
+}
+
+// ByID returns district by id.
+func (r *DistrictRepository) ByID(ctx context.Context, id int) (*domain.District, error) {
+	var cityID int
+	var name string
+
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		selectDistricts+` AND d.id = $2`, shopFilter(ctx), id,
+	).Scan(&id, &cityID, &name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrDistrictNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to load district", "id", id, "err", err)
+		return nil, err
+	}
+
+	return domain.NewDistrictFromDB(id, cityID, name), nil
+}
+
+// Create inserts district and sets its id.
+func (r *DistrictRepository) Create(ctx context.Context, district *domain.District) error {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`INSERT INTO districts (city_id, name)
+		 SELECT c.id, $2 FROM cities c
+		 WHERE c.id = $1 AND ($3 = 0 OR c.shop_id = $3)
+		 RETURNING id`,
+		district.CityID(), district.Name(), shopFilter(ctx),
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrCityNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to insert district", "city_id", district.CityID(), "err", err)
+		return err
+	}
+
+	district.SetID(id)
+	return nil
+}
+
+// Update saves name of district.
+func (r *DistrictRepository) Update(ctx context.Context, district *domain.District) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE districts d SET name = $2
+		 FROM cities c
+		 WHERE d.id = $1 AND c.id = d.city_id AND ($3 = 0 OR c.shop_id = $3)`,
+		district.ID(), district.Name(), shopFilter(ctx),
+	)
+	return r.affected(res, err, "update", district.ID())
+}
+
+// DeleteByID removes district.
+func (r *DistrictRepository) DeleteByID(ctx context.Context, id int) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM districts d USING cities c
+		 WHERE d.id = $1 AND c.id = d.city_id AND ($2 = 0 OR c.shop_id = $2)`,
+		id, shopFilter(ctx),
+	)
+	return r.affected(res, err, "delete", id)
+}
+
+func (r *DistrictRepository) list(ctx context.Context, query string, args ...any) ([]domain.District, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, append([]any{shopFilter(ctx)}, args...)...)
+	if err != nil {
+		r.logger.Error("failed to query districts", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var districts []domain.District
+	for rows.Next() {
+		var id, cityID int
+		var name string
+		if err := rows.Scan(&id, &cityID, &name); err != nil {
+			r.logger.Error("failed to scan district", "err", err)
+			return nil, err
+		}
+		districts = append(districts, *domain.NewDistrictFromDB(id, cityID, name))
+	}
+	return districts, rows.Err()
+}
+
+// affected checks result of statement changing district with id.
+func (r *DistrictRepository) affected(res sql.Result, err error, op string, id int) error {
+	if err != nil {
+		r.logger.Error("failed to "+op+" district", "id", id, "err", err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrDistrictNotFound
+	}
+	return nil
+}
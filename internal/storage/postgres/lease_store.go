@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"botmanager/internal/manager"
+)
+
+var _ manager.LeaseStore = (*LeaseStore)(nil)
+
+// LeaseStore implements manager.LeaseStore for PostgreSQL.
+//
+// Expiry is checked against database clock,
+// so clocks of instances need not be in sync.
+type LeaseStore struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewLeaseStore creates a new lease store.
+func NewLeaseStore(db *sql.DB, logger *slog.Logger) *LeaseStore {
+	return &LeaseStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Acquire takes or extends lease of bot for owner
+// unless another owner holds not expired lease.
+func (s *LeaseStore) Acquire(ctx context.Context, botID, owner string, ttl time.Duration) (bool, error) {
+	var holder string
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO bot_leases (bot_id, owner, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (bot_id) DO UPDATE
+		SET owner=EXCLUDED.owner, expires_at=EXCLUDED.expires_at
+		WHERE bot_leases.owner=EXCLUDED.owner OR bot_leases.expires_at < NOW()
+		RETURNING owner
+	`, botID, owner, ttl.Milliseconds()).Scan(&holder)
+	if err != nil {
+		// conflicting row was not updated
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		s.logger.Error("failed to acquire bot lease", "bot_id", botID, "err", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Release removes lease of bot held by owner.
+func (s *LeaseStore) Release(ctx context.Context, botID, owner string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM bot_leases WHERE bot_id=$1 AND owner=$2`,
+		botID, owner,
+	)
+	if err != nil {
+		s.logger.Error("failed to release bot lease", "bot_id", botID, "err", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"botmanager/internal/infrastructure/telegram"
+)
+
+var _ telegram.OffsetStore = (*OffsetStore)(nil)
+
+// OffsetStore implements telegram.OffsetStore for PostgreSQL.
+type OffsetStore struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewOffsetStore creates a new offset store.
+func NewOffsetStore(db *sql.DB, logger *slog.Logger) *OffsetStore {
+	return &OffsetStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Load returns saved offset of bot or 0.
+func (s *OffsetStore) Load(ctx context.Context, botID string) (int64, error) {
+	var offset int64
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT update_offset FROM bot_update_offsets WHERE bot_id=$1`,
+		botID,
+	).Scan(&offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		s.logger.Error("failed to load update offset", "bot_id", botID, "err", err)
+		return 0, err
+	}
+
+	return offset, nil
+}
+
+// Save stores offset of bot.
+func (s *OffsetStore) Save(ctx context.Context, botID string, offset int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO bot_update_offsets (bot_id, update_offset)
+		VALUES ($1, $2)
+		ON CONFLICT (bot_id) DO UPDATE
+		SET update_offset=EXCLUDED.update_offset, updated_at=NOW()
+	`, botID, offset)
+	if err != nil {
+		s.logger.Error("failed to save update offset", "bot_id", botID, "err", err)
+		return err
+	}
+
+	return nil
+}
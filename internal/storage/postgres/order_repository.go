@@ -2,35 +2,185 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
 
 	"botmanager/internal/domain"
 	"botmanager/internal/service"
 )
 
-var _ service.OrderRepository = (*OrderRepo)(nil)
+var (
+	_ service.OrderRepository    = (*OrderRepository)(nil)
+	_ service.OverdueOrderFinder = (*OrderRepository)(nil)
+)
 
-type OrderRepo struct{}
+// OrderRepository stores orders and their items in postgres.
+//
+// Within transaction of TxManager order rows are locked
+// until commit, see ByID. Items never change once order
+// is created, so they are written only by the first Save.
+type OrderRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
 
-func NewOrderRepo() *OrderRepo {
-	return &OrderRepo{}
+// NewOrderRepository creates a new order repository.
+func NewOrderRepository(db *sql.DB, logger *slog.Logger) *OrderRepository {
+	return &OrderRepository{
+		db:     db,
+		logger: logger,
+	}
 }
 
-// ByID implements [service.OrderRepository].
-func (o *OrderRepo) ByID(ctx context.Context, id int) (*domain.Order, error) {
-	panic("unimplemented")
+// ByID returns order of shop ctx is bound to locked for update.
+func (r *OrderRepository) ByID(ctx context.Context, id int) (*domain.Order, error) {
+	db := conn(ctx, r.db)
+
+	p := domain.OrderFromDB{ID: id}
+	var status string
+
+	err := db.QueryRowContext(ctx,
+		`SELECT COALESCE(shop_id, 0), user_id, status, total, refunded, version,
+		        created_at, expires_at, paid_at, cancelled_at, expired_at,
+		        processing_at, ready_at, delivered_at, completed_at, refunded_at
+		 FROM orders WHERE id=$1 AND ($2 = 0 OR shop_id = $2)
+		 FOR UPDATE`,
+		id, shopFilter(ctx),
+	).Scan(
+		&p.ShopID, &p.UserID, &status, &p.Total, &p.Refunded, &p.Version,
+		&p.CreatedAt, &p.ExpiresAt, &p.PaidAt, &p.CancelledAt, &p.ExpiredAt,
+		&p.ProcessingAt, &p.ReadyAt, &p.DeliveredAt, &p.CompletedAt, &p.RefundedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrOrderNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to load order", "id", id, "err", err)
+		return nil, err
+	}
+	p.Status = domain.OrderStatus(status)
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT product_id, variant_id, quantity, unit_price
+		 FROM order_items WHERE order_id=$1
+		 ORDER BY position`,
+		id,
+	)
+	if err != nil {
+		r.logger.Error("failed to query order items", "id", id, "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID, variantID, quantity int
+		var unitPrice int64
+		if err := rows.Scan(&productID, &variantID, &quantity, &unitPrice); err != nil {
+			r.logger.Error("failed to scan order item", "id", id, "err", err)
+			return nil, err
+		}
+		p.Items = append(p.Items, domain.NewOrderItem(productID, variantID, quantity, unitPrice))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return domain.NewOrderFromDB(p), nil
 }
 
-// Create implements [service.OrderRepository].
-func (o *OrderRepo) Create(ctx context.Context, order *domain.Order) error {
-	panic("unimplemented")
+// Save creates order without id or updates existing one.
+//
+// New order must be saved within transaction of TxManager,
+// so it is never stored without its items.
+func (r *OrderRepository) Save(ctx context.Context, order *domain.Order) error {
+	if order.ID() == 0 {
+		return r.insert(ctx, order)
+	}
+
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE orders
+		 SET status=$1, refunded=$2, version=$3, expires_at=$4,
+		     paid_at=$5, cancelled_at=$6, expired_at=$7, processing_at=$8,
+		     ready_at=$9, delivered_at=$10, completed_at=$11, refunded_at=$12
+		 WHERE id=$13 AND ($14 = 0 OR shop_id = $14)`,
+		string(order.Status()), order.Refunded(), order.Version(), order.ExpiresAt(),
+		order.StatusChangedAt(domain.OrderStatusPaid),
+		order.StatusChangedAt(domain.OrderStatusCancelled),
+		order.StatusChangedAt(domain.OrderStatusExpired),
+		order.StatusChangedAt(domain.OrderStatusProcessing),
+		order.StatusChangedAt(domain.OrderStatusReady),
+		order.StatusChangedAt(domain.OrderStatusDelivered),
+		order.StatusChangedAt(domain.OrderStatusCompleted),
+		order.StatusChangedAt(domain.OrderStatusRefunded),
+		order.ID(), shopFilter(ctx),
+	)
+	if err != nil {
+		r.logger.Error("failed to update order", "id", order.ID(), "err", err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrOrderNotFound
+	}
+
+	return nil
 }
 
-// ListByCustomer implements [service.OrderRepository].
-func (o *OrderRepo) ListByCustomer(ctx context.Context, customerID int) ([]domain.Order, error) {
-	panic("unimplemented")
+func (r *OrderRepository) insert(ctx context.Context, order *domain.Order) error {
+	db := conn(ctx, r.db)
+
+	var id int
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO orders (shop_id, user_id, status, total, refunded, version, created_at, expires_at)
+		 VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		order.ShopID(), order.UserID(), string(order.Status()), order.Total(),
+		order.Refunded(), order.Version(), order.CreatedAt(), order.ExpiresAt(),
+	).Scan(&id)
+	if err != nil {
+		r.logger.Error("failed to insert order", "user_id", order.UserID(), "err", err)
+		return err
+	}
+
+	for i, item := range order.Items() {
+		_, err := db.ExecContext(ctx,
+			`INSERT INTO order_items (order_id, position, product_id, variant_id, quantity, unit_price)
+			 VALUES ($1, $2, $3, $4, $5, $6)`,
+			id, i, item.ProductID(), item.VariantID(), item.Quantity(), item.UnitPrice(),
+		)
+		if err != nil {
+			r.logger.Error("failed to insert order item", "id", id, "err", err)
+			return err
+		}
+	}
+
+	order.SetID(id)
+	return nil
 }
 
-// Update implements [service.OrderRepository].
-func (o *OrderRepo) Update(ctx context.Context, order *domain.Order) error {
-	panic("unimplemented")
+// OverdueIDs implements [service.OverdueOrderFinder].
+func (r *OrderRepository) OverdueIDs(ctx context.Context, now time.Time, afterID int, limit int) ([]int, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx,
+		`SELECT id FROM orders
+		 WHERE status=$1 AND expires_at <= $2 AND id > $3 AND ($4 = 0 OR shop_id = $4)
+		 ORDER BY id
+		 LIMIT NULLIF($5, 0)`,
+		string(domain.OrderStatusPending), now, afterID, shopFilter(ctx), limit,
+	)
+	if err != nil {
+		r.logger.Error("failed to query overdue orders", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
 }
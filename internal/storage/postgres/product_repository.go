@@ -9,8 +9,11 @@ import (
 	"time"
 
 	"botmanager/internal/domain"
+	"botmanager/internal/storage"
 )
 
+var _ storage.ProductRepository = (*ProductRepository)(nil)
+
 // ProductRepository represent product repository.
 type ProductRepository struct {
 	db     *sql.DB
@@ -42,9 +45,9 @@ func (r *ProductRepository) Save(ctx context.Context, p *domain.Product) error {
 	var productID int
 	if p.ID() == 0 {
 		err := tx.QueryRowContext(ctx,
-			`INSERT INTO products (category_id, name, description, image_path, version)
-		 	 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
-			p.CategoryID(), p.Name(), p.Description(), p.ImagePath(), p.Version(),
+			`INSERT INTO products (category_id, name, description, image_path, version, shop_id)
+		 	 VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0)) RETURNING id`,
+			p.CategoryID(), p.Name(), p.Description(), p.ImagePath(), p.Version(), p.ShopID(),
 		).Scan(&productID)
 		if err != nil {
 			tx.Rollback()
@@ -58,7 +61,7 @@ func (r *ProductRepository) Save(ctx context.Context, p *domain.Product) error {
 			ctx,
 			`UPDATE products
 		 	 SET category_id=$1, name=$2, description=$3, image_path=$4, version=$5
-		   WHERE id=$6 AND version=$7`,
+		   WHERE id=$6 AND version=$7 AND ($8 = 0 OR shop_id=$8)`,
 			p.CategoryID(),
 			p.Name(),
 			p.Description(),
@@ -66,6 +69,7 @@ func (r *ProductRepository) Save(ctx context.Context, p *domain.Product) error {
 			p.Version(),
 			p.ID(),
 			p.Version(),
+			shopFilter(ctx),
 		)
 		if err != nil {
 			tx.Rollback()
@@ -77,7 +81,7 @@ func (r *ProductRepository) Save(ctx context.Context, p *domain.Product) error {
 	}
 
 	// Insert/update variants
-	for _, v := range p.VariantForUpdate() {
+	for _, v := range p.VariantsForUpdate() {
 		if !v.IsActive() {
 			continue
 		}
@@ -128,16 +132,17 @@ func (r *ProductRepository) Save(ctx context.Context, p *domain.Product) error {
 // ByID load product and its variants.
 func (r *ProductRepository) ByID(ctx context.Context, id int) (*domain.Product, error) {
 	var (
-		categoryID  int
+		categoryID  sql.NullInt64
 		version     int
 		name        string
 		description string
 		imgPath     sql.NullString
+		shopID      int
 	)
 
 	row := r.db.QueryRowContext(ctx,
-		`SELECT id, category_id, name, description, image_path, version
-	 	 FROM products WHERE id=$1`, id)
+		`SELECT id, category_id, name, description, image_path, version, COALESCE(shop_id, 0)
+	 	 FROM products WHERE id=$1 AND ($2 = 0 OR shop_id=$2)`, id, shopFilter(ctx))
 	if err := row.Scan(
 		&id,
 		&categoryID,
@@ -145,6 +150,7 @@ func (r *ProductRepository) ByID(ctx context.Context, id int) (*domain.Product,
 		&description,
 		&imgPath,
 		&version,
+		&shopID,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrProductNotFound
@@ -158,6 +164,12 @@ func (r *ProductRepository) ByID(ctx context.Context, id int) (*domain.Product,
 		imgPtr = &imgPath.String
 	}
 
+	var categoryPtr *int
+	if categoryID.Valid {
+		category := int(categoryID.Int64)
+		categoryPtr = &category
+	}
+
 	variants, err := r.loadVariants(ctx, id)
 	if err != nil {
 		r.logger.Error("failed load variants", "err", err)
@@ -166,7 +178,7 @@ func (r *ProductRepository) ByID(ctx context.Context, id int) (*domain.Product,
 
 	product := domain.NewProductFromDB(
 		id,
-		categoryID,
+		categoryPtr,
 		name,
 		description,
 		imgPtr,
@@ -174,9 +186,69 @@ func (r *ProductRepository) ByID(ctx context.Context, id int) (*domain.Product,
 		variants,
 	)
 
+	if shopID != 0 {
+		if err := product.AssignShop(shopID); err != nil {
+			return nil, err
+		}
+	}
+
 	return product, nil
 }
 
+// ByVariantID returns product owning variant.
+func (r *ProductRepository) ByVariantID(ctx context.Context, variantID int) (*domain.Product, error) {
+	var productID int
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT product_id FROM product_variants WHERE id=$1`, variantID,
+	).Scan(&productID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrVariantNotFound
+		}
+		r.logger.Error("failed to load variant", "id", variantID, "err", err)
+		return nil, err
+	}
+
+	return r.ByID(ctx, productID)
+}
+
+// ListByCategory returns products of category ordered by name.
+func (r *ProductRepository) ListByCategory(ctx context.Context, categoryID int) ([]*domain.Product, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id FROM products
+		 WHERE category_id=$1 AND ($2 = 0 OR shop_id=$2)
+		 ORDER BY name, id`, categoryID, shopFilter(ctx))
+	if err != nil {
+		r.logger.Error("failed to query products", "category_id", categoryID, "err", err)
+		return nil, err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	products := make([]*domain.Product, 0, len(ids))
+	for _, id := range ids {
+		product, err := r.ByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
 func (r *ProductRepository) loadVariants(
 	ctx context.Context,
 	productID int,
@@ -217,7 +289,7 @@ func (r *ProductRepository) loadVariants(
 			id, packSize, districtID, price, archivedPtr,
 		)
 
-		variants = append(variants, v)
+		variants = append(variants, *v)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"botmanager/internal/storage"
+)
+
+var _ storage.SessionStore = (*SessionStore)(nil)
+
+// SessionStore implements storage.SessionStore for PostgreSQL.
+type SessionStore struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewSessionStore creates a new session store.
+func NewSessionStore(db *sql.DB, logger *slog.Logger) *SessionStore {
+	return &SessionStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Load returns not expired session of key.
+func (s *SessionStore) Load(ctx context.Context, key storage.SessionKey, now time.Time) (storage.SessionRecord, error) {
+	r := storage.SessionRecord{Key: key}
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT state, data, version, expires_at
+		FROM bot_sessions
+		WHERE bot_id=$1 AND chat_id=$2 AND expires_at > $3
+	`, key.BotID, key.ChatID, now).Scan(&r.State, &r.Data, &r.Version, &r.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.SessionRecord{}, storage.ErrSessionNotFound
+		}
+		s.logger.Error("failed to load session", "bot_id", key.BotID, "chat_id", key.ChatID, "err", err)
+		return storage.SessionRecord{}, err
+	}
+
+	return r, nil
+}
+
+// Save stores session if its version matches stored one.
+//
+// New session may replace only expired one.
+func (s *SessionStore) Save(ctx context.Context, r storage.SessionRecord, now time.Time) (int, error) {
+	var (
+		version int
+		err     error
+	)
+
+	if r.Version == 0 {
+		err = s.db.QueryRowContext(ctx, `
+			INSERT INTO bot_sessions (bot_id, chat_id, state, data, version, expires_at)
+			VALUES ($1, $2, $3, $4, 1, $5)
+			ON CONFLICT (bot_id, chat_id) DO UPDATE
+			SET state=EXCLUDED.state, data=EXCLUDED.data, version=1,
+				expires_at=EXCLUDED.expires_at, updated_at=NOW()
+			WHERE bot_sessions.expires_at <= $6
+			RETURNING version
+		`, r.Key.BotID, r.Key.ChatID, r.State, r.Data, r.ExpiresAt, now).Scan(&version)
+	} else {
+		err = s.db.QueryRowContext(ctx, `
+			UPDATE bot_sessions
+			SET state=$1, data=$2, version=version+1, expires_at=$3, updated_at=NOW()
+			WHERE bot_id=$4 AND chat_id=$5 AND version=$6 AND expires_at > $7
+			RETURNING version
+		`, r.State, r.Data, r.ExpiresAt, r.Key.BotID, r.Key.ChatID, r.Version, now).Scan(&version)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrSessionConflict
+		}
+		s.logger.Error("failed to save session", "bot_id", r.Key.BotID, "chat_id", r.Key.ChatID, "err", err)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// Delete removes session of key if its version matches stored one.
+//
+// Version 0 expects no session alive at now, expired one
+// is left to DeleteExpired.
+func (s *SessionStore) Delete(ctx context.Context, key storage.SessionKey, version int, now time.Time) error {
+	if version == 0 {
+		var alive bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM bot_sessions
+				WHERE bot_id=$1 AND chat_id=$2 AND expires_at > $3
+			)
+		`, key.BotID, key.ChatID, now).Scan(&alive)
+		if err != nil {
+			s.logger.Error("failed to check session", "bot_id", key.BotID, "chat_id", key.ChatID, "err", err)
+			return err
+		}
+		if alive {
+			return storage.ErrSessionConflict
+		}
+		return nil
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM bot_sessions
+		WHERE bot_id=$1 AND chat_id=$2 AND version=$3 AND expires_at > $4
+	`, key.BotID, key.ChatID, version, now)
+	if err != nil {
+		s.logger.Error("failed to delete session", "bot_id", key.BotID, "chat_id", key.ChatID, "err", err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return storage.ErrSessionConflict
+	}
+
+	return nil
+}
+
+// DeleteExpired removes sessions expired at now.
+func (s *SessionStore) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM bot_sessions WHERE expires_at <= $1`,
+		now,
+	)
+	if err != nil {
+		s.logger.Error("failed to delete expired sessions", "err", err)
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"botmanager/internal/domain"
+)
+
+// ShopRepository stores shops in postgres.
+type ShopRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewShopRepository creates a new shop repository.
+func NewShopRepository(db *sql.DB, logger *slog.Logger) *ShopRepository {
+	return &ShopRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Save creates shop without id or updates existing one.
+func (r *ShopRepository) Save(ctx context.Context, shop *domain.Shop) error {
+	if shop.ID() == 0 {
+		var id int
+		err := r.db.QueryRowContext(ctx,
+			`INSERT INTO shops (name, is_enabled, version, created_at, updated_at)
+			 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			shop.Name(), shop.IsEnabled(), shop.Version(), shop.CreatedAt(), shop.UpdatedAt(),
+		).Scan(&id)
+		if err != nil {
+			r.logger.Error("failed to insert shop", "err", err)
+			return err
+		}
+
+		shop.SetID(id)
+		return nil
+	}
+
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE shops
+		 SET name=$1, is_enabled=$2, version=$3, updated_at=$4
+		 WHERE id=$5`,
+		shop.Name(), shop.IsEnabled(), shop.Version(), shop.UpdatedAt(), shop.ID(),
+	)
+	if err != nil {
+		r.logger.Error("failed to update shop", "id", shop.ID(), "err", err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrShopNotFound
+	}
+
+	return nil
+}
+
+// ByID returns shop by id.
+func (r *ShopRepository) ByID(ctx context.Context, id int) (*domain.Shop, error) {
+	var (
+		name                 string
+		isEnabled            bool
+		version              int
+		createdAt, updatedAt time.Time
+	)
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT name, is_enabled, version, created_at, updated_at
+		 FROM shops WHERE id=$1`,
+		id,
+	).Scan(&name, &isEnabled, &version, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrShopNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get shop", "id", id, "err", err)
+		return nil, err
+	}
+
+	return domain.NewShopFromDB(id, name, isEnabled, version, createdAt, updatedAt), nil
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"botmanager/internal/domain"
+)
+
+// StockRepository stores stocks of variants in postgres.
+//
+// Within transaction of TxManager stock rows are locked
+// until commit, see ByVariantID.
+type StockRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewStockRepository creates a new stock repository.
+func NewStockRepository(db *sql.DB, logger *slog.Logger) *StockRepository {
+	return &StockRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ByVariantID returns stock of variant locked for update.
+func (r *StockRepository) ByVariantID(ctx context.Context, variantID int) (*domain.Stock, error) {
+	var id, warehouseID, quantity, reserved int
+
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`SELECT id, warehouse_id, quantity, reserved
+		 FROM stocks WHERE variant_id=$1
+		 FOR UPDATE`,
+		variantID,
+	).Scan(&id, &warehouseID, &quantity, &reserved)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrStockNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to load stock", "variant_id", variantID, "err", err)
+		return nil, err
+	}
+
+	return domain.NewStockFromDB(id, warehouseID, variantID, quantity, reserved), nil
+}
+
+// Save creates stock without id or updates existing one.
+func (r *StockRepository) Save(ctx context.Context, stock *domain.Stock) error {
+	db := conn(ctx, r.db)
+
+	if stock.ID() == 0 {
+		var id int
+		err := db.QueryRowContext(ctx,
+			`INSERT INTO stocks (warehouse_id, variant_id, quantity, reserved, version)
+			 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			stock.WarehouseID(), stock.VariantID(), stock.Quantity(), stock.Reserved(), stock.Version(),
+		).Scan(&id)
+		if err != nil {
+			r.logger.Error("failed to insert stock", "variant_id", stock.VariantID(), "err", err)
+			return err
+		}
+
+		stock.SetID(id)
+		return nil
+	}
+
+	res, err := db.ExecContext(ctx,
+		`UPDATE stocks
+		 SET quantity=$1, reserved=$2, version=version+1
+		 WHERE id=$3`,
+		stock.Quantity(), stock.Reserved(), stock.ID(),
+	)
+	if err != nil {
+		r.logger.Error("failed to update stock", "id", stock.ID(), "err", err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrStockNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"context"
+
+	"botmanager/internal/tenant"
+)
+
+// shopFilter returns shop ctx is bound to or 0 for unbound ctx.
+//
+// Queries of shop data filter rows with "($n = 0 OR shop_id = $n)".
+func shopFilter(ctx context.Context) int {
+	shopID, _ := tenant.ShopID(ctx)
+	return shopID
+}
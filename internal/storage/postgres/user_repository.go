@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/service"
+)
+
+var _ service.UserRepository = (*UserRepository)(nil)
+
+// selectUsers selects users of shop $1.
+const selectUsers = `SELECT id, COALESCE(shop_id, 0), tg_id, tg_name,
+	       COALESCE(email, ''), COALESCE(password_hash, ''), role, balance, is_enable,
+	       admin_access_expires_at, created_at, updated_at
+	FROM users
+	WHERE ($1 = 0 OR shop_id = $1)`
+
+// UserRepository stores users in postgres.
+//
+// Within transaction of TxManager user rows are locked
+// until commit, so balance is never changed concurrently.
+type UserRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewUserRepository creates a new user repository.
+func NewUserRepository(db *sql.DB, logger *slog.Logger) *UserRepository {
+	return &UserRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ByID returns user locked for update.
+func (r *UserRepository) ByID(ctx context.Context, id int) (*domain.User, error) {
+	return r.one(ctx, selectUsers+` AND id = $2 FOR UPDATE`, id)
+}
+
+// ByTelegramID returns user with Telegram id locked for update.
+func (r *UserRepository) ByTelegramID(ctx context.Context, tgID int64) (*domain.User, error) {
+	return r.one(ctx, selectUsers+` AND tg_id = $2 FOR UPDATE`, tgID)
+}
+
+// Save creates user without id or updates existing one.
+func (r *UserRepository) Save(ctx context.Context, user *domain.User) error {
+	db := conn(ctx, r.db)
+
+	var tgID *int64
+	if id, ok := user.TelegramID(); ok {
+		tgID = &id
+	}
+
+	var tgName *string
+	if name, ok := user.TelegramName(); ok {
+		tgName = &name
+	}
+
+	if user.ID() == 0 {
+		var id int
+		err := db.QueryRowContext(ctx,
+			`INSERT INTO users (shop_id, tg_id, tg_name, email, password_hash, role,
+			                    balance, is_enable, admin_access_expires_at, created_at, updated_at)
+			 VALUES (NULLIF($1, 0), $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10, $11)
+			 RETURNING id`,
+			user.ShopID(), tgID, tgName, user.Email(), user.PasswordHash(), string(user.Role()),
+			user.Balance(), user.IsEnabled(), user.AdminAccessExpiresAt(), user.CreatedAt(), user.UpdatedAt(),
+		).Scan(&id)
+		if err != nil {
+			r.logger.Error("failed to insert user", "err", err)
+			return err
+		}
+
+		user.SetID(id)
+		return nil
+	}
+
+	res, err := db.ExecContext(ctx,
+		`UPDATE users
+		 SET tg_name=$1, role=$2, balance=$3, is_enable=$4,
+		     admin_access_expires_at=$5, updated_at=$6
+		 WHERE id=$7 AND ($8 = 0 OR shop_id = $8)`,
+		tgName, string(user.Role()), user.Balance(), user.IsEnabled(),
+		user.AdminAccessExpiresAt(), user.UpdatedAt(), user.ID(), shopFilter(ctx),
+	)
+	if err != nil {
+		r.logger.Error("failed to update user", "id", user.ID(), "err", err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) one(ctx context.Context, query string, arg any) (*domain.User, error) {
+	var (
+		p    domain.UserFromDB
+		role string
+	)
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, shopFilter(ctx), arg).Scan(
+		&p.ID, &p.ShopID, &p.TgID, &p.TgName,
+		&p.Email, &p.PasswordHash, &role, &p.Balance, &p.IsEnabled,
+		&p.AdminAccessExpiresAt, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to load user", "err", err)
+		return nil, err
+	}
+	p.Role = domain.Role(role)
+
+	return domain.NewUserFromDB(p), nil
+}
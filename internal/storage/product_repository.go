@@ -0,0 +1,12 @@
+package storage
+
+import (
+	"context"
+
+	"botmanager/internal/domain"
+)
+
+type ProductRepository interface {
+	ByID(ctx context.Context, id int) (*domain.Product, error)
+	ListByCategory(ctx context.Context, categoryID int) ([]*domain.Product, error)
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ErrOutOfScope is returned for catalog entries hidden by Scope.
+var ErrOutOfScope = errors.New("out of storefront scope")
+
+// Scope limits catalog queries to a subset of cities and categories.
+//
+// Empty CityIDs or CategoryIDs means all cities or categories.
+type Scope struct {
+	CityIDs     []int
+	CategoryIDs []int
+}
+
+// AllowsCity reports whether city is visible within scope.
+func (s Scope) AllowsCity(id int) bool {
+	return len(s.CityIDs) == 0 || slices.Contains(s.CityIDs, id)
+}
+
+// AllowsCategory reports whether category is visible within scope.
+func (s Scope) AllowsCategory(id int) bool {
+	return len(s.CategoryIDs) == 0 || slices.Contains(s.CategoryIDs, id)
+}
+
+type scopeKey struct{}
+
+// WithScope returns ctx limiting queries of scoped repositories.
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// ScopeFromContext returns scope stored by WithScope.
+// Context without scope is not limited.
+func ScopeFromContext(ctx context.Context) Scope {
+	s, _ := ctx.Value(scopeKey{}).(Scope)
+	return s
+}
+
+// filter returns items allowed by keep.
+func filter[T any](items []T, keep func(*T) bool) []T {
+	result := make([]T, 0, len(items))
+	for i := range items {
+		if keep(&items[i]) {
+			result = append(result, items[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,298 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/tenant"
+)
+
+// Scoped repositories apply Scope and shop of query context to catalog reads,
+// see WithScope and tenant.WithShop. Writes are passed through unchanged.
+//
+// Entries hidden by scope or belonging to another shop are missing from lists,
+// ByID returns ErrOutOfScope for them.
+
+var (
+	_ CityRepository     = (*ScopedCityRepository)(nil)
+	_ DistrictRepository = (*ScopedDistrictRepository)(nil)
+	_ CategoryRepository = (*ScopedCategoryRepository)(nil)
+	_ ProductRepository  = (*ScopedProductRepository)(nil)
+)
+
+// ScopedCityRepository hides cities out of scope and cities of other shops.
+type ScopedCityRepository struct {
+	CityRepository
+}
+
+// NewScopedCityRepository wraps repo with scope filtering.
+func NewScopedCityRepository(repo CityRepository) *ScopedCityRepository {
+	if repo == nil {
+		panic("storage: CityRepository is nil")
+	}
+
+	return &ScopedCityRepository{CityRepository: repo}
+}
+
+func (r *ScopedCityRepository) List(ctx context.Context) ([]domain.City, error) {
+	cities, err := r.CityRepository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := ScopeFromContext(ctx)
+	return filter(cities, func(c *domain.City) bool {
+		return scope.AllowsCity(c.ID()) && tenant.Allows(ctx, c.ShopID())
+	}), nil
+}
+
+func (r *ScopedCityRepository) ByID(ctx context.Context, id int) (*domain.City, error) {
+	if !ScopeFromContext(ctx).AllowsCity(id) {
+		return nil, fmt.Errorf("city %d: %w", id, ErrOutOfScope)
+	}
+
+	city, err := r.CityRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tenant.Allows(ctx, city.ShopID()) {
+		return nil, fmt.Errorf("city %d: %w", id, ErrOutOfScope)
+	}
+
+	return city, nil
+}
+
+// ScopedDistrictRepository hides districts of cities out of scope
+// and districts of cities of other shops.
+type ScopedDistrictRepository struct {
+	DistrictRepository
+	cities CityRepository
+}
+
+// NewScopedDistrictRepository wraps repo with scope filtering,
+// cities resolve shop owning city of district.
+func NewScopedDistrictRepository(repo DistrictRepository, cities CityRepository) *ScopedDistrictRepository {
+	if repo == nil {
+		panic("storage: DistrictRepository is nil")
+	}
+
+	if cities == nil {
+		panic("storage: CityRepository is nil")
+	}
+
+	return &ScopedDistrictRepository{DistrictRepository: repo, cities: cities}
+}
+
+func (r *ScopedDistrictRepository) List(ctx context.Context) ([]domain.District, error) {
+	districts, err := r.DistrictRepository.List(ctx)
+	return r.filter(ctx, districts, err)
+}
+
+func (r *ScopedDistrictRepository) ListByCity(ctx context.Context, cityID int) ([]domain.District, error) {
+	if !ScopeFromContext(ctx).AllowsCity(cityID) {
+		return []domain.District{}, nil
+	}
+
+	districts, err := r.DistrictRepository.ListByCity(ctx, cityID)
+	return r.filter(ctx, districts, err)
+}
+
+func (r *ScopedDistrictRepository) ListByCategory(ctx context.Context, categoryID int) ([]domain.District, error) {
+	if !ScopeFromContext(ctx).AllowsCategory(categoryID) {
+		return []domain.District{}, nil
+	}
+
+	districts, err := r.DistrictRepository.ListByCategory(ctx, categoryID)
+	return r.filter(ctx, districts, err)
+}
+
+func (r *ScopedDistrictRepository) ListByProduct(ctx context.Context, productID int) ([]domain.District, error) {
+	districts, err := r.DistrictRepository.ListByProduct(ctx, productID)
+	return r.filter(ctx, districts, err)
+}
+
+func (r *ScopedDistrictRepository) ByID(ctx context.Context, id int) (*domain.District, error) {
+	district, err := r.DistrictRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ScopeFromContext(ctx).AllowsCity(district.CityID()) {
+		return nil, fmt.Errorf("district %d: %w", id, ErrOutOfScope)
+	}
+
+	if _, ok := tenant.ShopID(ctx); !ok {
+		return district, nil
+	}
+
+	// shop of district is shop of its city,
+	// repository may hide city of other shop itself
+	city, err := r.cities.ByID(ctx, district.CityID())
+	switch {
+	case errors.Is(err, domain.ErrCityNotFound):
+		return nil, fmt.Errorf("district %d: %w", id, ErrOutOfScope)
+	case err != nil:
+		return nil, err
+	case !tenant.Allows(ctx, city.ShopID()):
+		return nil, fmt.Errorf("district %d: %w", id, ErrOutOfScope)
+	}
+
+	return district, nil
+}
+
+func (r *ScopedDistrictRepository) filter(ctx context.Context, districts []domain.District, err error) ([]domain.District, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	scope := ScopeFromContext(ctx)
+	districts = filter(districts, func(d *domain.District) bool {
+		return scope.AllowsCity(d.CityID())
+	})
+
+	if _, ok := tenant.ShopID(ctx); !ok {
+		return districts, nil
+	}
+
+	// shop of district is shop of its city
+	cities, err := r.cities.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[int]bool, len(cities))
+	for i := range cities {
+		allowed[cities[i].ID()] = tenant.Allows(ctx, cities[i].ShopID())
+	}
+
+	return filter(districts, func(d *domain.District) bool {
+		return allowed[d.CityID()]
+	}), nil
+}
+
+// ScopedCategoryRepository hides categories out of scope,
+// categories of cities out of scope and categories of other shops.
+type ScopedCategoryRepository struct {
+	CategoryRepository
+	districts DistrictRepository
+}
+
+// NewScopedCategoryRepository wraps repo with scope filtering,
+// districts resolve city of district listed by ListByDistrict.
+func NewScopedCategoryRepository(repo CategoryRepository, districts DistrictRepository) *ScopedCategoryRepository {
+	if repo == nil {
+		panic("storage: CategoryRepository is nil")
+	}
+
+	if districts == nil {
+		panic("storage: DistrictRepository is nil")
+	}
+
+	return &ScopedCategoryRepository{CategoryRepository: repo, districts: districts}
+}
+
+func (r *ScopedCategoryRepository) List(ctx context.Context) ([]domain.Category, error) {
+	categories, err := r.CategoryRepository.List(ctx)
+	return r.filter(ctx, categories, err)
+}
+
+func (r *ScopedCategoryRepository) ListByCity(ctx context.Context, cityID int) ([]domain.Category, error) {
+	if !ScopeFromContext(ctx).AllowsCity(cityID) {
+		return []domain.Category{}, nil
+	}
+
+	categories, err := r.CategoryRepository.ListByCity(ctx, cityID)
+	return r.filter(ctx, categories, err)
+}
+
+func (r *ScopedCategoryRepository) ListByDistrict(ctx context.Context, districtID int) ([]domain.Category, error) {
+	district, err := r.districts.ByID(ctx, districtID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ScopeFromContext(ctx).AllowsCity(district.CityID()) {
+		return []domain.Category{}, nil
+	}
+
+	categories, err := r.CategoryRepository.ListByDistrict(ctx, districtID)
+	return r.filter(ctx, categories, err)
+}
+
+func (r *ScopedCategoryRepository) ByID(ctx context.Context, id int) (*domain.Category, error) {
+	if !ScopeFromContext(ctx).AllowsCategory(id) {
+		return nil, fmt.Errorf("category %d: %w", id, ErrOutOfScope)
+	}
+
+	category, err := r.CategoryRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tenant.Allows(ctx, category.ShopID()) {
+		return nil, fmt.Errorf("category %d: %w", id, ErrOutOfScope)
+	}
+
+	return category, nil
+}
+
+func (r *ScopedCategoryRepository) filter(ctx context.Context, categories []domain.Category, err error) ([]domain.Category, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	scope := ScopeFromContext(ctx)
+	return filter(categories, func(c *domain.Category) bool {
+		return scope.AllowsCategory(c.ID()) && tenant.Allows(ctx, c.ShopID())
+	}), nil
+}
+
+// ScopedProductRepository hides products of categories out of scope
+// and products of other shops.
+type ScopedProductRepository struct {
+	ProductRepository
+}
+
+// NewScopedProductRepository wraps repo with scope filtering.
+func NewScopedProductRepository(repo ProductRepository) *ScopedProductRepository {
+	if repo == nil {
+		panic("storage: ProductRepository is nil")
+	}
+
+	return &ScopedProductRepository{ProductRepository: repo}
+}
+
+func (r *ScopedProductRepository) ByID(ctx context.Context, id int) (*domain.Product, error) {
+	product, err := r.ProductRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID := product.CategoryID()
+	scope := ScopeFromContext(ctx)
+	if categoryID != nil && !scope.AllowsCategory(*categoryID) ||
+		categoryID == nil && len(scope.CategoryIDs) > 0 ||
+		!tenant.Allows(ctx, product.ShopID()) {
+		return nil, fmt.Errorf("product %d: %w", id, ErrOutOfScope)
+	}
+
+	return product, nil
+}
+
+func (r *ScopedProductRepository) ListByCategory(ctx context.Context, categoryID int) ([]*domain.Product, error) {
+	if !ScopeFromContext(ctx).AllowsCategory(categoryID) {
+		return []*domain.Product{}, nil
+	}
+
+	products, err := r.ProductRepository.ListByCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter(products, func(p **domain.Product) bool {
+		return tenant.Allows(ctx, (*p).ShopID())
+	}), nil
+}
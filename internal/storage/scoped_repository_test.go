@@ -0,0 +1,442 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/storage"
+	"botmanager/internal/tenant"
+)
+
+// Fixture: shop 1 owns cities 1, 2, categories 100, 200 and
+// products 1000, 2000, 4000; shop 2 owns city 3, category 300
+// and product 3000. District N0 lies in city N.
+
+// ---- fake repositories ----
+
+type fakeCities []*domain.City
+
+func (f fakeCities) List(ctx context.Context) ([]domain.City, error) {
+	result := make([]domain.City, 0, len(f))
+	for _, c := range f {
+		result = append(result, *c)
+	}
+	return result, nil
+}
+
+func (f fakeCities) ByID(ctx context.Context, id int) (*domain.City, error) {
+	for _, c := range f {
+		if c.ID() == id {
+			return c, nil
+		}
+	}
+	return nil, domain.ErrCityNotFound
+}
+
+func (f fakeCities) Create(ctx context.Context, city *domain.City) error { return nil }
+func (f fakeCities) Update(ctx context.Context, city *domain.City) error { return nil }
+func (f fakeCities) Delete(ctx context.Context, id int) error            { return nil }
+
+type fakeDistricts []domain.District
+
+func (f fakeDistricts) List(ctx context.Context) ([]domain.District, error) { return f, nil }
+
+func (f fakeDistricts) ListByCity(ctx context.Context, cityID int) ([]domain.District, error) {
+	var result []domain.District
+	for _, d := range f {
+		if d.CityID() == cityID {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// ListByCategory and ListByProduct return every district,
+// so that only filtering of wrapper is seen.
+func (f fakeDistricts) ListByCategory(ctx context.Context, categoryID int) ([]domain.District, error) {
+	return f, nil
+}
+
+func (f fakeDistricts) ListByProduct(ctx context.Context, productID int) ([]domain.District, error) {
+	return f, nil
+}
+
+func (f fakeDistricts) ByID(ctx context.Context, id int) (*domain.District, error) {
+	for i := range f {
+		if f[i].ID() == id {
+			return &f[i], nil
+		}
+	}
+	return nil, domain.ErrDistrictNotFound
+}
+
+func (f fakeDistricts) Create(ctx context.Context, district *domain.District) error { return nil }
+func (f fakeDistricts) Update(ctx context.Context, district *domain.District) error { return nil }
+func (f fakeDistricts) DeleteByID(ctx context.Context, id int) error                { return nil }
+
+type fakeCategories []*domain.Category
+
+// List, ListByCity and ListByDistrict return every category,
+// so that only filtering of wrapper is seen.
+func (f fakeCategories) List(ctx context.Context) ([]domain.Category, error) {
+	result := make([]domain.Category, 0, len(f))
+	for _, c := range f {
+		result = append(result, *c)
+	}
+	return result, nil
+}
+
+func (f fakeCategories) ListByCity(ctx context.Context, cityID int) ([]domain.Category, error) {
+	return f.List(ctx)
+}
+
+func (f fakeCategories) ListByDistrict(ctx context.Context, districtID int) ([]domain.Category, error) {
+	return f.List(ctx)
+}
+
+func (f fakeCategories) ByID(ctx context.Context, id int) (*domain.Category, error) {
+	for _, c := range f {
+		if c.ID() == id {
+			return c, nil
+		}
+	}
+	return nil, domain.ErrCategoryNotFound
+}
+
+func (f fakeCategories) Create(ctx context.Context, category *domain.Category) error { return nil }
+func (f fakeCategories) Update(ctx context.Context, category *domain.Category) error { return nil }
+func (f fakeCategories) DeleteByID(ctx context.Context, id int) error                { return nil }
+
+type fakeProducts []*domain.Product
+
+func (f fakeProducts) ByID(ctx context.Context, id int) (*domain.Product, error) {
+	for _, p := range f {
+		if p.ID() == id {
+			return p, nil
+		}
+	}
+	return nil, domain.ErrProductNotFound
+}
+
+func (f fakeProducts) ListByCategory(ctx context.Context, categoryID int) ([]*domain.Product, error) {
+	var result []*domain.Product
+	for _, p := range f {
+		if id := p.CategoryID(); id != nil && *id == categoryID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// ---- fixture ----
+
+func newCity(id, shopID int) *domain.City {
+	c := domain.NewCityFromDB(id, "city")
+	_ = c.AssignShop(shopID)
+	return c
+}
+
+func newCategory(id, shopID int) *domain.Category {
+	c := domain.NewCategoryFromDB(id, "category", "")
+	_ = c.AssignShop(shopID)
+	return c
+}
+
+func newProduct(id int, categoryID *int, shopID int) *domain.Product {
+	p := domain.NewProductFromDB(id, categoryID, "product", "", nil, 1, nil)
+	_ = p.AssignShop(shopID)
+	return p
+}
+
+var (
+	cities = fakeCities{newCity(1, 1), newCity(2, 1), newCity(3, 2)}
+
+	districts = fakeDistricts{
+		*domain.NewDistrictFromDB(10, 1, "district"),
+		*domain.NewDistrictFromDB(20, 2, "district"),
+		*domain.NewDistrictFromDB(30, 3, "district"),
+	}
+
+	categories = fakeCategories{newCategory(100, 1), newCategory(200, 1), newCategory(300, 2)}
+
+	products = fakeProducts{
+		newProduct(1000, ptr(100), 1),
+		newProduct(2000, ptr(200), 1),
+		newProduct(3000, ptr(300), 2),
+		newProduct(4000, nil, 1),
+	}
+)
+
+func ptr(v int) *int { return &v }
+
+// scoped returns context limited by scope and bound to shop,
+// zero shop leaves it unbound.
+func scoped(scope storage.Scope, shopID int) context.Context {
+	return tenant.WithShop(storage.WithScope(context.Background(), scope), shopID)
+}
+
+func ids[T any](items []T, id func(T) int) []int {
+	result := make([]int, 0, len(items))
+	for _, item := range items {
+		result = append(result, id(item))
+	}
+	return result
+}
+
+func checkIDs(t *testing.T, got []int, err error, want []int) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func checkByID(t *testing.T, err error, wantErr error) {
+	t.Helper()
+
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected error %v, got %v", wantErr, err)
+	}
+}
+
+// ---- tests ----
+
+func TestScopedCityRepository(t *testing.T) {
+	repo := storage.NewScopedCityRepository(cities)
+	cityID := func(c domain.City) int { return c.ID() }
+
+	listTests := []struct {
+		name string
+		ctx  context.Context
+		want []int
+	}{
+		{"unlimited", scoped(storage.Scope{}, 0), []int{1, 2, 3}},
+		{"scope", scoped(storage.Scope{CityIDs: []int{1, 3}}, 0), []int{1, 3}},
+		{"shop", scoped(storage.Scope{}, 2), []int{3}},
+		{"scope and shop", scoped(storage.Scope{CityIDs: []int{1, 3}}, 1), []int{1}},
+	}
+	for _, tt := range listTests {
+		t.Run("List "+tt.name, func(t *testing.T) {
+			got, err := repo.List(tt.ctx)
+			checkIDs(t, ids(got, cityID), err, tt.want)
+		})
+	}
+
+	byIDTests := []struct {
+		name    string
+		ctx     context.Context
+		id      int
+		wantErr error
+	}{
+		{"visible", scoped(storage.Scope{CityIDs: []int{1}}, 1), 1, nil},
+		{"out of scope", scoped(storage.Scope{CityIDs: []int{1}}, 0), 2, storage.ErrOutOfScope},
+		{"other shop", scoped(storage.Scope{}, 1), 3, storage.ErrOutOfScope},
+		{"missing", scoped(storage.Scope{}, 0), 9, domain.ErrCityNotFound},
+	}
+	for _, tt := range byIDTests {
+		t.Run("ByID "+tt.name, func(t *testing.T) {
+			_, err := repo.ByID(tt.ctx, tt.id)
+			checkByID(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestScopedDistrictRepository(t *testing.T) {
+	repo := storage.NewScopedDistrictRepository(districts, cities)
+	districtID := func(d domain.District) int { return d.ID() }
+
+	listTests := []struct {
+		name string
+		list func(context.Context) ([]domain.District, error)
+		ctx  context.Context
+		want []int
+	}{
+		{"List unlimited", repo.List, scoped(storage.Scope{}, 0), []int{10, 20, 30}},
+		{"List scope", repo.List, scoped(storage.Scope{CityIDs: []int{1}}, 0), []int{10}},
+		{"List shop", repo.List, scoped(storage.Scope{}, 1), []int{10, 20}},
+		{
+			"ListByCity other shop",
+			func(ctx context.Context) ([]domain.District, error) { return repo.ListByCity(ctx, 3) },
+			scoped(storage.Scope{}, 1),
+			[]int{},
+		},
+		{
+			"ListByCity in scope",
+			func(ctx context.Context) ([]domain.District, error) { return repo.ListByCity(ctx, 1) },
+			scoped(storage.Scope{CityIDs: []int{1}}, 0),
+			[]int{10},
+		},
+		{
+			"ListByCity out of scope",
+			func(ctx context.Context) ([]domain.District, error) { return repo.ListByCity(ctx, 2) },
+			scoped(storage.Scope{CityIDs: []int{1}}, 0),
+			[]int{},
+		},
+		{
+			"ListByCategory scope",
+			func(ctx context.Context) ([]domain.District, error) { return repo.ListByCategory(ctx, 100) },
+			scoped(storage.Scope{CityIDs: []int{2}, CategoryIDs: []int{100}}, 0),
+			[]int{20},
+		},
+		{
+			"ListByCategory out of scope",
+			func(ctx context.Context) ([]domain.District, error) { return repo.ListByCategory(ctx, 200) },
+			scoped(storage.Scope{CategoryIDs: []int{100}}, 0),
+			[]int{},
+		},
+		{
+			"ListByProduct scope",
+			func(ctx context.Context) ([]domain.District, error) { return repo.ListByProduct(ctx, 1000) },
+			scoped(storage.Scope{CityIDs: []int{1, 3}}, 0),
+			[]int{10, 30},
+		},
+		{
+			"ListByProduct scope and shop",
+			func(ctx context.Context) ([]domain.District, error) { return repo.ListByProduct(ctx, 1000) },
+			scoped(storage.Scope{CityIDs: []int{1, 3}}, 2),
+			[]int{30},
+		},
+	}
+	for _, tt := range listTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.list(tt.ctx)
+			checkIDs(t, ids(got, districtID), err, tt.want)
+		})
+	}
+
+	byIDTests := []struct {
+		name    string
+		ctx     context.Context
+		id      int
+		wantErr error
+	}{
+		{"visible", scoped(storage.Scope{CityIDs: []int{1}}, 0), 10, nil},
+		{"visible in shop", scoped(storage.Scope{}, 2), 30, nil},
+		{"out of scope", scoped(storage.Scope{CityIDs: []int{1}}, 0), 20, storage.ErrOutOfScope},
+		{"other shop", scoped(storage.Scope{}, 1), 30, storage.ErrOutOfScope},
+		{"missing", scoped(storage.Scope{}, 0), 90, domain.ErrDistrictNotFound},
+	}
+	for _, tt := range byIDTests {
+		t.Run("ByID "+tt.name, func(t *testing.T) {
+			_, err := repo.ByID(tt.ctx, tt.id)
+			checkByID(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestScopedCategoryRepository(t *testing.T) {
+	repo := storage.NewScopedCategoryRepository(categories, districts)
+	categoryID := func(c domain.Category) int { return c.ID() }
+
+	listTests := []struct {
+		name string
+		list func(context.Context) ([]domain.Category, error)
+		ctx  context.Context
+		want []int
+	}{
+		{"List unlimited", repo.List, scoped(storage.Scope{}, 0), []int{100, 200, 300}},
+		{"List scope", repo.List, scoped(storage.Scope{CategoryIDs: []int{100, 300}}, 0), []int{100, 300}},
+		{"List shop", repo.List, scoped(storage.Scope{}, 2), []int{300}},
+		{
+			"ListByCity in scope",
+			func(ctx context.Context) ([]domain.Category, error) { return repo.ListByCity(ctx, 1) },
+			scoped(storage.Scope{CityIDs: []int{1}}, 1),
+			[]int{100, 200},
+		},
+		{
+			"ListByCity out of scope",
+			func(ctx context.Context) ([]domain.Category, error) { return repo.ListByCity(ctx, 2) },
+			scoped(storage.Scope{CityIDs: []int{1}}, 0),
+			[]int{},
+		},
+		{
+			"ListByDistrict in scope",
+			func(ctx context.Context) ([]domain.Category, error) { return repo.ListByDistrict(ctx, 10) },
+			scoped(storage.Scope{CityIDs: []int{1}, CategoryIDs: []int{100, 300}}, 1),
+			[]int{100},
+		},
+		{
+			"ListByDistrict of city out of scope",
+			func(ctx context.Context) ([]domain.Category, error) { return repo.ListByDistrict(ctx, 20) },
+			scoped(storage.Scope{CityIDs: []int{1}}, 0),
+			[]int{},
+		},
+	}
+	for _, tt := range listTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.list(tt.ctx)
+			checkIDs(t, ids(got, categoryID), err, tt.want)
+		})
+	}
+
+	t.Run("ListByDistrict missing", func(t *testing.T) {
+		_, err := repo.ListByDistrict(scoped(storage.Scope{}, 0), 90)
+		checkByID(t, err, domain.ErrDistrictNotFound)
+	})
+
+	byIDTests := []struct {
+		name    string
+		ctx     context.Context
+		id      int
+		wantErr error
+	}{
+		{"visible", scoped(storage.Scope{CategoryIDs: []int{100}}, 1), 100, nil},
+		{"out of scope", scoped(storage.Scope{CategoryIDs: []int{100}}, 0), 200, storage.ErrOutOfScope},
+		{"other shop", scoped(storage.Scope{}, 1), 300, storage.ErrOutOfScope},
+		{"missing", scoped(storage.Scope{}, 0), 900, domain.ErrCategoryNotFound},
+	}
+	for _, tt := range byIDTests {
+		t.Run("ByID "+tt.name, func(t *testing.T) {
+			_, err := repo.ByID(tt.ctx, tt.id)
+			checkByID(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestScopedProductRepository(t *testing.T) {
+	repo := storage.NewScopedProductRepository(products)
+	productID := func(p *domain.Product) int { return p.ID() }
+
+	listTests := []struct {
+		name       string
+		ctx        context.Context
+		categoryID int
+		want       []int
+	}{
+		{"unlimited", scoped(storage.Scope{}, 0), 100, []int{1000}},
+		{"category out of scope", scoped(storage.Scope{CategoryIDs: []int{100}}, 0), 200, []int{}},
+		{"other shop", scoped(storage.Scope{}, 1), 300, []int{}},
+	}
+	for _, tt := range listTests {
+		t.Run("ListByCategory "+tt.name, func(t *testing.T) {
+			got, err := repo.ListByCategory(tt.ctx, tt.categoryID)
+			checkIDs(t, ids(got, productID), err, tt.want)
+		})
+	}
+
+	byIDTests := []struct {
+		name    string
+		ctx     context.Context
+		id      int
+		wantErr error
+	}{
+		{"visible", scoped(storage.Scope{CategoryIDs: []int{100}}, 1), 1000, nil},
+		{"category out of scope", scoped(storage.Scope{CategoryIDs: []int{100}}, 0), 2000, storage.ErrOutOfScope},
+		{"uncategorized in limited scope", scoped(storage.Scope{CategoryIDs: []int{100}}, 0), 4000, storage.ErrOutOfScope},
+		{"uncategorized in unlimited scope", scoped(storage.Scope{}, 1), 4000, nil},
+		{"other shop", scoped(storage.Scope{}, 1), 3000, storage.ErrOutOfScope},
+		{"missing", scoped(storage.Scope{}, 0), 9000, domain.ErrProductNotFound},
+	}
+	for _, tt := range byIDTests {
+		t.Run("ByID "+tt.name, func(t *testing.T) {
+			_, err := repo.ByID(tt.ctx, tt.id)
+			checkByID(t, err, tt.wantErr)
+		})
+	}
+}
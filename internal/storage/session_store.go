@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionConflict is returned when session was changed
+	// by another update since it was loaded.
+	ErrSessionConflict = errors.New("session version conflict")
+)
+
+// SessionKey identifies conversation of a chat with a bot.
+type SessionKey struct {
+	BotID  string
+	ChatID int64
+}
+
+// SessionRecord is a stored conversation state.
+type SessionRecord struct {
+	Key   SessionKey
+	State string
+	Data  []byte
+	// Version is 0 for sessions that were never saved
+	// and is incremented by SessionStore on every save.
+	Version   int
+	ExpiresAt time.Time
+}
+
+// Expired reports whether record is expired at now.
+func (r SessionRecord) Expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && !now.Before(r.ExpiresAt)
+}
+
+// SessionStore persists session records with optimistic versioning.
+type SessionStore interface {
+	// Load returns record of key.
+	// Returns ErrSessionNotFound if there is no record or it is expired at now.
+	Load(ctx context.Context, key SessionKey, now time.Time) (SessionRecord, error)
+
+	// Save stores record if its Version matches stored one
+	// (0 for a new or expired record) and returns the new version.
+	// Returns ErrSessionConflict otherwise.
+	Save(ctx context.Context, r SessionRecord, now time.Time) (int, error)
+
+	// Delete removes record of key if version matches stored one
+	// (0 for a missing or expired record, nothing is removed then).
+	// Returns ErrSessionConflict otherwise.
+	Delete(ctx context.Context, key SessionKey, version int, now time.Time) error
+
+	// DeleteExpired removes records expired at now
+	// and returns their count.
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,34 @@
+// Package tenant carries shop (tenant) of a request through context.
+//
+// Transport layer binds request to shop, for example by bot
+// receiving the update, repositories and services read it
+// to isolate data of shops from each other.
+//
+// Context without shop is not limited: it is used by tasks
+// of the instance itself, which work with every shop.
+package tenant
+
+import "context"
+
+type shopKey struct{}
+
+// WithShop returns ctx bound to shop.
+// Zero shopID leaves ctx unbound.
+func WithShop(ctx context.Context, shopID int) context.Context {
+	if shopID <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, shopKey{}, shopID)
+}
+
+// ShopID returns shop ctx is bound to.
+func ShopID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(shopKey{}).(int)
+	return id, ok
+}
+
+// Allows reports whether entity of shop is visible within ctx.
+func Allows(ctx context.Context, shopID int) bool {
+	id, ok := ShopID(ctx)
+	return !ok || id == shopID
+}
@@ -0,0 +1,363 @@
+// Package catalog implements catalog browsing conversation of storefront bots.
+//
+// Customer walks through city → district → category → product → variant
+// with inline keyboards and finishes by creating an order.
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/storage"
+	"botmanager/internal/transport/bot"
+)
+
+var ErrInvalidCallback = errors.New("invalid callback data")
+
+// Callback data routes. Every route is prefixed by "cat:".
+const (
+	prefix = "cat:"
+
+	routeHome     = prefix + "home"
+	routeCities   = prefix + "c"    // cat:c:<page>
+	routeCity     = prefix + "city" // cat:city:<city>:<page>
+	routeDistrict = prefix + "dist" // cat:dist:<district>:<page>
+	routeCategory = prefix + "catg" // cat:catg:<district>:<category>:<page>
+	routeProduct  = prefix + "prod" // cat:prod:<district>:<product>
+	routeBuy      = prefix + "buy"  // cat:buy:<product>:<variant>
+)
+
+// OrderCreator creates order for selected product variant.
+type OrderCreator interface {
+	CreateForVariant(ctx context.Context, userID, productID, variantID int) (*domain.Order, error)
+}
+
+// Flow is catalog browsing conversation.
+type Flow struct {
+	cities     storage.CityRepository
+	districts  storage.DistrictRepository
+	categories storage.CategoryRepository
+	products   storage.ProductRepository
+	orders     OrderCreator
+	logger     *slog.Logger
+}
+
+// NewFlow creates a new catalog flow.
+//
+// Repositories are wrapped with storage scoped repositories,
+// so with Storefront middleware every bot shows only its scope.
+// logger may be nil, in that case slog.Default() is used.
+func NewFlow(
+	cities storage.CityRepository,
+	districts storage.DistrictRepository,
+	categories storage.CategoryRepository,
+	products storage.ProductRepository,
+	orders OrderCreator,
+	logger *slog.Logger,
+) *Flow {
+	if cities == nil {
+		panic("catalog: CityRepository is nil")
+	}
+
+	if districts == nil {
+		panic("catalog: DistrictRepository is nil")
+	}
+
+	if categories == nil {
+		panic("catalog: CategoryRepository is nil")
+	}
+
+	if products == nil {
+		panic("catalog: ProductRepository is nil")
+	}
+
+	if orders == nil {
+		panic("catalog: OrderCreator is nil")
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Flow{
+		cities:     storage.NewScopedCityRepository(cities),
+		districts:  storage.NewScopedDistrictRepository(districts, cities),
+		categories: storage.NewScopedCategoryRepository(categories, districts),
+		products:   storage.NewScopedProductRepository(products),
+		orders:     orders,
+		logger:     logger,
+	}
+}
+
+// Register mounts flow on router.
+//
+// /start and /catalog open list of cities, /support shows
+// support contact of storefront.
+// Buying requires LoadUser middleware.
+func (f *Flow) Register(r *bot.Router) {
+	r.Command("start", f.Home)
+	r.Command("catalog", f.Home)
+	r.Command("support", f.Support)
+
+	r.Callback(routeHome, f.Home)
+	r.Callback(routeCities+":", f.citiesPage)
+	r.Callback(routeCity+":", f.city)
+	r.Callback(routeDistrict+":", f.district)
+	r.Callback(routeCategory+":", f.category)
+	r.Callback(routeProduct+":", f.product)
+	r.Callback(routeBuy+":", f.buy)
+}
+
+// Home shows first page of cities.
+func (f *Flow) Home(c *bot.Context) error {
+	return f.showCities(c, 0)
+}
+
+// Support shows support contact of storefront.
+func (f *Flow) Support(c *bot.Context) error {
+	contact := c.Storefront().SupportContact
+	if contact == "" {
+		return c.Reply("Support is not available for now.", nil)
+	}
+	return c.Reply("Support: "+contact, nil)
+}
+
+func (f *Flow) citiesPage(c *bot.Context) error {
+	args, err := parseArgs(c.Payload, 1)
+	if err != nil {
+		return err
+	}
+	return f.showCities(c, args[0])
+}
+
+func (f *Flow) showCities(c *bot.Context, page int) error {
+	cities, err := f.cities.List(c.Context())
+	if err != nil {
+		return fmt.Errorf("list cities: %w", err)
+	}
+
+	var welcome string
+	if text := c.Storefront().WelcomeText; text != "" {
+		welcome = text + "\n\n"
+	}
+
+	if len(cities) == 0 {
+		return f.render(c, welcome+"Catalog is empty for now.", nil)
+	}
+
+	items := make([]listItem, 0, len(cities))
+	for _, city := range cities {
+		items = append(items, listItem{
+			label: city.Name(),
+			data:  data(routeCity, city.ID(), 0),
+		})
+	}
+
+	kb := listKeyboard(items, page, func(p int) string {
+		return data(routeCities, p)
+	}, "")
+
+	return f.render(c, welcome+"Choose your city:", kb)
+}
+
+// city shows districts of city.
+func (f *Flow) city(c *bot.Context) error {
+	args, err := parseArgs(c.Payload, 2)
+	if err != nil {
+		return err
+	}
+	cityID, page := args[0], args[1]
+
+	districts, err := f.districts.ListByCity(c.Context(), cityID)
+	if err != nil {
+		return fmt.Errorf("list districts: %w", err)
+	}
+
+	items := make([]listItem, 0, len(districts))
+	for _, d := range districts {
+		items = append(items, listItem{
+			label: d.Name(),
+			data:  data(routeDistrict, d.ID(), 0),
+		})
+	}
+
+	kb := listKeyboard(items, page, func(p int) string {
+		return data(routeCity, cityID, p)
+	}, routeHome)
+
+	if len(items) == 0 {
+		return f.render(c, "No districts available in this city.", kb)
+	}
+
+	return f.render(c, "Choose district:", kb)
+}
+
+// district shows categories available in district.
+func (f *Flow) district(c *bot.Context) error {
+	args, err := parseArgs(c.Payload, 2)
+	if err != nil {
+		return err
+	}
+	districtID, page := args[0], args[1]
+
+	district, err := f.districts.ByID(c.Context(), districtID)
+	if err != nil {
+		return fmt.Errorf("load district: %w", err)
+	}
+
+	categories, err := f.categories.ListByDistrict(c.Context(), districtID)
+	if err != nil {
+		return fmt.Errorf("list categories: %w", err)
+	}
+
+	items := make([]listItem, 0, len(categories))
+	for _, cat := range categories {
+		items = append(items, listItem{
+			label: cat.Name(),
+			data:  data(routeCategory, districtID, cat.ID(), 0),
+		})
+	}
+
+	kb := listKeyboard(items, page, func(p int) string {
+		return data(routeDistrict, districtID, p)
+	}, data(routeCity, district.CityID(), 0))
+
+	if len(items) == 0 {
+		return f.render(c, "Nothing available in this district yet.", kb)
+	}
+
+	return f.render(c, "Choose category:", kb)
+}
+
+// category shows products of category having active variants in district.
+func (f *Flow) category(c *bot.Context) error {
+	args, err := parseArgs(c.Payload, 3)
+	if err != nil {
+		return err
+	}
+	districtID, categoryID, page := args[0], args[1], args[2]
+
+	products, err := f.products.ListByCategory(c.Context(), categoryID)
+	if err != nil {
+		return fmt.Errorf("list products: %w", err)
+	}
+
+	var items []listItem
+	for _, p := range products {
+		if len(activeVariantsIn(p, districtID)) == 0 {
+			continue
+		}
+
+		items = append(items, listItem{
+			label: p.Name(),
+			data:  data(routeProduct, districtID, p.ID()),
+		})
+	}
+
+	kb := listKeyboard(items, page, func(p int) string {
+		return data(routeCategory, districtID, categoryID, p)
+	}, data(routeDistrict, districtID, 0))
+
+	if len(items) == 0 {
+		return f.render(c, "No products available in this category.", kb)
+	}
+
+	return f.render(c, "Choose product:", kb)
+}
+
+// product shows active variants of product in district with prices.
+func (f *Flow) product(c *bot.Context) error {
+	args, err := parseArgs(c.Payload, 2)
+	if err != nil {
+		return err
+	}
+	districtID, productID := args[0], args[1]
+
+	product, err := f.products.ByID(c.Context(), productID)
+	if err != nil {
+		return fmt.Errorf("load product: %w", err)
+	}
+
+	var items []listItem
+	for _, v := range activeVariantsIn(product, districtID) {
+		items = append(items, listItem{
+			label: fmt.Sprintf("%s — %s", v.PackSize(), formatPrice(v.Price())),
+			data:  data(routeBuy, product.ID(), v.ID()),
+		})
+	}
+
+	back := data(routeDistrict, districtID, 0)
+	if categoryID := product.CategoryID(); categoryID != nil {
+		back = data(routeCategory, districtID, *categoryID, 0)
+	}
+
+	kb := listKeyboard(items, 0, func(int) string { return "" }, back)
+
+	text := product.Name()
+	if product.Description() != "" {
+		text += "\n\n" + product.Description()
+	}
+
+	if len(items) == 0 {
+		return f.render(c, text+"\n\nThis product is not available anymore.", kb)
+	}
+
+	return f.render(c, text+"\n\nChoose option:", kb)
+}
+
+// buy creates order for selected variant.
+func (f *Flow) buy(c *bot.Context) error {
+	args, err := parseArgs(c.Payload, 2)
+	if err != nil {
+		return err
+	}
+	productID, variantID := args[0], args[1]
+
+	user := c.User()
+	if user == nil {
+		return bot.ErrUserRequired
+	}
+
+	order, err := f.orders.CreateForVariant(c.Context(), user.ID(), productID, variantID)
+	if err != nil {
+		if errors.Is(err, domain.ErrVariantNotFound) || errors.Is(err, domain.ErrProductNotFound) {
+			return f.render(c, "Sorry, this option is not available anymore.",
+				listKeyboard(nil, 0, nil, routeHome))
+		}
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			return f.render(c, "Sorry, this option is out of stock right now.",
+				listKeyboard(nil, 0, nil, routeHome))
+		}
+		return fmt.Errorf("create order: %w", err)
+	}
+
+	f.logger.Info("order created from catalog",
+		"order_id", order.ID(),
+		"user_id", user.ID(),
+	)
+
+	text := fmt.Sprintf("Order #%d created.\nTotal: %s", order.ID(), formatPrice(order.Total()))
+	return f.render(c, text, listKeyboard(nil, 0, nil, routeHome))
+}
+
+// render shows screen and confirms callback query, if any.
+func (f *Flow) render(c *bot.Context, text string, markup any) error {
+	if err := c.Render(text, markup); err != nil {
+		return err
+	}
+	return c.AnswerCallback("")
+}
+
+// activeVariantsIn returns active variants of product sold in district.
+func activeVariantsIn(p *domain.Product, districtID int) []domain.ProductVariant {
+	var result []domain.ProductVariant
+	for _, v := range p.ActiveVariants() {
+		if v.DistrictID() == districtID {
+			result = append(result, v)
+		}
+	}
+	return result
+}
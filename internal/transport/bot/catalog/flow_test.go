@@ -0,0 +1,475 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/infrastructure/telegram"
+	"botmanager/internal/manager"
+	"botmanager/internal/transport/bot"
+)
+
+// ---- fake Bot API ----
+
+type apiCall struct {
+	method string
+	params map[string]any
+}
+
+type fakeAPI struct {
+	mu    sync.Mutex
+	calls []apiCall
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&params)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, apiCall{method: r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], params: params})
+	f.mu.Unlock()
+
+	result := `true`
+	if strings.HasSuffix(r.URL.Path, "/sendMessage") {
+		result = `{"message_id": 1, "chat": {"id": 42}}`
+	}
+	fmt.Fprintf(w, `{"ok": true, "result": %s}`, result)
+}
+
+// screen returns text and keyboard of the last rendered screen.
+func (f *fakeAPI) screen(t *testing.T) (string, [][]string) {
+	t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := len(f.calls) - 1; i >= 0; i-- {
+		c := f.calls[i]
+		if c.method != "sendMessage" && c.method != "editMessageText" {
+			continue
+		}
+
+		var buttons [][]string
+		markup, _ := c.params["reply_markup"].(map[string]any)
+		rows, _ := markup["inline_keyboard"].([]any)
+		for _, row := range rows {
+			var line []string
+			for _, b := range row.([]any) {
+				btn := b.(map[string]any)
+				line = append(line, btn["text"].(string)+"|"+btn["callback_data"].(string))
+			}
+			buttons = append(buttons, line)
+		}
+
+		return c.params["text"].(string), buttons
+	}
+
+	t.Fatal("nothing was rendered")
+	return "", nil
+}
+
+// ---- fake repositories ----
+
+type fakeCities []domain.City
+
+func (f fakeCities) List(ctx context.Context) ([]domain.City, error)        { return f, nil }
+func (f fakeCities) ByID(ctx context.Context, id int) (*domain.City, error) { return nil, nil }
+func (f fakeCities) Create(ctx context.Context, city *domain.City) error    { return nil }
+func (f fakeCities) Update(ctx context.Context, city *domain.City) error    { return nil }
+func (f fakeCities) Delete(ctx context.Context, id int) error               { return nil }
+
+type fakeDistricts []domain.District
+
+func (f fakeDistricts) List(ctx context.Context) ([]domain.District, error) { return f, nil }
+func (f fakeDistricts) ListByCity(ctx context.Context, cityID int) ([]domain.District, error) {
+	var result []domain.District
+	for _, d := range f {
+		if d.CityID() == cityID {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+func (f fakeDistricts) ListByCategory(ctx context.Context, categoryID int) ([]domain.District, error) {
+	return nil, nil
+}
+func (f fakeDistricts) ListByProduct(ctx context.Context, productID int) ([]domain.District, error) {
+	return nil, nil
+}
+func (f fakeDistricts) ByID(ctx context.Context, id int) (*domain.District, error) {
+	for i := range f {
+		if f[i].ID() == id {
+			return &f[i], nil
+		}
+	}
+	return nil, fmt.Errorf("district %d not found", id)
+}
+func (f fakeDistricts) Create(ctx context.Context, district *domain.District) error { return nil }
+func (f fakeDistricts) Update(ctx context.Context, district *domain.District) error { return nil }
+func (f fakeDistricts) DeleteByID(ctx context.Context, id int) error                { return nil }
+
+type fakeCategories []domain.Category
+
+func (f fakeCategories) List(ctx context.Context) ([]domain.Category, error) { return f, nil }
+func (f fakeCategories) ListByCity(ctx context.Context, cityID int) ([]domain.Category, error) {
+	return f, nil
+}
+func (f fakeCategories) ListByDistrict(ctx context.Context, districtID int) ([]domain.Category, error) {
+	return f, nil
+}
+func (f fakeCategories) ByID(ctx context.Context, id int) (*domain.Category, error)  { return nil, nil }
+func (f fakeCategories) Create(ctx context.Context, category *domain.Category) error { return nil }
+func (f fakeCategories) Update(ctx context.Context, category *domain.Category) error { return nil }
+func (f fakeCategories) DeleteByID(ctx context.Context, id int) error                { return nil }
+
+type fakeProducts []*domain.Product
+
+func (f fakeProducts) ByID(ctx context.Context, id int) (*domain.Product, error) {
+	for _, p := range f {
+		if p.ID() == id {
+			return p, nil
+		}
+	}
+	return nil, domain.ErrProductNotFound
+}
+
+func (f fakeProducts) ListByCategory(ctx context.Context, categoryID int) ([]*domain.Product, error) {
+	var result []*domain.Product
+	for _, p := range f {
+		if id := p.CategoryID(); id != nil && *id == categoryID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+type fakeOrders struct {
+	userID, productID, variantID int
+	err                          error
+}
+
+func (f *fakeOrders) CreateForVariant(ctx context.Context, userID, productID, variantID int) (*domain.Order, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	f.userID, f.productID, f.variantID = userID, productID, variantID
+
+	// users built by domain.NewUser have no id yet, so the order is
+	// attributed to a fixed one
+	order, err := domain.NewOrder(1, []domain.OrderItem{
+		domain.NewOrderItem(productID, variantID, 1, 1500),
+	}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	order.SetID(77)
+	return order, nil
+}
+
+type fixedUser struct{ user *domain.User }
+
+func (f fixedUser) EnsureTelegramUser(ctx context.Context, tgID int64, tgName string) (*domain.User, error) {
+	return f.user, nil
+}
+
+type fakeConfigs map[string]manager.BotConfig
+
+func (f fakeConfigs) Config(id string) (manager.BotConfig, bool) {
+	cfg, ok := f[id]
+	return cfg, ok
+}
+
+// ---- fixture ----
+
+const testToken = "1:test"
+
+type fixture struct {
+	api     *fakeAPI
+	bot     *telegram.Bot
+	router  *bot.Router
+	orders  *fakeOrders
+	configs fakeConfigs
+}
+
+func newFixture(t *testing.T, cities fakeCities) *fixture {
+	t.Helper()
+
+	district, _ := domain.NewDistrict(1, "Center")
+	district.SetID(10)
+	otherDistrict, _ := domain.NewDistrict(1, "Suburb")
+	otherDistrict.SetID(11)
+
+	category, _ := domain.NewCategory("Coffee", "")
+	category.SetID(5)
+
+	archivedAt := time.Now()
+	categoryID := 5
+	product := domain.NewProductFromDB(100, &categoryID, "Arabica", "Fresh beans", nil, 1, []domain.ProductVariant{
+		*domain.NewProductVariantFromDB(1000, "250g", 10, 1500, nil),
+		*domain.NewProductVariantFromDB(1001, "1kg", 10, 5000, &archivedAt),
+		*domain.NewProductVariantFromDB(1002, "500g", 11, 2500, nil),
+	})
+	onlyElsewhere := domain.NewProductFromDB(101, &categoryID, "Robusta", "", nil, 1, []domain.ProductVariant{
+		*domain.NewProductVariantFromDB(1100, "250g", 11, 900, nil),
+	})
+
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	tgID := int64(42)
+	user, _ := domain.NewUser(domain.NewUserParams{TgID: &tgID})
+
+	orders := &fakeOrders{}
+	flow := NewFlow(
+		cities,
+		fakeDistricts{*district, *otherDistrict},
+		fakeCategories{*category},
+		fakeProducts{product, onlyElsewhere},
+		orders,
+		nil,
+	)
+
+	configs := fakeConfigs{}
+
+	r := bot.NewRouter()
+	r.Use(bot.LoadUser(fixedUser{user: user}), bot.Storefront(configs))
+	flow.Register(r)
+
+	return &fixture{
+		api:     api,
+		bot:     telegram.NewBot(telegram.NewClient(srv.URL, srv.Client()), testToken),
+		router:  r,
+		orders:  orders,
+		configs: configs,
+	}
+}
+
+func (f *fixture) press(t *testing.T, data string) {
+	t.Helper()
+
+	err := f.router.HandleUpdate(context.Background(), f.bot, telegram.Update{
+		CallbackQuery: &telegram.CallbackQuery{
+			ID:      "cb",
+			From:    telegram.User{ID: 42},
+			Message: &telegram.Message{MessageID: 1, Chat: telegram.Chat{ID: 42}},
+			Data:    data,
+		},
+	})
+	if err != nil {
+		t.Fatalf("press %s: %v", data, err)
+	}
+}
+
+func (f *fixture) send(t *testing.T, text string) {
+	t.Helper()
+
+	err := f.router.HandleUpdate(context.Background(), f.bot, telegram.Update{
+		Message: &telegram.Message{
+			From: &telegram.User{ID: 42},
+			Chat: telegram.Chat{ID: 42},
+			Text: text,
+		},
+	})
+	if err != nil {
+		t.Fatalf("send %s: %v", text, err)
+	}
+}
+
+func cities(names ...string) fakeCities {
+	var result fakeCities
+	for i, name := range names {
+		c, _ := domain.NewCity(name)
+		c.SetID(i + 1)
+		result = append(result, *c)
+	}
+	return result
+}
+
+func flat(buttons [][]string) string {
+	var parts []string
+	for _, row := range buttons {
+		parts = append(parts, strings.Join(row, ","))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ---- tests ----
+
+func TestFlowWalkthrough(t *testing.T) {
+	f := newFixture(t, cities("Moscow"))
+
+	f.send(t, "/start")
+	text, kb := f.api.screen(t)
+	if text != "Choose your city:" || flat(kb) != "Moscow|cat:city:1:0" {
+		t.Fatalf("unexpected cities screen %q\n%s", text, flat(kb))
+	}
+
+	f.press(t, "cat:city:1:0")
+	_, kb = f.api.screen(t)
+	want := "Center|cat:dist:10:0\nSuburb|cat:dist:11:0\n« Back|cat:home"
+	if flat(kb) != want {
+		t.Fatalf("unexpected districts keyboard\n%s", flat(kb))
+	}
+
+	f.press(t, "cat:dist:10:0")
+	_, kb = f.api.screen(t)
+	want = "Coffee|cat:catg:10:5:0\n« Back|cat:city:1:0,⌂ Home|cat:home"
+	if flat(kb) != want {
+		t.Fatalf("unexpected categories keyboard\n%s", flat(kb))
+	}
+
+	// Robusta is sold only in other district
+	f.press(t, "cat:catg:10:5:0")
+	_, kb = f.api.screen(t)
+	want = "Arabica|cat:prod:10:100\n« Back|cat:dist:10:0,⌂ Home|cat:home"
+	if flat(kb) != want {
+		t.Fatalf("unexpected products keyboard\n%s", flat(kb))
+	}
+
+	// archived and other district variants are hidden
+	f.press(t, "cat:prod:10:100")
+	text, kb = f.api.screen(t)
+	want = "250g — 15.00|cat:buy:100:1000\n« Back|cat:catg:10:5:0,⌂ Home|cat:home"
+	if flat(kb) != want {
+		t.Fatalf("unexpected variants keyboard\n%s", flat(kb))
+	}
+	if !strings.Contains(text, "Fresh beans") {
+		t.Fatalf("expected product description, got %q", text)
+	}
+
+	f.press(t, "cat:buy:100:1000")
+	text, _ = f.api.screen(t)
+	if !strings.Contains(text, "Order #77") {
+		t.Fatalf("unexpected order screen %q", text)
+	}
+
+	if f.orders.productID != 100 || f.orders.variantID != 1000 {
+		t.Fatalf("unexpected order %+v", f.orders)
+	}
+}
+
+func TestFlowPagination(t *testing.T) {
+	var names []string
+	for i := 0; i < pageSize+3; i++ {
+		names = append(names, fmt.Sprintf("City %02d", i))
+	}
+	f := newFixture(t, cities(names...))
+
+	f.send(t, "/catalog")
+	_, kb := f.api.screen(t)
+	if len(kb) != pageSize+1 {
+		t.Fatalf("expected %d rows, got %d", pageSize+1, len(kb))
+	}
+	if last := strings.Join(kb[len(kb)-1], ","); last != "Next ›|cat:c:1" {
+		t.Fatalf("unexpected pager %s", last)
+	}
+
+	f.press(t, "cat:c:1")
+	_, kb = f.api.screen(t)
+	if len(kb) != 4 {
+		t.Fatalf("expected 4 rows on second page, got %d", len(kb))
+	}
+	if last := strings.Join(kb[len(kb)-1], ","); last != "‹ Prev|cat:c:0" {
+		t.Fatalf("unexpected pager %s", last)
+	}
+}
+
+func TestFlowBuyUnavailableVariant(t *testing.T) {
+	f := newFixture(t, cities("Moscow"))
+	f.orders.err = fmt.Errorf("load product variant: %w", domain.ErrVariantNotFound)
+
+	f.press(t, "cat:buy:100:1001")
+
+	text, kb := f.api.screen(t)
+	if !strings.Contains(text, "not available") {
+		t.Fatalf("unexpected screen %q", text)
+	}
+	if flat(kb) != "« Back|cat:home" {
+		t.Fatalf("expected home navigation, got %s", flat(kb))
+	}
+}
+
+func TestFlowBuyOutOfStock(t *testing.T) {
+	f := newFixture(t, cities("Moscow"))
+	f.orders.err = fmt.Errorf("reserve stock: %w", domain.ErrInsufficientStock)
+
+	f.press(t, "cat:buy:100:1001")
+
+	text, kb := f.api.screen(t)
+	if !strings.Contains(text, "out of stock") {
+		t.Fatalf("unexpected screen %q", text)
+	}
+	if flat(kb) != "« Back|cat:home" {
+		t.Fatalf("expected home navigation, got %s", flat(kb))
+	}
+}
+
+func TestFlowInvalidCallback(t *testing.T) {
+	f := newFixture(t, cities("Moscow"))
+
+	err := f.router.HandleUpdate(context.Background(), f.bot, telegram.Update{
+		CallbackQuery: &telegram.CallbackQuery{Data: "cat:city:x"},
+	})
+	if err == nil {
+		t.Fatal("expected error for malformed callback data")
+	}
+}
+
+func TestFlowStorefrontScope(t *testing.T) {
+	f := newFixture(t, cities("Moscow", "Kazan"))
+	f.configs[manager.BotID(testToken)] = manager.BotConfig{
+		Storefront: manager.Storefront{
+			CityIDs:        []int{2},
+			WelcomeText:    "Welcome to Kazan shop!",
+			SupportContact: "@kazan_support",
+		},
+	}
+
+	f.send(t, "/start")
+	text, kb := f.api.screen(t)
+	if text != "Welcome to Kazan shop!\n\nChoose your city:" || flat(kb) != "Kazan|cat:city:2:0" {
+		t.Fatalf("unexpected cities screen %q\n%s", text, flat(kb))
+	}
+
+	// districts of other city are not reachable with crafted callback
+	f.press(t, "cat:city:1:0")
+	text, _ = f.api.screen(t)
+	if !strings.Contains(text, "No districts") {
+		t.Fatalf("expected no districts out of scope, got %q", text)
+	}
+
+	f.send(t, "/support")
+	text, _ = f.api.screen(t)
+	if text != "Support: @kazan_support" {
+		t.Fatalf("unexpected support reply %q", text)
+	}
+}
+
+func TestFlowStorefrontCategories(t *testing.T) {
+	f := newFixture(t, cities("Moscow"))
+	f.configs[manager.BotID(testToken)] = manager.BotConfig{
+		Storefront: manager.Storefront{CategoryIDs: []int{6}},
+	}
+
+	f.press(t, "cat:dist:10:0")
+	text, _ := f.api.screen(t)
+	if !strings.Contains(text, "Nothing available") {
+		t.Fatalf("expected categories out of scope to be hidden, got %q", text)
+	}
+
+	f.press(t, "cat:catg:10:5:0")
+	text, _ = f.api.screen(t)
+	if !strings.Contains(text, "No products") {
+		t.Fatalf("expected products out of scope to be hidden, got %q", text)
+	}
+}
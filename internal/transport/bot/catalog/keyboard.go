@@ -0,0 +1,118 @@
+package catalog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"botmanager/internal/infrastructure/telegram"
+)
+
+// pageSize is a number of list items shown on one screen.
+const pageSize = 8
+
+// button creates inline keyboard button.
+func button(text, data string) telegram.InlineKeyboardButton {
+	return telegram.InlineKeyboardButton{Text: text, CallbackData: data}
+}
+
+// listItem is a single selectable entry of list screen.
+type listItem struct {
+	label string
+	data  string
+}
+
+// listKeyboard builds keyboard with one item per row, pagination
+// and navigation rows. pageData builds callback data of other page,
+// back is callback data of parent screen (empty for root screen).
+func listKeyboard(
+	items []listItem,
+	page int,
+	pageData func(page int) string,
+	back string,
+) telegram.InlineKeyboardMarkup {
+	start, end := pageBounds(len(items), page)
+
+	var rows [][]telegram.InlineKeyboardButton
+	for _, item := range items[start:end] {
+		rows = append(rows, []telegram.InlineKeyboardButton{button(item.label, item.data)})
+	}
+
+	var pager []telegram.InlineKeyboardButton
+	if start > 0 {
+		pager = append(pager, button("‹ Prev", pageData(page-1)))
+	}
+	if end < len(items) {
+		pager = append(pager, button("Next ›", pageData(page+1)))
+	}
+	if len(pager) > 0 {
+		rows = append(rows, pager)
+	}
+
+	if nav := navRow(back); len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+
+	return telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// navRow returns back/home buttons. Root screen has no navigation.
+func navRow(back string) []telegram.InlineKeyboardButton {
+	if back == "" {
+		return nil
+	}
+
+	row := []telegram.InlineKeyboardButton{button("« Back", back)}
+	if back != routeHome {
+		row = append(row, button("⌂ Home", routeHome))
+	}
+	return row
+}
+
+// pageBounds returns slice bounds of page, clamping page into valid range.
+func pageBounds(total, page int) (int, int) {
+	if page < 0 {
+		page = 0
+	}
+
+	start := page * pageSize
+	if start >= total {
+		start = max(0, (total-1)/pageSize*pageSize)
+	}
+
+	return start, min(start+pageSize, total)
+}
+
+// data joins callback data parts.
+func data(route string, args ...int) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, route)
+	for _, a := range args {
+		parts = append(parts, strconv.Itoa(a))
+	}
+	return strings.Join(parts, ":")
+}
+
+// parseArgs parses exactly n integer arguments of callback payload.
+func parseArgs(payload string, n int) ([]int, error) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != n {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCallback, payload)
+	}
+
+	args := make([]int, n)
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCallback, payload)
+		}
+		args[i] = v
+	}
+
+	return args, nil
+}
+
+// formatPrice formats amount in smallest currency units.
+func formatPrice(amount int64) string {
+	return fmt.Sprintf("%d.%02d", amount/100, amount%100)
+}
@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"context"
+	"errors"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/infrastructure/telegram"
+	"botmanager/internal/manager"
+)
+
+var ErrNoChat = errors.New("update has no chat")
+
+// Context carries a single update through middleware and handlers.
+type Context struct {
+	ctx    context.Context
+	Bot    *telegram.Bot
+	Update telegram.Update
+
+	// Command is command name without slash and bot mention,
+	// Args is the rest of message text. Set for command updates.
+	Command string
+	Args    string
+
+	// Payload is callback data without matched route prefix.
+	// Set for callback query updates.
+	Payload string
+
+	user       *domain.User
+	storefront manager.Storefront
+}
+
+// NewContext creates context for update received by bot.
+func NewContext(ctx context.Context, b *telegram.Bot, u telegram.Update) *Context {
+	return &Context{
+		ctx:    ctx,
+		Bot:    b,
+		Update: u,
+	}
+}
+
+// Context returns context.Context of update.
+//
+// It carries resolved user, see UserFromContext.
+func (c *Context) Context() context.Context {
+	return c.ctx
+}
+
+// User returns user resolved by LoadUser middleware or nil.
+func (c *Context) User() *domain.User {
+	return c.user
+}
+
+// SetUser attaches user to update.
+func (c *Context) SetUser(u *domain.User) {
+	c.user = u
+	c.ctx = WithUser(c.ctx, u)
+}
+
+// Storefront returns storefront of bot set by Storefront middleware.
+func (c *Context) Storefront() manager.Storefront {
+	return c.storefront
+}
+
+// ChatID returns chat the update belongs to.
+func (c *Context) ChatID() (int64, error) {
+	id, ok := c.Update.ChatID()
+	if !ok {
+		return 0, ErrNoChat
+	}
+	return id, nil
+}
+
+// Reply sends text message to the chat of update.
+// markup may be nil.
+func (c *Context) Reply(text string, markup any) error {
+	chatID, err := c.ChatID()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Bot.SendMessage(c.ctx, telegram.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: markup,
+	})
+	return err
+}
+
+// Render shows screen to user.
+//
+// For callback query it edits message with pressed button,
+// otherwise it sends a new message.
+func (c *Context) Render(text string, markup any) error {
+	cb := c.Update.CallbackQuery
+	if cb == nil || cb.Message == nil {
+		return c.Reply(text, markup)
+	}
+
+	return c.Bot.EditMessageText(c.ctx, telegram.EditMessageTextParams{
+		ChatID:      cb.Message.Chat.ID,
+		MessageID:   cb.Message.MessageID,
+		Text:        text,
+		ReplyMarkup: markup,
+	})
+}
+
+// AnswerCallback confirms callback query of update.
+// It does nothing for other kinds of updates.
+func (c *Context) AnswerCallback(text string) error {
+	if c.Update.CallbackQuery == nil {
+		return nil
+	}
+
+	return c.Bot.AnswerCallbackQuery(c.ctx, telegram.AnswerCallbackQueryParams{
+		CallbackQueryID: c.Update.CallbackQuery.ID,
+		Text:            text,
+	})
+}
+
+type userKey struct{}
+
+// WithUser returns ctx carrying user.
+func WithUser(ctx context.Context, u *domain.User) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// UserFromContext returns user stored by WithUser.
+func UserFromContext(ctx context.Context) (*domain.User, bool) {
+	u, ok := ctx.Value(userKey{}).(*domain.User)
+	return u, ok && u != nil
+}
@@ -0,0 +1,198 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/manager"
+	"botmanager/internal/storage"
+	"botmanager/internal/tenant"
+)
+
+var (
+	ErrUserRequired = errors.New("update has no user")
+	ErrAccessDenied = errors.New("access denied")
+)
+
+// Logging logs every handled update with its outcome and duration.
+//
+// Updates of bots run by manager are logged to logger of bot,
+// see manager.Logger, others to logger.
+func Logging(logger *slog.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			logger := manager.Logger(c.Context(), logger)
+			start := time.Now()
+			err := next(c)
+
+			attrs := []any{
+				"update_id", c.Update.UpdateID,
+				"duration", time.Since(start),
+			}
+			if c.Command != "" {
+				attrs = append(attrs, "command", c.Command)
+			}
+			if u := c.User(); u != nil {
+				attrs = append(attrs, "user_id", u.ID())
+			}
+
+			if err != nil {
+				logger.Warn("update handling failed", append(attrs, "err", err)...)
+				return err
+			}
+
+			logger.Debug("update handled", attrs...)
+			return nil
+		}
+	}
+}
+
+// Recovery turns panic of handler into error.
+// Panics are logged like in Logging.
+func Recovery(logger *slog.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					manager.Logger(c.Context(), logger).Error("bot handler panic",
+						"update_id", c.Update.UpdateID,
+						"panic", r,
+						"stack", string(debug.Stack()),
+					)
+					err = fmt.Errorf("handler panic: %v", r)
+				}
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// UserResolver resolves application user by Telegram identity.
+type UserResolver interface {
+	EnsureTelegramUser(ctx context.Context, tgID int64, tgName string) (*domain.User, error)
+}
+
+// LoadUser resolves author of update and attaches it to Context.
+//
+// Updates without author are passed through without user.
+func LoadUser(users UserResolver) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			from := c.Update.From()
+			if from == nil {
+				return next(c)
+			}
+
+			user, err := users.EnsureTelegramUser(c.Context(), from.ID, from.Username)
+			if err != nil {
+				return fmt.Errorf("load user: %w", err)
+			}
+
+			c.SetUser(user)
+			return next(c)
+		}
+	}
+}
+
+// BotConfigs returns current config of bot, see manager.Manager.
+type BotConfigs interface {
+	Config(id string) (manager.BotConfig, bool)
+}
+
+// Storefront attaches storefront of bot to Context
+// and limits queries made with Context.Context()
+// to its scope and shop, see storage.WithScope and tenant.WithShop.
+//
+// Updates of bots unknown to configs are not limited.
+func Storefront(configs BotConfigs) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			cfg, ok := configs.Config(c.Bot.ID())
+			if !ok {
+				return next(c)
+			}
+
+			sf := cfg.Storefront
+			c.storefront = sf
+			c.ctx = storage.WithScope(c.ctx, storage.Scope{
+				CityIDs:     sf.CityIDs,
+				CategoryIDs: sf.CategoryIDs,
+			})
+			c.ctx = tenant.WithShop(c.ctx, cfg.ShopID)
+
+			return next(c)
+		}
+	}
+}
+
+// ShopLookup returns shop by id.
+type ShopLookup interface {
+	ByID(ctx context.Context, id int) (*domain.Shop, error)
+}
+
+// ShopEnabled stops updates of bots bound to disabled shop
+// with domain.ErrShopDisabled. It must be used after Storefront.
+//
+// Updates not bound to shop are passed through.
+func ShopEnabled(shops ShopLookup) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			shopID, ok := tenant.ShopID(c.Context())
+			if !ok {
+				return next(c)
+			}
+
+			shop, err := shops.ByID(c.Context(), shopID)
+			if err != nil {
+				return fmt.Errorf("load shop: %w", err)
+			}
+
+			if !shop.IsEnabled() {
+				return domain.ErrShopDisabled
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireAdmin lets through only users with valid admin panel access.
+// When update is bound to shop, user must be allowed to manage it,
+// see domain.User.CanManageShop. It must be used after LoadUser.
+func RequireAdmin(now func() time.Time) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			user := c.User()
+			if user == nil {
+				return ErrUserRequired
+			}
+
+			allowed := user.CanUseAdminPanel(now())
+			if shopID, ok := tenant.ShopID(c.ctx); ok {
+				allowed = user.CanManageShop(shopID, now())
+			}
+			if !allowed {
+				return ErrAccessDenied
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Group returns handler wrapped with middleware,
+// to protect only some routes, e.g.
+//
+//	r.Command("stats", bot.Group(stats, bot.RequireAdmin(time.Now)))
+func Group(h HandlerFunc, mw ...Middleware) HandlerFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
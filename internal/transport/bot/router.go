@@ -0,0 +1,147 @@
+// Package bot provides routing of Telegram updates to bot handlers.
+//
+// Router works much like HTTP router: it matches commands,
+// callback query data prefixes and free text, and wraps
+// handlers with middleware.
+package bot
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"botmanager/internal/infrastructure/telegram"
+)
+
+// HandlerFunc handles routed update.
+type HandlerFunc func(c *Context) error
+
+// Middleware wraps handler with additional behaviour.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+var _ telegram.Handler = (*Router)(nil)
+
+// Router dispatches updates to handlers.
+//
+// Routing rules:
+//   - message starting with "/" is routed by command name,
+//     "/start@my_bot args" matches Command("start");
+//   - callback query is routed by the longest matching data prefix;
+//   - any other text message goes to Text handler;
+//   - everything unmatched goes to NotFound handler.
+//
+// Router is not safe for modification after it starts serving updates.
+type Router struct {
+	middlewares []Middleware
+	commands    map[string]HandlerFunc
+	callbacks   []callbackRoute
+	text        HandlerFunc
+	notFound    HandlerFunc
+}
+
+type callbackRoute struct {
+	prefix  string
+	handler HandlerFunc
+}
+
+// NewRouter creates a new update router.
+func NewRouter() *Router {
+	return &Router{
+		commands: make(map[string]HandlerFunc),
+		notFound: func(c *Context) error { return nil },
+	}
+}
+
+// Use appends middleware to the chain.
+// Middleware is applied in order of registration.
+func (r *Router) Use(mw ...Middleware) {
+	r.middlewares = append(r.middlewares, mw...)
+}
+
+// Command registers handler of command, with or without leading slash.
+func (r *Router) Command(name string, h HandlerFunc) {
+	r.commands[strings.TrimPrefix(name, "/")] = h
+}
+
+// Callback registers handler of callback queries with data prefix.
+func (r *Router) Callback(prefix string, h HandlerFunc) {
+	r.callbacks = append(r.callbacks, callbackRoute{prefix: prefix, handler: h})
+
+	sort.SliceStable(r.callbacks, func(i, j int) bool {
+		return len(r.callbacks[i].prefix) > len(r.callbacks[j].prefix)
+	})
+}
+
+// Text registers handler of text messages which are not commands.
+func (r *Router) Text(h HandlerFunc) {
+	r.text = h
+}
+
+// NotFound registers handler of unmatched updates.
+// By default unmatched updates are ignored.
+func (r *Router) NotFound(h HandlerFunc) {
+	r.notFound = h
+}
+
+// HandleUpdate implements telegram.Handler.
+func (r *Router) HandleUpdate(ctx context.Context, b *telegram.Bot, u telegram.Update) error {
+	c := NewContext(ctx, b, u)
+
+	h := r.route(c)
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		h = r.middlewares[i](h)
+	}
+
+	return h(c)
+}
+
+// route finds handler of update and fills routing fields of c.
+func (r *Router) route(c *Context) HandlerFunc {
+	u := c.Update
+
+	if u.CallbackQuery != nil {
+		for _, route := range r.callbacks {
+			if strings.HasPrefix(u.CallbackQuery.Data, route.prefix) {
+				c.Payload = strings.TrimPrefix(u.CallbackQuery.Data, route.prefix)
+				return route.handler
+			}
+		}
+		return r.notFound
+	}
+
+	if u.Message == nil {
+		return r.notFound
+	}
+
+	text := strings.TrimSpace(u.Message.Text)
+
+	if name, args, ok := parseCommand(text); ok {
+		if h, found := r.commands[name]; found {
+			c.Command = name
+			c.Args = args
+			return h
+		}
+		return r.notFound
+	}
+
+	if text != "" && r.text != nil {
+		return r.text
+	}
+
+	return r.notFound
+}
+
+// parseCommand splits "/name@bot args" into name and args.
+func parseCommand(text string) (string, string, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+
+	head, args, _ := strings.Cut(text[1:], " ")
+	name, _, _ := strings.Cut(head, "@")
+	if name == "" {
+		return "", "", false
+	}
+
+	return name, strings.TrimSpace(args), true
+}
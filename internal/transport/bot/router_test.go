@@ -0,0 +1,334 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/infrastructure/telegram"
+	"botmanager/internal/tenant"
+)
+
+func message(text string) telegram.Update {
+	return telegram.Update{
+		UpdateID: 1,
+		Message: &telegram.Message{
+			From: &telegram.User{ID: 42, Username: "john"},
+			Chat: telegram.Chat{ID: 42},
+			Text: text,
+		},
+	}
+}
+
+func callback(data string) telegram.Update {
+	return telegram.Update{
+		UpdateID: 1,
+		CallbackQuery: &telegram.CallbackQuery{
+			ID:   "cb",
+			From: telegram.User{ID: 42},
+			Data: data,
+		},
+	}
+}
+
+func handle(t *testing.T, r *Router, u telegram.Update) error {
+	t.Helper()
+	return r.HandleUpdate(context.Background(), nil, u)
+}
+
+func TestRouterCommands(t *testing.T) {
+	r := NewRouter()
+
+	var got *Context
+	r.Command("/start", func(c *Context) error {
+		got = c
+		return nil
+	})
+
+	if err := handle(t, r, message("/start@shop_bot  ref42 ")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got == nil {
+		t.Fatal("expected start handler to be called")
+	}
+
+	if got.Command != "start" || got.Args != "ref42" {
+		t.Fatalf("unexpected command %q args %q", got.Command, got.Args)
+	}
+}
+
+func TestRouterUnknownCommandGoesToNotFound(t *testing.T) {
+	r := NewRouter()
+
+	textCalled, notFound := false, false
+	r.Text(func(c *Context) error {
+		textCalled = true
+		return nil
+	})
+	r.NotFound(func(c *Context) error {
+		notFound = true
+		return nil
+	})
+
+	_ = handle(t, r, message("/unknown"))
+
+	if textCalled || !notFound {
+		t.Fatal("expected unknown command to be routed to NotFound")
+	}
+}
+
+func TestRouterCallbackLongestPrefix(t *testing.T) {
+	r := NewRouter()
+
+	var route, payload string
+	r.Callback("cat:", func(c *Context) error {
+		route, payload = "cat", c.Payload
+		return nil
+	})
+	r.Callback("cat:page:", func(c *Context) error {
+		route, payload = "page", c.Payload
+		return nil
+	})
+
+	_ = handle(t, r, callback("cat:page:2"))
+	if route != "page" || payload != "2" {
+		t.Fatalf("expected page route with payload 2, got %s %s", route, payload)
+	}
+
+	_ = handle(t, r, callback("cat:7"))
+	if route != "cat" || payload != "7" {
+		t.Fatalf("expected cat route with payload 7, got %s %s", route, payload)
+	}
+}
+
+func TestRouterText(t *testing.T) {
+	r := NewRouter()
+
+	var text string
+	r.Text(func(c *Context) error {
+		text = c.Update.Message.Text
+		return nil
+	})
+
+	_ = handle(t, r, message("hello"))
+	if text != "hello" {
+		t.Fatalf("expected text handler, got %q", text)
+	}
+}
+
+func TestRouterMiddlewareOrder(t *testing.T) {
+	r := NewRouter()
+
+	var calls []string
+	mw := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c *Context) error {
+				calls = append(calls, name)
+				return next(c)
+			}
+		}
+	}
+
+	r.Use(mw("first"), mw("second"))
+	r.Command("help", func(c *Context) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+
+	_ = handle(t, r, message("/help"))
+
+	if strings.Join(calls, ",") != "first,second,handler" {
+		t.Fatalf("unexpected call order %v", calls)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	r := NewRouter()
+	r.Use(Recovery(slog.Default()))
+	r.Command("boom", func(c *Context) error {
+		panic("boom")
+	})
+
+	err := handle(t, r, message("/boom"))
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected panic to be turned into error, got %v", err)
+	}
+}
+
+type stubResolver struct {
+	user *domain.User
+	err  error
+	tgID int64
+}
+
+func (s *stubResolver) EnsureTelegramUser(ctx context.Context, tgID int64, tgName string) (*domain.User, error) {
+	s.tgID = tgID
+	return s.user, s.err
+}
+
+func TestLoadUser(t *testing.T) {
+	tgID := int64(42)
+	user, _ := domain.NewUser(domain.NewUserParams{TgID: &tgID})
+	resolver := &stubResolver{user: user}
+
+	r := NewRouter()
+	r.Use(LoadUser(resolver))
+
+	var fromCtx *domain.User
+	r.Callback("x", func(c *Context) error {
+		if c.User() != user {
+			t.Fatal("expected user in update context")
+		}
+		fromCtx, _ = UserFromContext(c.Context())
+		return nil
+	})
+
+	if err := handle(t, r, callback("x")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resolver.tgID != 42 {
+		t.Fatalf("expected user to be resolved by telegram id, got %d", resolver.tgID)
+	}
+
+	if fromCtx != user {
+		t.Fatal("expected user in context.Context")
+	}
+}
+
+func TestLoadUserError(t *testing.T) {
+	resolver := &stubResolver{err: errors.New("db down")}
+
+	r := NewRouter()
+	r.Use(LoadUser(resolver))
+	r.Command("start", func(c *Context) error {
+		t.Fatal("handler must not be called")
+		return nil
+	})
+
+	if err := handle(t, r, message("/start")); !errors.Is(err, resolver.err) {
+		t.Fatalf("expected resolver error, got %v", err)
+	}
+}
+
+type stubShops map[int]*domain.Shop
+
+func (s stubShops) ByID(ctx context.Context, id int) (*domain.Shop, error) {
+	shop, ok := s[id]
+	if !ok {
+		return nil, domain.ErrShopNotFound
+	}
+	return shop, nil
+}
+
+func TestShopEnabled(t *testing.T) {
+	enabled, _ := domain.NewShop("enabled")
+	disabled, _ := domain.NewShop("disabled")
+	disabled.Disable()
+
+	shops := stubShops{1: enabled, 2: disabled}
+
+	cases := []struct {
+		name string
+		shop int
+		want error
+	}{
+		{"not bound", 0, nil},
+		{"enabled shop", 1, nil},
+		{"disabled shop", 2, domain.ErrShopDisabled},
+		{"unknown shop", 3, domain.ErrShopNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRouter()
+			r.Use(func(next HandlerFunc) HandlerFunc {
+				return func(c *Context) error {
+					c.ctx = tenant.WithShop(c.ctx, tc.shop)
+					return next(c)
+				}
+			})
+			r.Use(ShopEnabled(shops))
+
+			handled := false
+			r.Command("start", func(c *Context) error {
+				handled = true
+				return nil
+			})
+
+			if err := handle(t, r, message("/start")); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+			if handled != (tc.want == nil) {
+				t.Fatalf("expected handler called %v, got %v", tc.want == nil, handled)
+			}
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	now := time.Now()
+
+	tgID := int64(42)
+	customer, _ := domain.NewUser(domain.NewUserParams{TgID: &tgID})
+
+	admin, _ := domain.NewUser(domain.NewUserParams{
+		Email:        "admin@shop.dev",
+		PasswordHash: "hash",
+		Role:         domain.RoleAdmin,
+	})
+	admin.GrantAdminAccess(now.Add(time.Hour))
+
+	shopAdmin, _ := domain.NewUser(domain.NewUserParams{
+		Email:        "manager@shop.dev",
+		PasswordHash: "hash",
+		Role:         domain.RoleAdmin,
+		ShopID:       1,
+	})
+	shopAdmin.GrantAdminAccess(now.Add(time.Hour))
+
+	cases := []struct {
+		name string
+		user *domain.User
+		shop int
+		want error
+	}{
+		{"no user", nil, 0, ErrUserRequired},
+		{"customer", customer, 0, ErrAccessDenied},
+		{"admin", admin, 0, nil},
+		{"admin in any shop", admin, 2, nil},
+		{"shop admin in own shop", shopAdmin, 1, nil},
+		{"shop admin in other shop", shopAdmin, 2, ErrAccessDenied},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRouter()
+			r.Use(func(next HandlerFunc) HandlerFunc {
+				return func(c *Context) error {
+					c.ctx = tenant.WithShop(c.ctx, tc.shop)
+					return next(c)
+				}
+			})
+			r.Use(LoadUser(&stubResolver{user: tc.user}))
+			r.Command("stats", Group(
+				func(c *Context) error { return nil },
+				RequireAdmin(func() time.Time { return now }),
+			))
+
+			u := message("/stats")
+			if tc.user == nil {
+				u.Message.From = nil
+			}
+
+			if err := handle(t, r, u); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"botmanager/internal/transport/bot"
+)
+
+// State is a state of conversation.
+type State string
+
+// Idle is state of chat without active session.
+const Idle State = ""
+
+// Transitions lists states reachable from each state.
+// Transition to Idle is always allowed, see Machine.Finish.
+type Transitions map[State][]State
+
+// Session is a conversation loaded by Machine.
+type Session[T any] struct {
+	Key   Key
+	State State
+	Data  T
+
+	version int
+}
+
+// Machine is a typed state machine of conversations
+// with data of type T kept in Store.
+type Machine[T any] struct {
+	store       Store
+	ttl         time.Duration
+	transitions map[State]map[State]bool
+	now         func() time.Time
+}
+
+// NewMachine creates machine with given transitions.
+// Sessions expire after ttl without saves.
+func NewMachine[T any](store Store, ttl time.Duration, transitions Transitions) *Machine[T] {
+	if store == nil {
+		panic("session: Store is nil")
+	}
+	if ttl <= 0 {
+		panic("session: ttl must be positive")
+	}
+
+	allowed := make(map[State]map[State]bool, len(transitions))
+	for from, targets := range transitions {
+		allowed[from] = make(map[State]bool, len(targets))
+		for _, to := range targets {
+			allowed[from][to] = true
+		}
+	}
+
+	return &Machine[T]{
+		store:       store,
+		ttl:         ttl,
+		transitions: allowed,
+		now:         time.Now,
+	}
+}
+
+// KeyOf returns session key of chat the update belongs to.
+func KeyOf(c *bot.Context) (Key, error) {
+	chatID, err := c.ChatID()
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{BotID: c.Bot.ID(), ChatID: chatID}, nil
+}
+
+// Load returns session of key.
+// Missing or expired session is returned in Idle state with zero data.
+func (m *Machine[T]) Load(ctx context.Context, key Key) (*Session[T], error) {
+	r, err := m.store.Load(ctx, key, m.now())
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return &Session[T]{Key: key, State: Idle}, nil
+		}
+		return nil, fmt.Errorf("load session: %w", err)
+	}
+
+	s := &Session[T]{
+		Key:     key,
+		State:   State(r.State),
+		version: r.Version,
+	}
+	if len(r.Data) > 0 {
+		if err := json.Unmarshal(r.Data, &s.Data); err != nil {
+			return nil, fmt.Errorf("decode session data: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// LoadFor returns session of chat the update belongs to.
+func (m *Machine[T]) LoadFor(c *bot.Context) (*Session[T], error) {
+	key, err := KeyOf(c)
+	if err != nil {
+		return nil, err
+	}
+	return m.Load(c.Context(), key)
+}
+
+// Transition moves session to state to and saves it with its data.
+// Staying in the same state is always allowed and refreshes TTL.
+//
+// Returns ErrInvalidTransition if transition is not defined
+// and ErrConflict if session was saved by someone else since load.
+func (m *Machine[T]) Transition(ctx context.Context, s *Session[T], to State) error {
+	if to == Idle {
+		return m.Finish(ctx, s)
+	}
+
+	if to != s.State && !m.transitions[s.State][to] {
+		return fmt.Errorf("%w: %q -> %q", ErrInvalidTransition, s.State, to)
+	}
+
+	data, err := json.Marshal(s.Data)
+	if err != nil {
+		return fmt.Errorf("encode session data: %w", err)
+	}
+
+	now := m.now()
+	version, err := m.store.Save(ctx, Record{
+		Key:       s.Key,
+		State:     string(to),
+		Data:      data,
+		Version:   s.version,
+		ExpiresAt: now.Add(m.ttl),
+	}, now)
+	if err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+
+	s.State = to
+	s.version = version
+	return nil
+}
+
+// Finish ends conversation and resets session to Idle.
+//
+// Returns ErrConflict if session was saved by someone else since load,
+// session is left unchanged then.
+func (m *Machine[T]) Finish(ctx context.Context, s *Session[T]) error {
+	if err := m.store.Delete(ctx, s.Key, s.version, m.now()); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+
+	var zero T
+	s.State = Idle
+	s.Data = zero
+	s.version = 0
+	return nil
+}
@@ -0,0 +1,247 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapStore is a minimal Store, storage backends are in storage packages.
+type mapStore struct {
+	mu      sync.Mutex
+	records map[Key]Record
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{records: make(map[Key]Record)}
+}
+
+func (s *mapStore) Load(ctx context.Context, key Key, now time.Time) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[key]
+	if !ok || r.Expired(now) {
+		return Record{}, ErrNotFound
+	}
+	return r, nil
+}
+
+func (s *mapStore) Save(ctx context.Context, r Record, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current int
+	if stored, ok := s.records[r.Key]; ok && !stored.Expired(now) {
+		current = stored.Version
+	}
+	if r.Version != current {
+		return 0, ErrConflict
+	}
+
+	r.Version++
+	s.records[r.Key] = r
+	return r.Version, nil
+}
+
+func (s *mapStore) Delete(ctx context.Context, key Key, version int, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current int
+	if stored, ok := s.records[key]; ok && !stored.Expired(now) {
+		current = stored.Version
+	}
+	if version != current {
+		return ErrConflict
+	}
+
+	delete(s.records, key)
+	return nil
+}
+
+func (s *mapStore) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+const (
+	askAddress State = "ask_address"
+	askPhone   State = "ask_phone"
+	confirm    State = "confirm"
+)
+
+type checkout struct {
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+}
+
+func newCheckout(store Store) *Machine[checkout] {
+	return NewMachine[checkout](store, time.Hour, Transitions{
+		Idle:       {askAddress},
+		askAddress: {askPhone},
+		askPhone:   {confirm, askAddress},
+	})
+}
+
+var key = Key{BotID: "bot", ChatID: 42}
+
+func TestMachineTransitions(t *testing.T) {
+	m := newCheckout(newMapStore())
+	ctx := context.Background()
+
+	s, err := m.Load(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State != Idle {
+		t.Fatalf("expected idle session, got %q", s.State)
+	}
+
+	if err := m.Transition(ctx, s, askAddress); err != nil {
+		t.Fatal(err)
+	}
+	s.Data.Address = "Main st. 1"
+	if err := m.Transition(ctx, s, askPhone); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := m.Load(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.State != askPhone || loaded.Data.Address != "Main st. 1" {
+		t.Fatalf("unexpected session %+v", loaded)
+	}
+
+	err = m.Transition(ctx, loaded, askAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Transition(ctx, loaded, confirm)
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+	if loaded.State != askAddress {
+		t.Fatalf("failed transition must not change state, got %q", loaded.State)
+	}
+
+	if err := m.Finish(ctx, loaded); err != nil {
+		t.Fatal(err)
+	}
+	s, _ = m.Load(ctx, key)
+	if s.State != Idle || s.Data.Address != "" {
+		t.Fatalf("expected reset session, got %+v", s)
+	}
+}
+
+func TestMachineConflict(t *testing.T) {
+	m := newCheckout(newMapStore())
+	ctx := context.Background()
+
+	s, _ := m.Load(ctx, key)
+	if err := m.Transition(ctx, s, askAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := m.Load(ctx, key)
+	second, _ := m.Load(ctx, key)
+
+	if err := m.Transition(ctx, first, askPhone); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Transition(ctx, second, askPhone); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+
+	// two chats starting conversation at once
+	a, _ := m.Load(ctx, Key{BotID: "bot", ChatID: 1})
+	b, _ := m.Load(ctx, Key{BotID: "bot", ChatID: 1})
+	if err := m.Transition(ctx, a, askAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Transition(ctx, b, askAddress); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+}
+
+func TestMachineFinishConflict(t *testing.T) {
+	m := newCheckout(newMapStore())
+	ctx := context.Background()
+
+	s, _ := m.Load(ctx, key)
+	if err := m.Transition(ctx, s, askAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	stale, _ := m.Load(ctx, key)
+	if err := m.Transition(ctx, s, askPhone); err != nil {
+		t.Fatal(err)
+	}
+
+	// stale update must not end conversation moved on meanwhile
+	if err := m.Finish(ctx, stale); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if stale.State != askAddress {
+		t.Fatalf("failed finish must not change session, got %q", stale.State)
+	}
+	if loaded, _ := m.Load(ctx, key); loaded.State != askPhone {
+		t.Fatalf("expected session kept, got %q", loaded.State)
+	}
+
+	// idle session may not finish conversation started meanwhile
+	idle, _ := m.Load(ctx, Key{BotID: "bot", ChatID: 2})
+	started, _ := m.Load(ctx, Key{BotID: "bot", ChatID: 2})
+	if err := m.Transition(ctx, started, askAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Finish(ctx, idle); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+
+	if err := m.Finish(ctx, started); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMachineExpiry(t *testing.T) {
+	m := newCheckout(newMapStore())
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	s, _ := m.Load(ctx, key)
+	if err := m.Transition(ctx, s, askAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(59 * time.Minute)
+	s, _ = m.Load(ctx, key)
+	if s.State != askAddress {
+		t.Fatalf("session expired too early")
+	}
+	// saving refreshes TTL
+	if err := m.Transition(ctx, s, askAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(59 * time.Minute)
+	s, _ = m.Load(ctx, key)
+	if s.State != askAddress {
+		t.Fatalf("TTL was not refreshed")
+	}
+
+	now = now.Add(time.Hour)
+	s, _ = m.Load(ctx, key)
+	if s.State != Idle {
+		t.Fatalf("expected expired session, got %q", s.State)
+	}
+
+	// expired session may be replaced by a new one
+	if err := m.Transition(ctx, s, askAddress); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,33 @@
+// Package session keeps per-chat conversation state of bots.
+//
+// Multi-step interactions (checkout, admin editing, support)
+// are described as a finite state machine, see Machine.
+// State is stored in Store with TTL and optimistic versioning,
+// so concurrent updates of the same chat do not overwrite each other.
+//
+// Store contract is declared by storage package,
+// so storage backends do not depend on bot transport.
+package session
+
+import (
+	"errors"
+
+	"botmanager/internal/storage"
+)
+
+var (
+	ErrNotFound = storage.ErrSessionNotFound
+	// ErrConflict is returned when session was changed
+	// by another update since it was loaded.
+	ErrConflict          = storage.ErrSessionConflict
+	ErrInvalidTransition = errors.New("invalid session transition")
+)
+
+type (
+	// Key identifies conversation of a chat with a bot.
+	Key = storage.SessionKey
+	// Record is a stored session.
+	Record = storage.SessionRecord
+	// Store persists session records.
+	Store = storage.SessionStore
+)
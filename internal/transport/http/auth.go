@@ -0,0 +1,43 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"botmanager/internal/tenant"
+)
+
+// AdminTokens maps admin API token to shop it manages.
+// Zero shop means admin of the instance, managing every shop.
+type AdminTokens map[string]int
+
+// AdminAuth allows only requests with one of admin API tokens
+// in "Authorization: Bearer <token>" header and binds request
+// context to shop of the token, see tenant.WithShop.
+//
+// Empty tokens are ignored, so admin routes are closed
+// unless a token is configured.
+func AdminAuth(tokens AdminTokens) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+			shopID, found := 0, false
+			// every token is compared, so timing does not tell which one matched
+			for token, id := range tokens {
+				if token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+					shopID, found = id, true
+				}
+			}
+
+			if !ok || !found {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenant.WithShop(r.Context(), shopID)))
+		})
+	}
+}
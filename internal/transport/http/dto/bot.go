@@ -0,0 +1,54 @@
+package dto
+
+import "time"
+
+type RegisterBotRequest struct {
+	Name   string `json:"name"`
+	Token  string `json:"token"`
+	ShopID int    `json:"shop_id"`
+}
+
+type BotResponse struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	DisplayName   string     `json:"display_name"`
+	Username      string     `json:"username,omitempty"`
+	TelegramID    int64      `json:"telegram_id,omitempty"`
+	Capabilities  []string   `json:"capabilities"`
+	TokenHint     string     `json:"token_hint"`
+	Status        string     `json:"status"`
+	LastError     string     `json:"last_error,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	UptimeSeconds int64      `json:"uptime_seconds"`
+	Restarts      int        `json:"restarts"`
+	ShopID        int        `json:"shop_id,omitempty"`
+	Storefront    Storefront `json:"storefront"`
+}
+
+// Storefront limits catalog of bot, empty lists mean no limit.
+type Storefront struct {
+	CityIDs        []int  `json:"city_ids"`
+	CategoryIDs    []int  `json:"category_ids"`
+	WelcomeText    string `json:"welcome_text"`
+	SupportContact string `json:"support_contact"`
+}
+
+// UpdateBotRequest changes only fields which are set.
+type UpdateBotRequest struct {
+	Name           *string     `json:"name"`
+	AllowedUpdates *[]string   `json:"allowed_updates"`
+	Storefront     *Storefront `json:"storefront"`
+}
+
+type CrashResponse struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+	Stack string    `json:"stack,omitempty"`
+}
+
+type LogRecordResponse struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs"`
+}
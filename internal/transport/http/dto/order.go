@@ -2,8 +2,8 @@
 package dto
 
 type OrderReponse struct {
-	ID         int   `json:"id"`
-	CustomerID int   `json:"customer_id"`
-	ProductID  int   `json:"product_id"`
-	Price      int64 `json:"price"`
+	ID         int    `json:"id"`
+	CustomerID int    `json:"customer_id"`
+	Status     string `json:"status"`
+	Total      int64  `json:"total"`
 }
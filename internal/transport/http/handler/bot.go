@@ -0,0 +1,536 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"botmanager/internal/manager"
+	"botmanager/internal/tenant"
+	"botmanager/internal/transport/http/dto"
+)
+
+// BotHandler handles HTTP requests managing bots of manager.Manager.
+//
+// Bots are addressed by manager.BotID, raw tokens
+// are accepted on registration only and never returned.
+// Request bound to shop, see tenant.WithShop, manages only
+// bots of that shop, bots of other shops are not found.
+type BotHandler struct {
+	manager *manager.Manager
+}
+
+// NewBotHandler creates a new bot handler.
+func NewBotHandler(m *manager.Manager) *BotHandler {
+	if m == nil {
+		panic("handler: Manager is nil")
+	}
+
+	return &BotHandler{manager: m}
+}
+
+// Register handles bot registration.
+//
+// Expects JSON body:
+//
+//	{
+//	  "name": string,
+//	  "token": string,
+//	  "shop_id": int // optional
+//	}
+//
+// Request bound to shop registers bot in its shop,
+// shop_id of another shop is 403 Forbidden.
+//
+// Returns registered bot as JSON, 409 Conflict if token is already registered.
+func (h *BotHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req dto.RegisterBotRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Name == "" || req.Token == "" {
+		http.Error(w, "name and token are required", http.StatusBadRequest)
+		return
+	}
+
+	if req.ShopID < 0 {
+		http.Error(w, "shop_id must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	if shopID, ok := tenant.ShopID(r.Context()); ok {
+		if req.ShopID != 0 && req.ShopID != shopID {
+			http.Error(w, "shop_id of another shop", http.StatusForbidden)
+			return
+		}
+		req.ShopID = shopID
+	}
+
+	if err := h.manager.RegisterInShop(req.Name, req.Token, req.ShopID); err != nil {
+		writeBotError(w, err)
+		return
+	}
+
+	state, ok := h.manager.Status(manager.BotID(req.Token))
+	if !ok {
+		// removed right after registration
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(h.botResponse(state))
+}
+
+// List returns all bots with their status ordered by name.
+//
+// Query param:
+//
+//	shop_id - return only bots of shop (optional)
+func (h *BotHandler) List(w http.ResponseWriter, r *http.Request) {
+	states := slices.DeleteFunc(h.manager.Statuses(), func(s manager.BotState) bool {
+		return !tenant.Allows(r.Context(), s.Bot.ShopID)
+	})
+
+	if raw := r.URL.Query().Get("shop_id"); raw != "" {
+		shopID, err := strconv.Atoi(raw)
+		if err != nil || shopID <= 0 {
+			http.Error(w, "invalid shop_id", http.StatusBadRequest)
+			return
+		}
+
+		states = slices.DeleteFunc(states, func(s manager.BotState) bool {
+			return s.Bot.ShopID != shopID
+		})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Bot.Name != states[j].Bot.Name {
+			return states[i].Bot.Name < states[j].Bot.Name
+		}
+		return states[i].Bot.ID < states[j].Bot.ID
+	})
+
+	resp := make([]dto.BotResponse, 0, len(states))
+	for _, state := range states {
+		resp = append(resp, h.botResponse(state))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Get returns bot with its status.
+//
+// Path param:
+//
+//	id - bot identifier
+func (h *BotHandler) Get(w http.ResponseWriter, r *http.Request) {
+	state, ok := h.manager.Status(chi.URLParam(r, "id"))
+	if !ok || !tenant.Allows(r.Context(), state.Bot.ShopID) {
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.botResponse(state))
+}
+
+// Crashes returns latest crashes of bot, oldest first.
+//
+// Path param:
+//
+//	id - bot identifier
+func (h *BotHandler) Crashes(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.botID(r)
+	if !ok {
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	crashes, ok := h.manager.Crashes(id)
+	if !ok {
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	resp := make([]dto.CrashResponse, 0, len(crashes))
+	for _, c := range crashes {
+		resp = append(resp, dto.CrashResponse{
+			Time:  c.Time.UTC(),
+			Error: c.Err.Error(),
+			Stack: c.Stack,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Logs returns latest log records of bot, oldest first.
+//
+// Path param:
+//
+//	id - bot identifier
+//
+// Query params:
+//
+//	level - minimal level: debug, info, warn or error (optional, default debug)
+//	limit - number of latest records (optional, default all kept)
+func (h *BotHandler) Logs(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.botID(r)
+	if !ok {
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	level, ok := parseLevel(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	records, ok := h.manager.Logs(id, manager.LogQuery{
+		MinLevel: level,
+		Limit:    limit,
+	})
+	if !ok {
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	resp := make([]dto.LogRecordResponse, 0, len(records))
+	for _, rec := range records {
+		resp = append(resp, logRecordResponse(rec))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// StreamLogs streams new log records of bot as server-sent events
+// until client disconnects or bot is removed.
+//
+// Path param:
+//
+//	id - bot identifier
+//
+// Query param:
+//
+//	level - minimal level: debug, info, warn or error (optional, default debug)
+//
+// Every record is sent as "log" event with JSON data.
+func (h *BotHandler) StreamLogs(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.botID(r)
+	if !ok {
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	level, ok := parseLevel(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	records, cancel, ok := h.manager.TailLogs(id, level, 0)
+	if !ok {
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// comments keep idle connection open through proxies
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+
+		case rec, open := <-records:
+			if !open {
+				return
+			}
+
+			data, err := json.Marshal(logRecordResponse(rec))
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: log\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// Update changes config of running bot without restart.
+//
+// Expects JSON body, omitted fields are kept:
+//
+//	{
+//	  "name": string,
+//	  "allowed_updates": [string],
+//	  "storefront": {
+//	    "city_ids": [int],
+//	    "category_ids": [int],
+//	    "welcome_text": string,
+//	    "support_contact": string
+//	  }
+//	}
+//
+// Returns updated bot as JSON.
+func (h *BotHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.botID(r)
+	if !ok {
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	var req dto.UpdateBotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cfg, ok := h.manager.Config(id)
+	if !ok {
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	if req.Name != nil {
+		cfg.Name = *req.Name
+	}
+	if req.AllowedUpdates != nil {
+		cfg.AllowedUpdates = *req.AllowedUpdates
+	}
+	if sf := req.Storefront; sf != nil {
+		cfg.Storefront = manager.Storefront{
+			CityIDs:        sf.CityIDs,
+			CategoryIDs:    sf.CategoryIDs,
+			WelcomeText:    strings.TrimSpace(sf.WelcomeText),
+			SupportContact: strings.TrimSpace(sf.SupportContact),
+		}
+	}
+
+	if err := h.manager.Update(id, cfg); err != nil {
+		writeBotError(w, err)
+		return
+	}
+
+	h.Get(w, r)
+}
+
+// Pause stops bot until it is resumed.
+//
+// Returns 204 No Content on success and 202 Accepted
+// if bot is still stopping when request is done.
+func (h *BotHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.do(w, r, h.manager.Pause)
+}
+
+// Resume starts paused bot.
+//
+// Returns 204 No Content on success, 409 Conflict if bot is not paused.
+func (h *BotHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.do(w, r, func(_ context.Context, id string) error {
+		return h.manager.Resume(id)
+	})
+}
+
+// Restart restarts bot runner.
+//
+// Returns 204 No Content on success and 202 Accepted
+// if bot is still stopping when request is done.
+func (h *BotHandler) Restart(w http.ResponseWriter, r *http.Request) {
+	h.do(w, r, h.manager.Restart)
+}
+
+// Remove stops bot and removes it.
+//
+// Returns 204 No Content on success and 202 Accepted
+// if bot is still stopping when request is done.
+func (h *BotHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	h.do(w, r, h.manager.Remove)
+}
+
+// do applies operation to bot addressed by id path param
+// within request context.
+//
+// Bot still stopping when request is done is reported
+// with 202 Accepted.
+func (h *BotHandler) do(w http.ResponseWriter, r *http.Request, op func(ctx context.Context, id string) error) {
+	id, ok := h.botID(r)
+	if !ok {
+		http.Error(w, manager.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	err := op(r.Context(), id)
+
+	var stopErr *manager.StopTimeoutError
+	if errors.As(err, &stopErr) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if err != nil {
+		writeBotError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// botID returns id path param of bot visible within request.
+func (h *BotHandler) botID(r *http.Request) (string, bool) {
+	id := chi.URLParam(r, "id")
+
+	bot, ok := h.manager.Bot(id)
+	if !ok || !tenant.Allows(r.Context(), bot.ShopID) {
+		return "", false
+	}
+	return id, true
+}
+
+func writeBotError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, manager.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, manager.ErrInvalidConfig),
+		errors.Is(err, manager.ErrInvalidToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, manager.ErrDuplicationToken),
+		errors.Is(err, manager.ErrInvalidState):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *BotHandler) botResponse(state manager.BotState) dto.BotResponse {
+	resp := dto.BotResponse{
+		ID:            state.Bot.ID,
+		Name:          state.Bot.Name,
+		DisplayName:   state.Bot.DisplayName(),
+		Username:      state.Bot.Identity.Username,
+		TelegramID:    state.Bot.Identity.TelegramID,
+		Capabilities:  capabilities(state.Bot.Identity),
+		TokenHint:     state.Bot.TokenHint,
+		Status:        state.Status.String(),
+		UptimeSeconds: int64(state.Uptime.Seconds()),
+		Restarts:      state.Restarts,
+		ShopID:        state.Bot.ShopID,
+	}
+
+	if state.LastError != nil {
+		resp.LastError = state.LastError.Error()
+	}
+
+	if !state.StartedAt.IsZero() {
+		startedAt := state.StartedAt.UTC()
+		resp.StartedAt = &startedAt
+	}
+
+	// bot removed meanwhile keeps empty storefront
+	cfg, _ := h.manager.Config(state.Bot.ID)
+	resp.Storefront = dto.Storefront{
+		CityIDs:        nonNil(cfg.Storefront.CityIDs),
+		CategoryIDs:    nonNil(cfg.Storefront.CategoryIDs),
+		WelcomeText:    cfg.Storefront.WelcomeText,
+		SupportContact: cfg.Storefront.SupportContact,
+	}
+
+	return resp
+}
+
+// sseKeepAlive is interval of keep-alive comments of event streams.
+const sseKeepAlive = 15 * time.Second
+
+// parseLevel reads level query param,
+// it writes 400 Bad Request for unknown level.
+func parseLevel(w http.ResponseWriter, r *http.Request) (slog.Level, bool) {
+	raw := r.URL.Query().Get("level")
+	if raw == "" {
+		return slog.LevelDebug, true
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		http.Error(w, "invalid level", http.StatusBadRequest)
+		return 0, false
+	}
+	return level, true
+}
+
+func logRecordResponse(rec manager.LogRecord) dto.LogRecordResponse {
+	return dto.LogRecordResponse{
+		Time:    rec.Time.UTC(),
+		Level:   rec.Level.String(),
+		Message: rec.Message,
+		Attrs:   rec.Attrs,
+	}
+}
+
+// nonNil makes empty ids encoded as [] instead of null.
+func nonNil(ids []int) []int {
+	if ids == nil {
+		return []int{}
+	}
+	return ids
+}
+
+// capabilities lists Telegram capabilities of bot account.
+func capabilities(identity manager.Identity) []string {
+	caps := []string{}
+	if identity.CanJoinGroups {
+		caps = append(caps, "can_join_groups")
+	}
+	if identity.CanReadAllGroupMessages {
+		caps = append(caps, "can_read_all_group_messages")
+	}
+	if identity.SupportsInlineQueries {
+		caps = append(caps, "supports_inline_queries")
+	}
+	return caps
+}
@@ -0,0 +1,156 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"botmanager/internal/manager"
+	"botmanager/internal/tenant"
+	"botmanager/internal/transport/http/dto"
+	"botmanager/internal/transport/http/handler"
+)
+
+// blockingRunner runs bot until it is stopped.
+type blockingRunner struct{}
+
+func (blockingRunner) Run(ctx context.Context, token string) error {
+	<-ctx.Done()
+	return nil
+}
+
+const (
+	tokenOfShop1 = "111:shop-one"
+	tokenOfShop2 = "222:shop-two"
+)
+
+// newShopBots returns manager running bot of shop 1 and bot of shop 2.
+func newShopBots(t *testing.T) *manager.Manager {
+	t.Helper()
+
+	m := manager.NewManager(blockingRunner{})
+	t.Cleanup(func() { m.StopAll(context.Background()) })
+
+	if err := m.RegisterInShop("one", tokenOfShop1, 1); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := m.RegisterInShop("two", tokenOfShop2, 2); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	return m
+}
+
+// newBotRouter serves bot handler to requests bound to shop,
+// zero shop leaves them unbound.
+func newBotRouter(m *manager.Manager, shopID int) http.Handler {
+	h := handler.NewBotHandler(m)
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(tenant.WithShop(r.Context(), shopID)))
+		})
+	})
+	r.Post("/bots", h.Register)
+	r.Get("/bots", h.List)
+	r.Get("/bots/{id}", h.Get)
+	r.Get("/bots/{id}/crashes", h.Crashes)
+	r.Get("/bots/{id}/logs", h.Logs)
+	r.Patch("/bots/{id}", h.Update)
+	r.Delete("/bots/{id}", h.Remove)
+	r.Post("/bots/{id}/pause", h.Pause)
+	r.Post("/bots/{id}/resume", h.Resume)
+	r.Post("/bots/{id}/restart", h.Restart)
+	return r
+}
+
+func listBots(t *testing.T, h http.Handler) []dto.BotResponse {
+	t.Helper()
+
+	rec := serve(h, http.MethodGet, "/bots", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp []dto.BotResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestBotHandler_ListByShop(t *testing.T) {
+	m := newShopBots(t)
+
+	if bots := listBots(t, newBotRouter(m, 0)); len(bots) != 2 {
+		t.Fatalf("expected bots of every shop, got %+v", bots)
+	}
+
+	bots := listBots(t, newBotRouter(m, 1))
+	if len(bots) != 1 || bots[0].ShopID != 1 {
+		t.Fatalf("expected only bot of shop 1, got %+v", bots)
+	}
+}
+
+func TestBotHandler_BotOfAnotherShop(t *testing.T) {
+	m := newShopBots(t)
+	h := newBotRouter(m, 1)
+	other := "/bots/" + manager.BotID(tokenOfShop2)
+
+	tests := []struct {
+		method, target, body string
+	}{
+		{http.MethodGet, other, ""},
+		{http.MethodGet, other + "/crashes", ""},
+		{http.MethodGet, other + "/logs", ""},
+		{http.MethodPatch, other, `{"name":"stolen"}`},
+		{http.MethodPost, other + "/pause", ""},
+		{http.MethodPost, other + "/resume", ""},
+		{http.MethodPost, other + "/restart", ""},
+		{http.MethodDelete, other, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			if rec := serve(h, tt.method, tt.target, tt.body); rec.Code != http.StatusNotFound {
+				t.Fatalf("expected 404, got %d: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+
+	state, ok := m.Status(manager.BotID(tokenOfShop2))
+	running := state.Status == manager.StatusStarting || state.Status == manager.StatusRunning
+	if !ok || !running || state.Bot.Name != "two" {
+		t.Fatalf("bot of another shop must stay untouched, got %+v", state)
+	}
+
+	if rec := serve(h, http.MethodGet, "/bots/"+manager.BotID(tokenOfShop1), ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for bot of own shop, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestBotHandler_RegisterInShop(t *testing.T) {
+	m := newShopBots(t)
+	h := newBotRouter(m, 1)
+
+	rec := serve(h, http.MethodPost, "/bots", `{"name":"other","token":"333:other","shop_id":2}`)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(h, http.MethodPost, "/bots", `{"name":"three","token":"333:three"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp dto.BotResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.ShopID != 1 {
+		t.Fatalf("expected bot registered in shop of request, got %+v", resp)
+	}
+}
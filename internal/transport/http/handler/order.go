@@ -5,6 +5,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -42,10 +43,11 @@ type createRequest struct {
 //
 //	{
 //	  "customer_id": int,
-//		"product_id": int
+//	  "product_id": int,
+//	  "variant_id": int
 //	}
 //
-// Returns created order as JSON.
+// Returns created order as JSON, 409 Conflict if stock is insufficient.
 func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req createRequest
 
@@ -54,24 +56,20 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := h.service.Create(r.Context(), req.CustomerID, req.ProductID, req.VariantID)
+	order, err := h.service.CreateForVariant(r.Context(), req.CustomerID, req.ProductID, req.VariantID)
 	if err != nil {
-		if errors.Is(err, domain.ErrProductNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeOrderError(w, err)
 		return
 	}
 
 	resp := dto.OrderReponse{
 		ID:         order.ID(),
-		CustomerID: order.CustomerID(),
-		ProductID:  order.ProductID(),
-		Price:      order.Price(),
+		CustomerID: order.UserID(),
+		Status:     string(order.Status()),
+		Total:      order.Total(),
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(resp)
 }
@@ -84,19 +82,7 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 //
 // Returns 204 No Content on success.
 func (h *OrderHandler) Confirm(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "invalid order id", http.StatusBadRequest)
-		return
-	}
-
-	if err := h.service.Confirm(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
+	h.do(w, r, h.service.ConfirmPayment)
 }
 
 // Cancel handles order cancellation.
@@ -107,13 +93,40 @@ func (h *OrderHandler) Confirm(w http.ResponseWriter, r *http.Request) {
 //
 // Returns 204 No Content on success.
 func (h *OrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, _ := strconv.Atoi(idStr)
+	h.do(w, r, h.service.Cancel)
+}
 
-	if err := h.service.Cancel(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+// do applies operation to order addressed by id path param.
+func (h *OrderHandler) do(
+	w http.ResponseWriter,
+	r *http.Request,
+	op func(ctx context.Context, id int) error,
+) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid order id", http.StatusBadRequest)
+		return
+	}
+
+	if err := op(r.Context(), id); err != nil {
+		writeOrderError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func writeOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound),
+		errors.Is(err, domain.ErrProductNotFound),
+		errors.Is(err, domain.ErrVariantNotFound),
+		errors.Is(err, domain.ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInsufficientStock),
+		errors.Is(err, domain.ErrOrderTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
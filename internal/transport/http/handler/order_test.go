@@ -0,0 +1,126 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/service"
+	"botmanager/internal/storage/memory"
+	"botmanager/internal/transport/http/dto"
+	"botmanager/internal/transport/http/handler"
+)
+
+type nopEventBus struct{}
+
+func (nopEventBus) Publish(context.Context, ...domain.Event) error { return nil }
+
+// newOrderRouter serves order handler backed by in-memory
+// repositories with product 1 having variant 1000 and given stock.
+func newOrderRouter(t *testing.T, quantity int) http.Handler {
+	t.Helper()
+	ctx := context.Background()
+
+	products := memory.NewProductRepository(&sync.Mutex{})
+	categoryID := 1
+	_ = products.Create(ctx, domain.NewProductFromDB(1, &categoryID, "Arabica", "", nil, 1, []domain.ProductVariant{
+		*domain.NewProductVariantFromDB(1000, "250g", 10, 1500, nil),
+	}))
+
+	stocks := memory.NewStockRepository()
+	stock, err := domain.NewStock(1, 1000, quantity)
+	if err != nil {
+		t.Fatalf("new stock: %v", err)
+	}
+	_ = stocks.Save(ctx, stock)
+
+	orders := service.NewOrderService(
+		products,
+		memory.NewOrderRepository(),
+		memory.NewUserRepository(),
+		stocks,
+		nopEventBus{},
+		memory.NewTxManager(&sync.Mutex{}),
+		nil,
+	)
+	h := handler.NewOrderHandler(orders)
+
+	r := chi.NewRouter()
+	r.Post("/orders", h.Create)
+	r.Post("/orders/{id}/confirm", h.Confirm)
+	r.Post("/orders/{id}/cancel", h.Cancel)
+	return r
+}
+
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestOrderHandler_Create(t *testing.T) {
+	h := newOrderRouter(t, 1)
+
+	rec := serve(h, http.MethodPost, "/orders", `{"customer_id":7,"product_id":1,"variant_id":1000}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp dto.OrderReponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.ID == 0 || resp.CustomerID != 7 || resp.Status != "pending" || resp.Total != 1500 {
+		t.Fatalf("unexpected order %+v", resp)
+	}
+}
+
+func TestOrderHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		want   int
+	}{
+		{"malformed body", "/orders", `{`, http.StatusBadRequest},
+		{"unknown product", "/orders", `{"customer_id":7,"product_id":2,"variant_id":1000}`, http.StatusNotFound},
+		{"unknown variant", "/orders", `{"customer_id":7,"product_id":1,"variant_id":1}`, http.StatusNotFound},
+		{"insufficient stock", "/orders", `{"customer_id":7,"product_id":1,"variant_id":1000}`, http.StatusConflict},
+		{"invalid id", "/orders/x/confirm", ``, http.StatusBadRequest},
+		{"unknown order", "/orders/42/cancel", ``, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newOrderRouter(t, 0)
+
+			rec := serve(h, http.MethodPost, tt.target, tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_CancelTwice(t *testing.T) {
+	h := newOrderRouter(t, 1)
+
+	rec := serve(h, http.MethodPost, "/orders", `{"customer_id":7,"product_id":1,"variant_id":1000}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(h, http.MethodPost, "/orders/1/cancel", ``); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(h, http.MethodPost, "/orders/1/confirm", ``); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 paying cancelled order, got %d: %s", rec.Code, rec.Body)
+	}
+}
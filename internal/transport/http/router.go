@@ -13,21 +13,40 @@ import (
 	"botmanager/internal/transport/http/handler"
 )
 
+// WebhookPath is path where Telegram webhooks of all bots are mounted.
+const WebhookPath = "/telegram/webhook"
+
 // NewRouter configurates and returns HTTP router.
 //
 // It defines API routes, groups and middleware.
 // The router is responsible only for HTTP concerns.
-func NewRouter(orderHandler *handler.OrderHandler) http.Handler {
+//
+// webhook may be nil when bots use long polling.
+// Bot management routes require one of adminTokens, see AdminAuth.
+func NewRouter(
+	orderHandler *handler.OrderHandler,
+	botHandler *handler.BotHandler,
+	webhook http.Handler,
+	adminTokens AdminTokens,
+) http.Handler {
 	r := chi.NewRouter()
 
 	// ---- Global middleware ----
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
+	// ---- Telegram webhooks ----
+	// Mounted without request logger: path contains bot secret.
+	if webhook != nil {
+		// POST /telegram/webhook/{secret}
+		r.Post(WebhookPath+"/{secret}", webhook.ServeHTTP)
+	}
+
 	// ---- API grouping ----
 	r.Route("/api", func(r chi.Router) {
+		r.Use(middleware.Logger)
+
 		// Versioning group
 		r.Route("/v1", func(r chi.Router) {
 			// Orders endpoints
@@ -39,6 +58,34 @@ func NewRouter(orderHandler *handler.OrderHandler) http.Handler {
 				// POST /api/v1/orders/{id}/cancel
 				r.Post("/{id}/cancel", orderHandler.Cancel)
 			})
+
+			// Bots endpoints
+			r.Route("/bots", func(r chi.Router) {
+				r.Use(AdminAuth(adminTokens))
+
+				// POST /api/v1/bots
+				r.Post("/", botHandler.Register)
+				// GET /api/v1/bots
+				r.Get("/", botHandler.List)
+				// GET /api/v1/bots/{id}
+				r.Get("/{id}", botHandler.Get)
+				// GET /api/v1/bots/{id}/crashes
+				r.Get("/{id}/crashes", botHandler.Crashes)
+				// GET /api/v1/bots/{id}/logs
+				r.Get("/{id}/logs", botHandler.Logs)
+				// GET /api/v1/bots/{id}/logs/stream
+				r.Get("/{id}/logs/stream", botHandler.StreamLogs)
+				// PATCH /api/v1/bots/{id}
+				r.Patch("/{id}", botHandler.Update)
+				// DELETE /api/v1/bots/{id}
+				r.Delete("/{id}", botHandler.Remove)
+				// POST /api/v1/bots/{id}/pause
+				r.Post("/{id}/pause", botHandler.Pause)
+				// POST /api/v1/bots/{id}/resume
+				r.Post("/{id}/resume", botHandler.Resume)
+				// POST /api/v1/bots/{id}/restart
+				r.Post("/{id}/restart", botHandler.Restart)
+			})
 		})
 	})
 
@@ -0,0 +1,297 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"botmanager/internal/domain"
+	"botmanager/internal/manager"
+	"botmanager/internal/service"
+	"botmanager/internal/storage/memory"
+	transporthttp "botmanager/internal/transport/http"
+	"botmanager/internal/transport/http/dto"
+	"botmanager/internal/transport/http/handler"
+)
+
+const (
+	adminToken = "admin-secret"
+	shopToken  = "shop-secret"
+
+	botToken   = "111:bot"
+	crashToken = "999:crash"
+)
+
+// testRunner logs start of bot and runs it until it is stopped,
+// bot of crashToken fails at once.
+type testRunner struct{}
+
+func (testRunner) Run(ctx context.Context, token string) error {
+	manager.Logger(ctx, slog.Default()).Info("bot started")
+
+	if token == crashToken {
+		return errors.New("boom")
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+type nopEventBus struct{}
+
+func (nopEventBus) Publish(context.Context, ...domain.Event) error { return nil }
+
+// newRouter serves API with admin token of the instance
+// and token of shop 1.
+func newRouter(t *testing.T) (http.Handler, *manager.Manager) {
+	t.Helper()
+
+	m := manager.NewManager(testRunner{})
+	t.Cleanup(func() { m.StopAll(context.Background()) })
+
+	orders := service.NewOrderService(
+		memory.NewProductRepository(&sync.Mutex{}),
+		memory.NewOrderRepository(),
+		memory.NewUserRepository(),
+		memory.NewStockRepository(),
+		nopEventBus{},
+		memory.NewTxManager(&sync.Mutex{}),
+		nil,
+	)
+
+	router := transporthttp.NewRouter(
+		handler.NewOrderHandler(orders),
+		handler.NewBotHandler(m),
+		nil,
+		transporthttp.AdminTokens{adminToken: 0, shopToken: 1},
+	)
+	return router, m
+}
+
+// call serves request authorized by token, empty token sends no header.
+func call(h http.Handler, token, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.NewDecoder(rec.Body).Decode(&v); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return v
+}
+
+func expectCode(t *testing.T, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+
+	if rec.Code != want {
+		t.Fatalf("expected %d, got %d: %s", want, rec.Code, rec.Body)
+	}
+}
+
+func waitStatus(t *testing.T, m *manager.Manager, id string, want manager.BotStatus) {
+	t.Helper()
+
+	deadline := time.After(time.Second)
+	for {
+		state, ok := m.Status(id)
+		if ok && state.Status == want {
+			return
+		}
+
+		select {
+		case <-deadline:
+			t.Fatalf("expected status %s, got %s", want, state.Status)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	h, _ := newRouter(t)
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no header", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic " + adminToken, http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"empty token", "Bearer ", http.StatusUnauthorized},
+		{"admin token", "Bearer " + adminToken, http.StatusOK},
+		{"shop token", "Bearer " + shopToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/bots", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			expectCode(t, rec, tt.want)
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("expected WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestAdminAuthWithoutTokens(t *testing.T) {
+	// empty token is not configured token
+	h := transporthttp.AdminAuth(transporthttp.AdminTokens{"": 0})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("request must not pass")
+		}),
+	)
+
+	for _, header := range []string{"", "Bearer "} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", header)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		expectCode(t, rec, http.StatusUnauthorized)
+	}
+}
+
+func TestBotRoutes(t *testing.T) {
+	h, m := newRouter(t)
+	id := manager.BotID(botToken)
+
+	// register
+	rec := call(h, adminToken, http.MethodPost, "/api/v1/bots", `{"name":"coffee","token":"`+botToken+`"}`)
+	expectCode(t, rec, http.StatusCreated)
+	if bot := decode[dto.BotResponse](t, rec); bot.ID != id || bot.Name != "coffee" || strings.Contains(bot.TokenHint, "bot") {
+		t.Fatalf("unexpected registered bot %+v", bot)
+	}
+
+	rec = call(h, adminToken, http.MethodPost, "/api/v1/bots", `{"name":"again","token":"`+botToken+`"}`)
+	expectCode(t, rec, http.StatusConflict)
+
+	rec = call(h, adminToken, http.MethodPost, "/api/v1/bots", `{"name":""}`)
+	expectCode(t, rec, http.StatusBadRequest)
+
+	waitStatus(t, m, id, manager.StatusRunning)
+
+	// list
+	rec = call(h, adminToken, http.MethodGet, "/api/v1/bots", "")
+	expectCode(t, rec, http.StatusOK)
+	if bots := decode[[]dto.BotResponse](t, rec); len(bots) != 1 || bots[0].ID != id || bots[0].Status != "running" {
+		t.Fatalf("unexpected bots %+v", bots)
+	}
+
+	// pause and resume
+	expectCode(t, call(h, adminToken, http.MethodPost, "/api/v1/bots/"+id+"/pause", ""), http.StatusNoContent)
+	waitStatus(t, m, id, manager.StatusPaused)
+
+	expectCode(t, call(h, adminToken, http.MethodPost, "/api/v1/bots/"+id+"/resume", ""), http.StatusNoContent)
+	expectCode(t, call(h, adminToken, http.MethodPost, "/api/v1/bots/"+id+"/resume", ""), http.StatusConflict)
+	waitStatus(t, m, id, manager.StatusRunning)
+
+	// restart
+	expectCode(t, call(h, adminToken, http.MethodPost, "/api/v1/bots/"+id+"/restart", ""), http.StatusNoContent)
+	waitStatus(t, m, id, manager.StatusRunning)
+
+	// delete
+	expectCode(t, call(h, adminToken, http.MethodDelete, "/api/v1/bots/"+id, ""), http.StatusNoContent)
+	expectCode(t, call(h, adminToken, http.MethodGet, "/api/v1/bots/"+id, ""), http.StatusNotFound)
+	expectCode(t, call(h, adminToken, http.MethodDelete, "/api/v1/bots/"+id, ""), http.StatusNotFound)
+	expectCode(t, call(h, adminToken, http.MethodPost, "/api/v1/bots/"+id+"/pause", ""), http.StatusNotFound)
+	expectCode(t, call(h, adminToken, http.MethodPost, "/api/v1/bots/"+id+"/restart", ""), http.StatusNotFound)
+}
+
+func TestBotCrashAndLogRoutes(t *testing.T) {
+	h, m := newRouter(t)
+	id := manager.BotID(crashToken)
+
+	rec := call(h, adminToken, http.MethodPost, "/api/v1/bots", `{"name":"crash","token":"`+crashToken+`"}`)
+	expectCode(t, rec, http.StatusCreated)
+	waitStatus(t, m, id, manager.StatusFailed)
+
+	rec = call(h, adminToken, http.MethodGet, "/api/v1/bots/"+id+"/crashes", "")
+	expectCode(t, rec, http.StatusOK)
+	if crashes := decode[[]dto.CrashResponse](t, rec); len(crashes) != 1 || crashes[0].Error != "boom" {
+		t.Fatalf("unexpected crashes %+v", crashes)
+	}
+
+	rec = call(h, adminToken, http.MethodGet, "/api/v1/bots/"+id+"/logs?level=info", "")
+	expectCode(t, rec, http.StatusOK)
+	logs := decode[[]dto.LogRecordResponse](t, rec)
+	if len(logs) == 0 || !containsMessage(logs, "bot started") {
+		t.Fatalf("expected log of runner, got %+v", logs)
+	}
+
+	rec = call(h, adminToken, http.MethodGet, "/api/v1/bots/"+id+"/logs?limit=1", "")
+	expectCode(t, rec, http.StatusOK)
+	if logs := decode[[]dto.LogRecordResponse](t, rec); len(logs) != 1 {
+		t.Fatalf("expected one latest record, got %+v", logs)
+	}
+
+	expectCode(t, call(h, adminToken, http.MethodGet, "/api/v1/bots/"+id+"/logs?level=loud", ""), http.StatusBadRequest)
+	expectCode(t, call(h, adminToken, http.MethodGet, "/api/v1/bots/"+id+"/logs?limit=-1", ""), http.StatusBadRequest)
+	expectCode(t, call(h, adminToken, http.MethodGet, "/api/v1/bots/unknown/crashes", ""), http.StatusNotFound)
+	expectCode(t, call(h, adminToken, http.MethodGet, "/api/v1/bots/unknown/logs", ""), http.StatusNotFound)
+
+	// routes stay closed without token
+	expectCode(t, call(h, "", http.MethodGet, "/api/v1/bots/"+id+"/crashes", ""), http.StatusUnauthorized)
+	expectCode(t, call(h, "", http.MethodGet, "/api/v1/bots/"+id+"/logs", ""), http.StatusUnauthorized)
+}
+
+func TestShopTokenSeesOnlyItsShop(t *testing.T) {
+	h, m := newRouter(t)
+
+	if err := m.RegisterInShop("other", "222:other", 2); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	other := manager.BotID("222:other")
+
+	rec := call(h, shopToken, http.MethodPost, "/api/v1/bots", `{"name":"own","token":"`+botToken+`"}`)
+	expectCode(t, rec, http.StatusCreated)
+	if bot := decode[dto.BotResponse](t, rec); bot.ShopID != 1 {
+		t.Fatalf("expected bot of shop 1, got %+v", bot)
+	}
+
+	rec = call(h, shopToken, http.MethodGet, "/api/v1/bots", "")
+	expectCode(t, rec, http.StatusOK)
+	if bots := decode[[]dto.BotResponse](t, rec); len(bots) != 1 || bots[0].ID != manager.BotID(botToken) {
+		t.Fatalf("expected only bot of shop 1, got %+v", bots)
+	}
+
+	expectCode(t, call(h, shopToken, http.MethodDelete, "/api/v1/bots/"+other, ""), http.StatusNotFound)
+	expectCode(t, call(h, shopToken, http.MethodGet, "/api/v1/bots/"+other+"/logs", ""), http.StatusNotFound)
+
+	rec = call(h, adminToken, http.MethodGet, "/api/v1/bots", "")
+	if bots := decode[[]dto.BotResponse](t, rec); len(bots) != 2 {
+		t.Fatalf("expected admin of instance to see every bot, got %+v", bots)
+	}
+}
+
+func containsMessage(logs []dto.LogRecordResponse, msg string) bool {
+	for _, rec := range logs {
+		if rec.Message == msg {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS bots(
+  id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  bot_id TEXT NOT NULL UNIQUE,
+  name TEXT NOT NULL,
+  encrypted_token TEXT NOT NULL,
+  is_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_bots_is_enabled ON bots(is_enabled);
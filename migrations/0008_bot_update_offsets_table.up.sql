@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS bot_update_offsets(
+  bot_id TEXT PRIMARY KEY,
+  update_offset BIGINT NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS bot_sessions(
+  bot_id TEXT NOT NULL,
+  chat_id BIGINT NOT NULL,
+  state TEXT NOT NULL,
+  data JSONB NOT NULL DEFAULT '{}',
+  version INT NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (bot_id, chat_id)
+);
+
+CREATE INDEX IF NOT EXISTS bot_sessions_expires_at_idx ON bot_sessions(expires_at);
@@ -0,0 +1,2 @@
+-- Comma separated Telegram update types, empty means default set.
+ALTER TABLE bots ADD COLUMN IF NOT EXISTS allowed_updates TEXT NOT NULL DEFAULT '';
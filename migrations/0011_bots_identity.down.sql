@@ -0,0 +1,6 @@
+ALTER TABLE bots
+    DROP COLUMN IF EXISTS telegram_id,
+    DROP COLUMN IF EXISTS username,
+    DROP COLUMN IF EXISTS can_join_groups,
+    DROP COLUMN IF EXISTS can_read_all_group_messages,
+    DROP COLUMN IF EXISTS supports_inline_queries;
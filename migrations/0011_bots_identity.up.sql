@@ -0,0 +1,7 @@
+-- Bot account returned by getMe at registration, zero values mean unknown.
+ALTER TABLE bots
+    ADD COLUMN IF NOT EXISTS telegram_id BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS username TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS can_join_groups BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS can_read_all_group_messages BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS supports_inline_queries BOOLEAN NOT NULL DEFAULT FALSE;
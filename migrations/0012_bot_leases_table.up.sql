@@ -0,0 +1,6 @@
+-- Ownership of bots by manager instances, see manager.LeaseStore.
+CREATE TABLE IF NOT EXISTS bot_leases(
+  bot_id TEXT PRIMARY KEY,
+  owner TEXT NOT NULL,
+  expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Catalog scope and texts of bot, see manager.Storefront.
+ALTER TABLE bots ADD COLUMN IF NOT EXISTS storefront JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS users_shop_id_tg_id_key;
+ALTER TABLE users ADD CONSTRAINT users_tg_id_key UNIQUE (tg_id);
+
+DROP INDEX IF EXISTS categories_shop_id_name_key;
+ALTER TABLE categories ADD CONSTRAINT categories_name_key UNIQUE (name);
+
+DROP INDEX IF EXISTS cities_shop_id_name_key;
+ALTER TABLE cities ADD CONSTRAINT cities_name_key UNIQUE (name);
+
+ALTER TABLE bots DROP COLUMN IF EXISTS shop_id;
+ALTER TABLE users DROP COLUMN IF EXISTS shop_id;
+ALTER TABLE products DROP COLUMN IF EXISTS shop_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS shop_id;
+ALTER TABLE cities DROP COLUMN IF EXISTS shop_id;
+
+DROP TABLE IF EXISTS shops;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS shops(
+  id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  name TEXT NOT NULL,
+  is_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  version INT NOT NULL DEFAULT 1,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- NULL shop means data of the instance itself.
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS shop_id INT NULL REFERENCES shops(id) ON DELETE CASCADE;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS shop_id INT NULL REFERENCES shops(id) ON DELETE CASCADE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS shop_id INT NULL REFERENCES shops(id) ON DELETE CASCADE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS shop_id INT NULL REFERENCES shops(id) ON DELETE CASCADE;
+ALTER TABLE bots ADD COLUMN IF NOT EXISTS shop_id INT NULL REFERENCES shops(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_cities_shop_id ON cities(shop_id);
+CREATE INDEX IF NOT EXISTS idx_categories_shop_id ON categories(shop_id);
+CREATE INDEX IF NOT EXISTS idx_products_shop_id ON products(shop_id);
+CREATE INDEX IF NOT EXISTS idx_bots_shop_id ON bots(shop_id);
+
+-- Names and Telegram accounts are unique within a shop only.
+ALTER TABLE cities DROP CONSTRAINT IF EXISTS cities_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS cities_shop_id_name_key ON cities(COALESCE(shop_id, 0), name);
+
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS categories_shop_id_name_key ON categories(COALESCE(shop_id, 0), name);
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_tg_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_shop_id_tg_id_key ON users(COALESCE(shop_id, 0), tg_id);
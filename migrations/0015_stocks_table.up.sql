@@ -0,0 +1,10 @@
+-- Stock of variant, see domain.Stock. Variant is stocked
+-- in a single warehouse of its district.
+CREATE TABLE IF NOT EXISTS stocks(
+  id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  warehouse_id BIGINT NOT NULL,
+  variant_id BIGINT NOT NULL UNIQUE REFERENCES product_variants(id) ON DELETE CASCADE,
+  quantity INT NOT NULL CHECK (quantity >= 0),
+  reserved INT NOT NULL DEFAULT 0 CHECK (reserved >= 0 AND reserved <= quantity),
+  version INT NOT NULL DEFAULT 1
+);
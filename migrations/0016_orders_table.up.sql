@@ -0,0 +1,38 @@
+-- Order of user, see domain.Order.
+CREATE TABLE IF NOT EXISTS orders(
+  id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  -- NULL shop means order of the instance itself.
+  shop_id INT NULL REFERENCES shops(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL,
+  status TEXT NOT NULL,
+  total BIGINT NOT NULL,
+  refunded BIGINT NOT NULL DEFAULT 0 CHECK (refunded >= 0 AND refunded <= total),
+  version INT NOT NULL DEFAULT 1,
+
+  created_at TIMESTAMP NOT NULL,
+  expires_at TIMESTAMP NULL,
+  paid_at TIMESTAMP NULL,
+  cancelled_at TIMESTAMP NULL,
+  expired_at TIMESTAMP NULL,
+  processing_at TIMESTAMP NULL,
+  ready_at TIMESTAMP NULL,
+  delivered_at TIMESTAMP NULL,
+  completed_at TIMESTAMP NULL,
+  refunded_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_shop_id ON orders(shop_id);
+-- sweeper looks for pending orders past their deadline
+CREATE INDEX IF NOT EXISTS idx_orders_pending_expires_at ON orders(expires_at) WHERE status = 'pending';
+
+-- Items are snapshot of variants at purchase time,
+-- they are not bound to products which may change later.
+CREATE TABLE IF NOT EXISTS order_items(
+  order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  position INT NOT NULL,
+  product_id BIGINT NOT NULL,
+  variant_id BIGINT NOT NULL,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  unit_price BIGINT NOT NULL,
+  PRIMARY KEY (order_id, position)
+);
@@ -0,0 +1,230 @@
+// Package envelope provides envelope encryption of small secrets
+// with AES-GCM and key rotation support.
+//
+// Every secret is encrypted with a fresh random data key.
+// The data key itself is encrypted (wrapped) with a master key
+// from Keyring. Ciphertext carries identifier of the master key,
+// so old secrets stay readable after a new primary key is added.
+//
+// Ciphertext format:
+//
+//	v1.<key id>.<base64 wrapped data key>.<base64 sealed secret>
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	version    = "v1"
+	separator  = "."
+	keySize    = 32
+	partsCount = 4
+)
+
+var (
+	ErrInvalidKey        = errors.New("envelope: invalid key")
+	ErrUnknownKey        = errors.New("envelope: unknown key id")
+	ErrInvalidCiphertext = errors.New("envelope: invalid ciphertext")
+)
+
+// Keyring holds master keys by their identifiers.
+//
+// New secrets are always encrypted with primary key.
+// Any key in ring can be used for decryption.
+type Keyring struct {
+	primary string
+	keys    map[string][]byte
+}
+
+// NewKeyring creates keyring with primary key id and set of 32-byte keys.
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no keys", ErrInvalidKey)
+	}
+
+	ring := &Keyring{
+		primary: primary,
+		keys:    make(map[string][]byte, len(keys)),
+	}
+
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, separator) {
+			return nil, fmt.Errorf("%w: bad key id %q", ErrInvalidKey, id)
+		}
+
+		if len(key) != keySize {
+			return nil, fmt.Errorf("%w: key %q must be %d bytes", ErrInvalidKey, id, keySize)
+		}
+
+		ring.keys[id] = append([]byte(nil), key...)
+	}
+
+	if _, ok := ring.keys[primary]; !ok {
+		return nil, fmt.Errorf("%w: primary key %q", ErrUnknownKey, primary)
+	}
+
+	return ring, nil
+}
+
+// ParseKeys decodes base64 encoded keys, as they are stored in config.
+func ParseKeys(encoded map[string]string) (map[string][]byte, error) {
+	keys := make(map[string][]byte, len(encoded))
+
+	for id, value := range encoded {
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %v", ErrInvalidKey, id, err)
+		}
+		keys[id] = key
+	}
+
+	return keys, nil
+}
+
+// PrimaryKeyID returns identifier of key used for encryption.
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// Encrypt seals plaintext with a new data key wrapped by primary key.
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("generate data key: %w", err)
+	}
+
+	sealed, err := seal(dataKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	return k.wrap(dataKey, sealed)
+}
+
+// Decrypt opens ciphertext produced by Encrypt or Rewrap.
+func (k *Keyring) Decrypt(ciphertext string) ([]byte, error) {
+	_, dataKey, sealed, err := k.unwrap(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(dataKey, sealed)
+}
+
+// Rewrap re-encrypts data key of ciphertext with primary key.
+//
+// The secret itself is not decrypted. Use it to migrate
+// ciphertexts after primary key rotation.
+func (k *Keyring) Rewrap(ciphertext string) (string, error) {
+	keyID, dataKey, sealed, err := k.unwrap(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	if keyID == k.primary {
+		return ciphertext, nil
+	}
+
+	return k.wrap(dataKey, sealed)
+}
+
+// NeedsRewrap reports whether ciphertext is wrapped by non-primary key.
+func (k *Keyring) NeedsRewrap(ciphertext string) bool {
+	parts := strings.Split(ciphertext, separator)
+	return len(parts) == partsCount && parts[1] != k.primary
+}
+
+func (k *Keyring) wrap(dataKey, sealed []byte) (string, error) {
+	wrapped, err := seal(k.keys[k.primary], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		version,
+		k.primary,
+		base64.RawURLEncoding.EncodeToString(wrapped),
+		base64.RawURLEncoding.EncodeToString(sealed),
+	}, separator), nil
+}
+
+func (k *Keyring) unwrap(ciphertext string) (string, []byte, []byte, error) {
+	parts := strings.Split(ciphertext, separator)
+	if len(parts) != partsCount || parts[0] != version {
+		return "", nil, nil, ErrInvalidCiphertext
+	}
+
+	master, ok := k.keys[parts[1]]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("%w: %q", ErrUnknownKey, parts[1])
+	}
+
+	wrapped, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrInvalidCiphertext
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", nil, nil, ErrInvalidCiphertext
+	}
+
+	dataKey, err := open(master, wrapped)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return parts[1], dataKey, sealed, nil
+}
+
+// seal encrypts plaintext with AES-GCM and prepends random nonce.
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts output of seal.
+func open(key, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, body := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, body, nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	return cipher.NewGCM(block)
+}
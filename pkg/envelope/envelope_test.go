@@ -0,0 +1,132 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	ring, err := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ct, err := ring.Encrypt([]byte("123456:secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(ct, "secret") {
+		t.Fatal("ciphertext contains plaintext")
+	}
+
+	pt, err := ring.Decrypt(ct)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(pt) != "123456:secret" {
+		t.Fatalf("unexpected plaintext %q", pt)
+	}
+
+	other, _ := ring.Encrypt([]byte("123456:secret"))
+	if other == ct {
+		t.Fatal("expected random data key per encryption")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old, _ := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	ct, _ := old.Encrypt([]byte("token"))
+
+	rotated, err := NewKeyring("k2", map[string][]byte{
+		"k1": testKey(1),
+		"k2": testKey(2),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !rotated.NeedsRewrap(ct) {
+		t.Fatal("expected old ciphertext to need rewrap")
+	}
+
+	pt, err := rotated.Decrypt(ct)
+	if err != nil || string(pt) != "token" {
+		t.Fatalf("expected old ciphertext to decrypt, got %q, %v", pt, err)
+	}
+
+	rewrapped, err := rotated.Rewrap(ct)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rotated.NeedsRewrap(rewrapped) {
+		t.Fatal("expected rewrapped ciphertext to use primary key")
+	}
+
+	current, _ := NewKeyring("k2", map[string][]byte{"k2": testKey(2)})
+	pt, err = current.Decrypt(rewrapped)
+	if err != nil || string(pt) != "token" {
+		t.Fatalf("expected rewrapped ciphertext to decrypt, got %q, %v", pt, err)
+	}
+
+	if _, err := current.Decrypt(ct); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	ring, _ := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	ct, _ := ring.Encrypt([]byte("token"))
+
+	tampered := ct[:len(ct)-2] + "AA"
+	if tampered == ct {
+		tampered = ct[:len(ct)-2] + "BB"
+	}
+
+	if _, err := ring.Decrypt(tampered); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Fatalf("expected ErrInvalidCiphertext, got %v", err)
+	}
+
+	if _, err := ring.Decrypt("garbage"); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Fatalf("expected ErrInvalidCiphertext, got %v", err)
+	}
+}
+
+func TestNewKeyringValidation(t *testing.T) {
+	if _, err := NewKeyring("k1", nil); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+
+	if _, err := NewKeyring("k1", map[string][]byte{"k1": []byte("short")}); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+
+	if _, err := NewKeyring("k2", map[string][]byte{"k1": testKey(1)}); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys(map[string]string{
+		"k1": "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(keys["k1"], testKey(1)) {
+		t.Fatal("unexpected decoded key")
+	}
+
+	if _, err := ParseKeys(map[string]string{"k1": "%%%"}); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+}